@@ -0,0 +1,72 @@
+// Command importplaces seeds the place hierarchy
+//
+// It takes the same flags and environment as the API. Places are read from
+// the CSV at PLACES_FILE, or the bundled sample if it's unset, and imported
+// in a single transaction.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Trisamudrisvara/goTrip/config"
+	"github.com/Trisamudrisvara/goTrip/geonames"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		slog.Error("error loading configuration", "err", err)
+		os.Exit(1)
+	}
+
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	slog.SetDefault(logging.New(os.Stdout, level))
+
+	var places io.Reader = strings.NewReader(geonames.Bundled)
+	source := "bundled"
+	if path := os.Getenv("PLACES_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			slog.Error("error opening places file", "err", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		places, source = f, path
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.New(ctx, cfg.DB.DSN())
+	if err != nil {
+		slog.Error("error connecting db pool", "err", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	if err := run(ctx, conn, places); err != nil {
+		slog.Error("error importing places", "source", source, "err", err)
+		os.Exit(1)
+	}
+}
+
+// run imports the places, all or nothing
+func run(ctx context.Context, conn *pgxpool.Pool, places io.Reader) error {
+	n, err := geonames.Import(ctx, conn, places)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("imported places", "count", n)
+	return nil
+}
@@ -0,0 +1,477 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"golang.org/x/text/language"
+
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+// Config holds every setting goTrip needs at startup
+//
+// Values are loaded with the following precedence (highest wins):
+// CLI flags, environment, .env file, YAML/TOML config file, defaults
+type Config struct {
+	// development or production, selects the defaults of
+	// the CORS and security header settings
+	Env string `env:"APP_ENV"`
+	// Port the API listens on
+	APIPort int `env:"API_PORT"`
+	// debug, info, warn or error
+	LogLevel string `env:"LOG_LEVEL"`
+
+	// JWT signing key, at least 32 bytes
+	Secret string `env:"SECRET"`
+	// uuid of the owner account
+	OwnerUUID string `env:"OWNER_UUID"`
+	// lifetime of issued JWTs
+	JWTExpiry time.Duration `env:"JWT_EXPIRY"`
+
+	DB        DB
+	RateLimit RateLimit
+	CSRF      CSRF
+	CORS      CORS
+	Headers   Headers
+	Media     Media
+	I18n      I18n
+	Trash     Trash
+	Mail      Mail
+}
+
+// DB holds the database connection settings
+// URL takes priority over the individual fields
+type DB struct {
+	URL     string `env:"DB_URL"`
+	Host    string `env:"HOST"`
+	User    string `env:"USER"`
+	Pass    string `env:"PASS"`
+	Name    string `env:"NAME"`
+	Port    string `env:"PORT"`
+	SSLMode string `env:"SSLMODE"`
+}
+
+// RateLimit holds the rate limit policy of each route group
+type RateLimit struct {
+	// login and register
+	Auth Policy `env:"RATE_LIMIT_AUTH"`
+	// GET, HEAD and OPTIONS requests
+	Read Policy `env:"RATE_LIMIT_READ"`
+	// every other request
+	Write Policy `env:"RATE_LIMIT_WRITE"`
+}
+
+// CSRF holds the CSRF protection settings
+type CSRF struct {
+	// header checked before the csrf form field, its value must match the cookie
+	Header string `env:"CSRF_HEADER"`
+	// skip CSRF for requests authenticated with an Authorization: Bearer header
+	SkipBearer bool `env:"CSRF_SKIP_BEARER"`
+	// lifetime of a token
+	Expiration time.Duration `env:"CSRF_EXPIRATION"`
+	// how often expired tokens are purged from the csrf_token table
+	PurgeInterval time.Duration `env:"CSRF_PURGE_INTERVAL"`
+}
+
+// CORS holds the cross origin settings
+type CORS struct {
+	// origins allowed to call the API, empty disables CORS
+	AllowOrigins []string `env:"CORS_ALLOW_ORIGINS"`
+	// allow cookies, needed for the csrf cookie, can't be used with "*"
+	AllowCredentials bool `env:"CORS_ALLOW_CREDENTIALS"`
+	// how long preflight responses may be cached
+	MaxAge time.Duration `env:"CORS_MAX_AGE"`
+}
+
+// Headers holds the security header settings
+type Headers struct {
+	// Strict-Transport-Security max-age, 0 disables it
+	HSTSMaxAge time.Duration `env:"HSTS_MAX_AGE"`
+	// Content-Security-Policy of API responses
+	CSP string `env:"CSP"`
+	// Content-Security-Policy of the swagger UI, which loads its assets from unpkg
+	DocsCSP        string `env:"DOCS_CSP"`
+	ReferrerPolicy string `env:"REFERRER_POLICY"`
+}
+
+// Media holds the upload and storage settings of destination photos
+type Media struct {
+	// local or s3
+	Store string `env:"MEDIA_STORE"`
+	// largest accepted upload in bytes
+	MaxSize int `env:"MEDIA_MAX_SIZE"`
+	// lifetime of the signed URLs media is served from
+	URLExpiry time.Duration `env:"MEDIA_URL_EXPIRY"`
+	// directory files are kept in by the local store
+	Dir string `env:"MEDIA_DIR"`
+	// URL prefix of files served by the local store, a path such as /media
+	// or an absolute URL, files are served under its path
+	BaseURL string `env:"MEDIA_BASE_URL"`
+	S3      S3
+}
+
+// S3 holds the settings of an S3 compatible bucket, e.g. AWS or MinIO
+type S3 struct {
+	// e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Endpoint  string `env:"S3_ENDPOINT"`
+	Region    string `env:"S3_REGION"`
+	Bucket    string `env:"S3_BUCKET"`
+	AccessKey string `env:"S3_ACCESS_KEY"`
+	SecretKey string `env:"S3_SECRET_KEY"`
+	// address the bucket as endpoint/bucket instead of bucket.endpoint,
+	// needed by most self hosted stores
+	PathStyle bool `env:"S3_PATH_STYLE"`
+}
+
+// I18n holds the languages destinations are written and translated in
+type I18n struct {
+	// BCP 47 locale of the content stored on destinations themselves
+	DefaultLocale string `env:"DEFAULT_LOCALE"`
+	// locales every destination should be translated to
+	Locales []string `env:"LOCALES"`
+}
+
+// Trash holds the settings of soft deleted destinations and trips
+type Trash struct {
+	// days deleted items are kept before being purged, 0 keeps them forever
+	RetentionDays int `env:"TRASH_RETENTION_DAYS"`
+	// how often items older than RetentionDays are purged
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL"`
+}
+
+// Mail holds the SMTP settings of the emails goTrip sends, such as trip
+// invitations
+type Mail struct {
+	// emails are only logged when empty, required in production
+	Host string `env:"SMTP_HOST"`
+	Port int    `env:"SMTP_PORT"`
+	User string `env:"SMTP_USER"`
+	Pass string `env:"SMTP_PASS"`
+	// sender address, e.g. goTrip <noreply@example.com>
+	From string `env:"MAIL_FROM"`
+}
+
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// Default returns the configuration used when nothing else is set
+func Default() *Config {
+	return &Config{
+		Env:       Development,
+		APIPort:   8080,
+		LogLevel:  "info",
+		JWTExpiry: 72 * time.Hour,
+		DB: DB{
+			SSLMode: "disable",
+		},
+		RateLimit: RateLimit{
+			Auth:  Policy{Max: 5, Expiration: time.Minute},
+			Read:  Policy{Max: 120, Expiration: time.Minute},
+			Write: Policy{Max: 30, Expiration: time.Minute},
+		},
+		CSRF: CSRF{
+			Header:        "X-CSRF-Token",
+			SkipBearer:    true,
+			Expiration:    time.Hour,
+			PurgeInterval: 10 * time.Minute,
+		},
+		CORS: CORS{
+			AllowOrigins: []string{"*"},
+			MaxAge:       time.Hour,
+		},
+		Headers: Headers{
+			CSP: "default-src 'none'; frame-ancestors 'none'",
+			DocsCSP: "default-src 'self'; " +
+				"script-src 'self' 'unsafe-inline' https://unpkg.com; " +
+				"style-src 'self' 'unsafe-inline' https://unpkg.com; " +
+				"img-src 'self' data: https://unpkg.com; " +
+				"frame-ancestors 'none'",
+			ReferrerPolicy: "no-referrer",
+		},
+		Media: Media{
+			Store:     "local",
+			MaxSize:   10 << 20,
+			URLExpiry: time.Hour,
+			Dir:       "uploads",
+			BaseURL:   "/media",
+			S3: S3{
+				Region:    "us-east-1",
+				PathStyle: true,
+			},
+		},
+		I18n: I18n{
+			DefaultLocale: "en",
+		},
+		Trash: Trash{
+			RetentionDays: 30,
+			PurgeInterval: time.Hour,
+		},
+		Mail: Mail{
+			Port: 587,
+		},
+	}
+}
+
+// Preset returns the defaults for the given environment
+func Preset(env string) *Config {
+	cfg := Default()
+	cfg.Env = env
+
+	if env == Production {
+		// origins must be listed explicitly, cookies are allowed
+		// so that a front-end on another origin can use the csrf cookie
+		cfg.CORS.AllowOrigins = nil
+		cfg.CORS.AllowCredentials = true
+		cfg.Headers.HSTSMaxAge = 365 * 24 * time.Hour
+	}
+
+	return cfg
+}
+
+// Load builds the configuration from defaults, an optional config file,
+// the .env file, the environment and the given CLI arguments,
+// then validates it
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("goTrip", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to a YAML or TOML config file")
+	fs.String("port", "", "port the API listens on (API_PORT)")
+	fs.String("log-level", "", "debug, info, warn or error (LOG_LEVEL)")
+	fs.String("db-url", "", "database connection string (DB_URL)")
+	fs.String("env", "", "development or production (APP_ENV)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// .env is optional, unlike godotenv.Load it doesn't touch the environment
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading .env file: %w", err)
+	}
+
+	env := environ()
+
+	// config file path: flag, then environment, then .env
+	path := *configFile
+	if path == "" {
+		path = env["CONFIG_FILE"]
+	}
+	if path == "" {
+		path = dotenv["CONFIG_FILE"]
+	}
+
+	file := map[string]string{}
+	if path != "" {
+		if file, err = readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// only flags that were explicitly set override other sources
+	flags := map[string]string{}
+	flagKeys := map[string]string{
+		"port":      "API_PORT",
+		"log-level": "LOG_LEVEL",
+		"db-url":    "DB_URL",
+		"env":       "APP_ENV",
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			flags[key] = f.Value.String()
+		}
+	})
+
+	// lowest precedence first
+	sources := []map[string]string{file, dotenv, env, flags}
+
+	// the environment picks the defaults, so it's resolved first
+	appEnv := Development
+	for _, source := range sources {
+		if v, ok := source["APP_ENV"]; ok && v != "" {
+			appEnv = v
+		}
+	}
+
+	cfg := Preset(appEnv)
+
+	for _, source := range sources {
+		if err := apply(cfg, source); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that required values are present and well formed
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Env != Development && c.Env != Production {
+		errs = append(errs, fmt.Errorf("APP_ENV %q must be %s or %s", c.Env, Development, Production))
+	}
+
+	if len(c.Secret) < 32 {
+		errs = append(errs, errors.New("SECRET must be at least 32 bytes long"))
+	}
+
+	if id, err := uuid.Parse(c.OwnerUUID); err != nil {
+		errs = append(errs, fmt.Errorf("OWNER_UUID %q is not a valid uuid", c.OwnerUUID))
+	} else {
+		// normalize so it can be compared with uuids from the db
+		c.OwnerUUID = id.String()
+	}
+
+	if c.APIPort < 1 || c.APIPort > 65535 {
+		errs = append(errs, fmt.Errorf("API_PORT %d is out of range", c.APIPort))
+	}
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL %q is invalid", c.LogLevel))
+	}
+
+	if c.JWTExpiry <= 0 {
+		errs = append(errs, errors.New("JWT_EXPIRY must be positive"))
+	}
+
+	if c.DB.URL == "" && (c.DB.Host == "" || c.DB.User == "" || c.DB.Name == "") {
+		errs = append(errs, errors.New("either DB_URL or HOST, USER and NAME must be set"))
+	}
+
+	if err := c.RateLimit.Auth.validate("RATE_LIMIT_AUTH"); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.RateLimit.Read.validate("RATE_LIMIT_READ"); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.RateLimit.Write.validate("RATE_LIMIT_WRITE"); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.CSRF.Header == "" {
+		errs = append(errs, errors.New("CSRF_HEADER must be set"))
+	}
+
+	if c.CSRF.Expiration <= 0 || c.CSRF.PurgeInterval < time.Second {
+		errs = append(errs, errors.New("CSRF_EXPIRATION must be positive and CSRF_PURGE_INTERVAL at least 1s"))
+	}
+
+	if slices.Contains(c.CORS.AllowOrigins, "*") {
+		if c.CORS.AllowCredentials {
+			errs = append(errs, errors.New("CORS_ALLOW_CREDENTIALS can't be used with CORS_ALLOW_ORIGINS=*"))
+		}
+		if c.Env == Production {
+			errs = append(errs, errors.New("CORS_ALLOW_ORIGINS=* isn't allowed in production"))
+		}
+	}
+
+	switch c.Media.Store {
+	case "local":
+		if c.Media.Dir == "" {
+			errs = append(errs, errors.New("MEDIA_DIR must be set when MEDIA_STORE is local"))
+		}
+		// files are served under its path, the root would shadow every route
+		if u, err := url.Parse(c.Media.BaseURL); err != nil || strings.Trim(u.Path, "/") == "" {
+			errs = append(errs, fmt.Errorf("MEDIA_BASE_URL %q must be a path such as /media or a URL ending in one", c.Media.BaseURL))
+		} else {
+			c.Media.BaseURL = strings.TrimSuffix(c.Media.BaseURL, "/")
+		}
+	case "s3":
+		if c.Media.S3.Endpoint == "" || c.Media.S3.Bucket == "" ||
+			c.Media.S3.AccessKey == "" || c.Media.S3.SecretKey == "" {
+			errs = append(errs, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY must be set when MEDIA_STORE is s3"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("MEDIA_STORE %q must be local or s3", c.Media.Store))
+	}
+
+	if c.Media.MaxSize < 1 {
+		errs = append(errs, errors.New("MEDIA_MAX_SIZE must be positive"))
+	}
+
+	// presigned S3 URLs can't be valid for longer than a week
+	if c.Media.URLExpiry < time.Second || c.Media.URLExpiry > 7*24*time.Hour {
+		errs = append(errs, errors.New("MEDIA_URL_EXPIRY must be between 1s and 168h"))
+	}
+
+	// invitation tokens are only delivered by email
+	if c.Env == Production && c.Mail.Host == "" {
+		errs = append(errs, errors.New("SMTP_HOST must be set in production"))
+	}
+
+	if c.Mail.Host != "" {
+		if c.Mail.Port < 1 || c.Mail.Port > 65535 {
+			errs = append(errs, fmt.Errorf("SMTP_PORT %d is out of range", c.Mail.Port))
+		}
+		if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+			errs = append(errs, fmt.Errorf("MAIL_FROM %q is not a valid address", c.Mail.From))
+		}
+	}
+
+	if c.Trash.RetentionDays < 0 || c.Trash.PurgeInterval < time.Second {
+		errs = append(errs, errors.New("TRASH_RETENTION_DAYS can't be negative and TRASH_PURGE_INTERVAL must be at least 1s"))
+	}
+
+	// normalize so they can be compared with locales from requests
+	if tag, err := language.Parse(c.I18n.DefaultLocale); err != nil {
+		errs = append(errs, fmt.Errorf("DEFAULT_LOCALE %q is not a valid locale", c.I18n.DefaultLocale))
+	} else {
+		c.I18n.DefaultLocale = tag.String()
+	}
+
+	var locales []string
+	for _, locale := range c.I18n.Locales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("LOCALES %q is not a valid locale", locale))
+			continue
+		}
+		// the default locale needs no translation
+		if locale = tag.String(); locale != c.I18n.DefaultLocale && !slices.Contains(locales, locale) {
+			locales = append(locales, locale)
+		}
+	}
+	c.I18n.Locales = locales
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// DSN returns the database connection string
+func (d DB) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.User, d.Pass, d.Name, d.SSLMode)
+
+	// Add port to connection string if specified
+	if d.Port != "" {
+		dsn += " port=" + d.Port
+	}
+
+	return dsn
+}
@@ -0,0 +1,303 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chdir moves into dir for the rest of the test, Load reads .env from there
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// unsetenv removes keys from the environment for the rest of the test
+func unsetenv(t *testing.T, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		// Setenv restores the previous value once the test ends
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		dotenv string
+		env    map[string]string
+		args   []string
+
+		wantLevel string
+		wantPort  int
+		wantHSTS  time.Duration
+	}{
+		{
+			name:      "preset",
+			wantLevel: "info",
+			wantPort:  8080,
+		},
+		{
+			name:      "file over preset",
+			file:      "log_level: debug\napi_port: 9000\n",
+			wantLevel: "debug",
+			wantPort:  9000,
+		},
+		{
+			name:      "dotenv over file",
+			file:      "log_level: debug\napi_port: 9000\n",
+			dotenv:    "LOG_LEVEL=warn\n",
+			wantLevel: "warn",
+			wantPort:  9000,
+		},
+		{
+			name:      "env over dotenv",
+			file:      "log_level: debug\n",
+			dotenv:    "LOG_LEVEL=warn\nAPI_PORT=9001\n",
+			env:       map[string]string{"LOG_LEVEL": "error"},
+			wantLevel: "error",
+			wantPort:  9001,
+		},
+		{
+			name:      "flags over env",
+			dotenv:    "API_PORT=9001\n",
+			env:       map[string]string{"LOG_LEVEL": "error", "API_PORT": "9002"},
+			args:      []string{"-log-level", "debug", "-port", "9003"},
+			wantLevel: "debug",
+			wantPort:  9003,
+		},
+		{
+			name:      "app env picks the preset",
+			file:      "app_env: production\n",
+			wantLevel: "info",
+			wantPort:  8080,
+			wantHSTS:  365 * 24 * time.Hour,
+		},
+		{
+			name:      "preset values can be overridden",
+			dotenv:    "APP_ENV=production\nHSTS_MAX_AGE=1h\n",
+			wantLevel: "info",
+			wantPort:  8080,
+			wantHSTS:  time.Hour,
+		},
+		{
+			name:      "app env flag over dotenv",
+			dotenv:    "APP_ENV=production\n",
+			args:      []string{"-env", "development"},
+			wantLevel: "info",
+			wantPort:  8080,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			chdir(t, dir)
+
+			unsetenv(t, "APP_ENV", "API_PORT", "LOG_LEVEL", "HSTS_MAX_AGE", "CONFIG_FILE")
+
+			// required values, set where every case can see them
+			t.Setenv("SECRET", strings.Repeat("s", 32))
+			t.Setenv("OWNER_UUID", "00000000-0000-0000-0000-000000000001")
+			t.Setenv("DB_URL", "postgres://localhost/trip")
+			t.Setenv("CORS_ALLOW_ORIGINS", "https://app.example.com")
+			t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+			t.Setenv("SMTP_HOST", "localhost")
+			t.Setenv("MAIL_FROM", "noreply@example.com")
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			if tt.dotenv != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(tt.dotenv), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(dir, "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append([]string{"-config", path}, args...)
+			}
+
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cfg.LogLevel != tt.wantLevel {
+				t.Errorf("LogLevel = %q, want %q", cfg.LogLevel, tt.wantLevel)
+			}
+			if cfg.APIPort != tt.wantPort {
+				t.Errorf("APIPort = %d, want %d", cfg.APIPort, tt.wantPort)
+			}
+			if cfg.Headers.HSTSMaxAge != tt.wantHSTS {
+				t.Errorf("HSTSMaxAge = %s, want %s", cfg.Headers.HSTSMaxAge, tt.wantHSTS)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		dotenv string
+		args   []string
+		want   string
+	}{
+		{"unknown flag", "", []string{"-unknown"}, "flag provided but not defined"},
+		{"bad int", "API_PORT=eighty\n", nil, "invalid value for API_PORT"},
+		{"bad duration", "JWT_EXPIRY=3 days\n", nil, "invalid value for JWT_EXPIRY"},
+		{"bad policy", "RATE_LIMIT_AUTH=5\n", nil, "invalid value for RATE_LIMIT_AUTH"},
+		{"unsupported file", "CONFIG_FILE=config.json\n", nil, "unsupported config file type"},
+		{"missing file", "CONFIG_FILE=missing.yaml\n", nil, "error reading config file"},
+		{"validated", "SECRET=short\n", nil, "SECRET must be at least 32 bytes long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			chdir(t, dir)
+
+			unsetenv(t, "APP_ENV", "API_PORT", "JWT_EXPIRY", "RATE_LIMIT_AUTH", "CONFIG_FILE", "SECRET")
+			t.Setenv("OWNER_UUID", "00000000-0000-0000-0000-000000000001")
+			t.Setenv("DB_URL", "postgres://localhost/trip")
+
+			dotenv := "SECRET=" + strings.Repeat("s", 32) + "\n" + tt.dotenv
+			if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(dotenv), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// valid returns a development configuration that passes Validate
+func valid() *Config {
+	cfg := Default()
+	cfg.Secret = strings.Repeat("s", 32)
+	cfg.OwnerUUID = "00000000-0000-0000-0000-000000000001"
+	cfg.DB.URL = "postgres://localhost/trip"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"db fields instead of url", func(c *Config) {
+			c.DB = DB{Host: "localhost", User: "trip", Name: "trip"}
+		}, ""},
+		{"env", func(c *Config) { c.Env = "staging" }, `APP_ENV "staging"`},
+		{"short secret", func(c *Config) { c.Secret = "secret" }, "SECRET must be at least 32 bytes long"},
+		{"owner uuid", func(c *Config) { c.OwnerUUID = "admin" }, `OWNER_UUID "admin" is not a valid uuid`},
+		{"port zero", func(c *Config) { c.APIPort = 0 }, "API_PORT 0 is out of range"},
+		{"port too high", func(c *Config) { c.APIPort = 65536 }, "API_PORT 65536 is out of range"},
+		{"log level", func(c *Config) { c.LogLevel = "verbose" }, `LOG_LEVEL "verbose" is invalid`},
+		{"jwt expiry", func(c *Config) { c.JWTExpiry = 0 }, "JWT_EXPIRY must be positive"},
+		{"no db", func(c *Config) { c.DB = DB{Host: "localhost"} }, "either DB_URL or HOST, USER and NAME must be set"},
+		{"auth rate limit", func(c *Config) { c.RateLimit.Auth.Max = 0 }, "RATE_LIMIT_AUTH"},
+		{"read rate limit", func(c *Config) { c.RateLimit.Read.Expiration = time.Millisecond }, "RATE_LIMIT_READ"},
+		{"write rate limit", func(c *Config) { c.RateLimit.Write = Policy{} }, "RATE_LIMIT_WRITE"},
+		{"csrf header", func(c *Config) { c.CSRF.Header = "" }, "CSRF_HEADER must be set"},
+		{"csrf purge interval", func(c *Config) { c.CSRF.PurgeInterval = time.Millisecond }, "CSRF_PURGE_INTERVAL"},
+		{"cors credentials with any origin", func(c *Config) { c.CORS.AllowCredentials = true }, "CORS_ALLOW_CREDENTIALS can't be used with CORS_ALLOW_ORIGINS=*"},
+		{"cors any origin in production", func(c *Config) {
+			c.Env = Production
+			c.Mail = Mail{Host: "localhost", Port: 587, From: "noreply@example.com"}
+		}, "CORS_ALLOW_ORIGINS=* isn't allowed in production"},
+		{"media store", func(c *Config) { c.Media.Store = "ftp" }, `MEDIA_STORE "ftp" must be local or s3`},
+		{"media dir", func(c *Config) { c.Media.Dir = "" }, "MEDIA_DIR must be set"},
+		{"media base url", func(c *Config) { c.Media.BaseURL = "https://api.example.com/media" }, ""},
+		{"media base url root", func(c *Config) { c.Media.BaseURL = "/" }, `MEDIA_BASE_URL "/" must be a path`},
+		{"media base url without path", func(c *Config) { c.Media.BaseURL = "https://api.example.com" }, "MEDIA_BASE_URL"},
+		{"s3 settings", func(c *Config) { c.Media.Store = "s3" }, "S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY must be set"},
+		{"media max size", func(c *Config) { c.Media.MaxSize = 0 }, "MEDIA_MAX_SIZE must be positive"},
+		{"media url expiry", func(c *Config) { c.Media.URLExpiry = 8 * 24 * time.Hour }, "MEDIA_URL_EXPIRY must be between 1s and 168h"},
+		{"smtp host in production", func(c *Config) {
+			c.Env = Production
+			c.CORS.AllowOrigins = nil
+		}, "SMTP_HOST must be set in production"},
+		{"smtp port", func(c *Config) {
+			c.Mail = Mail{Host: "localhost", Port: 0, From: "noreply@example.com"}
+		}, "SMTP_PORT 0 is out of range"},
+		{"mail from", func(c *Config) {
+			c.Mail = Mail{Host: "localhost", Port: 587, From: "noreply"}
+		}, `MAIL_FROM "noreply" is not a valid address`},
+		{"trash retention", func(c *Config) { c.Trash.RetentionDays = -1 }, "TRASH_RETENTION_DAYS can't be negative"},
+		{"default locale", func(c *Config) { c.I18n.DefaultLocale = "not a locale" }, `DEFAULT_LOCALE "not a locale" is not a valid locale`},
+		{"locales", func(c *Config) { c.I18n.Locales = []string{"fr", "??"} }, `LOCALES "??" is not a valid locale`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	cfg := valid()
+	cfg.OwnerUUID = "{00000000-0000-0000-0000-00000000000A}"
+	cfg.I18n.DefaultLocale = "EN"
+	cfg.I18n.Locales = []string{"fr-fr", "en", "FR-FR", "de"}
+	cfg.Media.BaseURL = "/files/"
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.OwnerUUID != "00000000-0000-0000-0000-00000000000a" {
+		t.Errorf("OwnerUUID = %q", cfg.OwnerUUID)
+	}
+	if cfg.I18n.DefaultLocale != "en" {
+		t.Errorf("DefaultLocale = %q", cfg.I18n.DefaultLocale)
+	}
+	if got := strings.Join(cfg.I18n.Locales, ","); got != "fr-FR,de" {
+		t.Errorf("Locales = %q, want fr-FR,de", got)
+	}
+	// local store URLs append "/" and the key
+	if cfg.Media.BaseURL != "/files" {
+		t.Errorf("BaseURL = %q, want /files", cfg.Media.BaseURL)
+	}
+}
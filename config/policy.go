@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a rate limit of Max requests per Expiration window
+// written as "max/window", e.g. "5/1m"
+type Policy struct {
+	Max        int
+	Expiration time.Duration
+}
+
+// UnmarshalText parses the "max/window" form
+func (p *Policy) UnmarshalText(text []byte) error {
+	max, window, ok := strings.Cut(string(text), "/")
+	if !ok {
+		return fmt.Errorf("rate limit %q must look like 5/1m", text)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(max))
+	if err != nil {
+		return fmt.Errorf("invalid rate limit max %q", max)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil {
+		return fmt.Errorf("invalid rate limit window %q", window)
+	}
+
+	p.Max = n
+	p.Expiration = d
+
+	return nil
+}
+
+// String returns the policy in its "max/window" form
+func (p Policy) String() string {
+	return fmt.Sprintf("%d/%s", p.Max, p.Expiration)
+}
+
+func (p Policy) validate(key string) error {
+	if p.Max < 1 || p.Expiration < time.Second {
+		return fmt.Errorf("%s %s must allow at least 1 request per window of at least 1s", key, p)
+	}
+	return nil
+}
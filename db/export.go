@@ -0,0 +1,155 @@
+package db
+
+// written by hand, sqlc collects :many queries into slices while exports
+// have to stream their rows to keep memory flat on large tables
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const exportDestinations = `-- name: ExportDestinations :many
+SELECT d.id, d.name, d.description, d.attraction,
+ d.latitude, d.longitude, d.country, d.region, d.city, d.place_id,
+ coalesce(r.average_rating, 0)::float8 AS average_rating,
+ coalesce(r.review_count, 0)::int8 AS review_count,
+ coalesce((
+   SELECT array_agg(t.slug ORDER BY t.slug)
+   FROM destination_tag dt
+   JOIN tag t ON t.id = dt.tag_id
+   WHERE dt.destination_id = d.id
+ ), '{}')::text[] AS tags
+FROM destination d
+LEFT JOIN (
+  SELECT destination_id, avg(rating) AS average_rating, count(*) AS review_count
+  FROM review
+  WHERE status <> 'hidden'
+  GROUP BY destination_id
+) r ON r.destination_id = d.id
+WHERE d.deleted_at IS NULL
+ AND (cardinality($1::text[]) = 0 OR d.id IN (
+   SELECT dt.destination_id
+   FROM destination_tag dt
+   JOIN tag t ON t.id = dt.tag_id
+   WHERE t.slug = ANY($1::text[])
+   GROUP BY dt.destination_id
+   HAVING count(*) = cardinality($1::text[])
+ ))
+ORDER BY
+ CASE WHEN $2::bool THEN coalesce(r.average_rating, 0) END DESC,
+ CASE WHEN $2::bool THEN coalesce(r.review_count, 0) END DESC,
+ d.name, d.id
+`
+
+type ExportDestinationsParams struct {
+	Slugs    []string
+	ByRating bool
+}
+
+type ExportDestinationsRow struct {
+	ID            pgtype.UUID
+	Name          string
+	Description   string
+	Attraction    string
+	Latitude      pgtype.Float8
+	Longitude     pgtype.Float8
+	Country       pgtype.Text
+	Region        pgtype.Text
+	City          pgtype.Text
+	PlaceID       pgtype.UUID
+	AverageRating float64
+	ReviewCount   int64
+	Tags          []string
+}
+
+// ExportDestinationsRows iterates over the rows of ExportDestinations,
+// it has to be closed
+type ExportDestinationsRows struct {
+	pgx.Rows
+}
+
+func (r ExportDestinationsRows) Row() (ExportDestinationsRow, error) {
+	var i ExportDestinationsRow
+	err := r.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Attraction,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Country,
+		&i.Region,
+		&i.City,
+		&i.PlaceID,
+		&i.AverageRating,
+		&i.ReviewCount,
+		&i.Tags,
+	)
+	return i, err
+}
+
+// destinations having every one of the given tags, slugs must be distinct
+// an empty list exports every destination
+func (q *Queries) ExportDestinations(ctx context.Context, arg ExportDestinationsParams) (ExportDestinationsRows, error) {
+	rows, err := q.db.Query(ctx, exportDestinations, arg.Slugs, arg.ByRating)
+	return ExportDestinationsRows{rows}, err
+}
+
+const exportTrips = `-- name: ExportTrips :many
+SELECT t.id, t.name, t.start_date, t.end_date, t.destination_id,
+ d.name AS destination_name,
+ (t.destination_id IS NULL OR d.deleted_at IS NOT NULL)::bool AS destination_deleted,
+ t.user_id
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE t.deleted_at IS NULL
+ AND ($1::bool OR EXISTS (
+   SELECT 1 FROM trip_member m
+   WHERE m.trip_id = t.id AND m.user_id = $2
+ ))
+ORDER BY t.start_date, t.name, t.id
+`
+
+type ExportTripsParams struct {
+	All    bool
+	UserID pgtype.UUID
+}
+
+type ExportTripsRow struct {
+	ID                 pgtype.UUID
+	Name               string
+	StartDate          string
+	EndDate            string
+	DestinationID      pgtype.UUID
+	DestinationName    pgtype.Text
+	DestinationDeleted bool
+	UserID             pgtype.UUID
+}
+
+// ExportTripsRows iterates over the rows of ExportTrips, it has to be closed
+type ExportTripsRows struct {
+	pgx.Rows
+}
+
+func (r ExportTripsRows) Row() (ExportTripsRow, error) {
+	var i ExportTripsRow
+	err := r.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartDate,
+		&i.EndDate,
+		&i.DestinationID,
+		&i.DestinationName,
+		&i.DestinationDeleted,
+		&i.UserID,
+	)
+	return i, err
+}
+
+// trips a user is a member of, or every trip if all is set
+func (q *Queries) ExportTrips(ctx context.Context, arg ExportTripsParams) (ExportTripsRows, error) {
+	rows, err := q.db.Query(ctx, exportTrips, arg.All, arg.UserID)
+	return ExportTripsRows{rows}, err
+}
@@ -8,11 +8,254 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Activity struct {
+	ID            pgtype.UUID
+	TripID        pgtype.UUID
+	Title         string
+	Category      string
+	Day           pgtype.Date
+	StartTime     pgtype.Time
+	EndTime       pgtype.Time
+	Location      string
+	DestinationID pgtype.UUID
+	AttractionID  pgtype.UUID
+	Notes         string
+	CreatedAt     pgtype.Timestamptz
+}
+
+type Attraction struct {
+	ID                   pgtype.UUID
+	DestinationID        pgtype.UUID
+	Name                 string
+	Category             string
+	Description          string
+	OpeningHours         pgtype.Text
+	VisitDurationMinutes pgtype.Int4
+	PriceLevel           pgtype.Int4
+	Latitude             pgtype.Float8
+	Longitude            pgtype.Float8
+}
+
+type Booking struct {
+	ID               pgtype.UUID
+	TripID           pgtype.UUID
+	Kind             string
+	Title            string
+	Provider         string
+	ConfirmationCode string
+	StartsAt         pgtype.Timestamptz
+	StartZone        string
+	EndsAt           pgtype.Timestamptz
+	EndZone          pgtype.Text
+	Address          string
+	Cost             pgtype.Int8
+	Currency         pgtype.Text
+	Notes            string
+	CreatedBy        pgtype.UUID
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Sequence         int32
+}
+
+type BookingAttachment struct {
+	ID          pgtype.UUID
+	BookingID   pgtype.UUID
+	Key         string
+	Filename    string
+	ContentType string
+	Size        int64
+	CreatedAt   pgtype.Timestamptz
+}
+
+type CalendarFeed struct {
+	UserID    pgtype.UUID
+	Token     string
+	CreatedAt pgtype.Timestamptz
+}
+
+type Checklist struct {
+	ID         pgtype.UUID
+	TripID     pgtype.UUID
+	Name       string
+	TemplateID pgtype.UUID
+	CreatedAt  pgtype.Timestamptz
+}
+
+type ChecklistItem struct {
+	ID          pgtype.UUID
+	ChecklistID pgtype.UUID
+	Title       string
+	AssigneeID  pgtype.UUID
+	Done        bool
+	DoneAt      pgtype.Timestamptz
+	Position    int32
+	CreatedAt   pgtype.Timestamptz
+}
+
+type ChecklistTemplate struct {
+	ID          pgtype.UUID
+	UserID      pgtype.UUID
+	Name        string
+	Description string
+	MinDays     pgtype.Int4
+	MaxDays     pgtype.Int4
+	TagID       pgtype.UUID
+	CreatedAt   pgtype.Timestamptz
+}
+
+type ChecklistTemplateItem struct {
+	ID         pgtype.UUID
+	TemplateID pgtype.UUID
+	Title      string
+	Position   int32
+}
+
 type Destination struct {
 	ID          pgtype.UUID
 	Name        string
 	Description string
 	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+	PlaceID     pgtype.UUID
+	DeletedAt   pgtype.Timestamptz
+}
+
+type DestinationRevision struct {
+	DestinationID pgtype.UUID
+	Version       int32
+	Action        string
+	Snapshot      []byte
+	EditorID      pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+}
+
+type DestinationTag struct {
+	DestinationID pgtype.UUID
+	TagID         pgtype.UUID
+}
+
+type DestinationTranslation struct {
+	DestinationID pgtype.UUID
+	Locale        string
+	Name          string
+	Description   string
+	Attraction    string
+	UpdatedAt     pgtype.Timestamptz
+}
+
+type ExchangeRate struct {
+	Base      string
+	Quote     string
+	Rate      pgtype.Numeric
+	UpdatedAt pgtype.Timestamptz
+}
+
+type Expense struct {
+	ID        pgtype.UUID
+	TripID    pgtype.UUID
+	Amount    int64
+	Currency  string
+	Category  string
+	PaidBy    pgtype.UUID
+	SpentOn   pgtype.Date
+	Note      string
+	CreatedBy pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+type ImportJob struct {
+	ID            pgtype.UUID
+	Status        string
+	Format        string
+	Mode          string
+	DryRun        bool
+	TotalRows     int32
+	ProcessedRows int32
+	CreatedRows   int32
+	UpdatedRows   int32
+	FailedRows    int32
+	Errors        []byte
+	EditorID      pgtype.UUID
+	CreatedAt     pgtype.Timestamptz
+	FinishedAt    pgtype.Timestamptz
+}
+
+type LedgerParticipant struct {
+	ID        pgtype.UUID
+	TripID    pgtype.UUID
+	Name      string
+	UserID    pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+type LedgerPayment struct {
+	ID          pgtype.UUID
+	TripID      pgtype.UUID
+	PaidBy      pgtype.UUID
+	Amount      int64
+	Currency    string
+	Description string
+	Split       string
+	CreatedBy   pgtype.UUID
+	CreatedAt   pgtype.Timestamptz
+}
+
+type LedgerSplit struct {
+	PaymentID     pgtype.UUID
+	ParticipantID pgtype.UUID
+	Shares        int32
+	Amount        int64
+}
+
+type MediaFile struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	Key           string
+	ThumbnailKey  string
+	ContentType   string
+	Size          int64
+	Width         int32
+	Height        int32
+	Caption       string
+	Position      int32
+	IsCover       bool
+	CreatedAt     pgtype.Timestamptz
+}
+
+type Place struct {
+	ID          pgtype.UUID
+	ParentID    pgtype.UUID
+	Kind        string
+	Name        string
+	CountryCode string
+	GeonameID   pgtype.Int8
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Admin1Code  pgtype.Text
+}
+
+type Review struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	UserID        pgtype.UUID
+	Rating        int32
+	Title         string
+	Body          string
+	VisitDate     pgtype.Date
+	Status        string
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+type Tag struct {
+	ID       pgtype.UUID
+	Slug     string
+	Name     string
+	Category string
 }
 
 type Trip struct {
@@ -21,6 +264,49 @@ type Trip struct {
 	StartDate     string
 	EndDate       string
 	DestinationID pgtype.UUID
+	UserID        pgtype.UUID
+	DeletedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	Sequence      int32
+	Budget        pgtype.Int8
+	Currency      string
+}
+
+type TripDestination struct {
+	TripID        pgtype.UUID
+	DestinationID pgtype.UUID
+	Position      int32
+}
+
+type TripInvitation struct {
+	ID          pgtype.UUID
+	TripID      pgtype.UUID
+	Email       string
+	Role        string
+	Token       string
+	InvitedBy   pgtype.UUID
+	Status      string
+	CreatedAt   pgtype.Timestamptz
+	RespondedAt pgtype.Timestamptz
+}
+
+type TripMember struct {
+	TripID    pgtype.UUID
+	UserID    pgtype.UUID
+	Role      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type TripShare struct {
+	ID           pgtype.UUID
+	TripID       pgtype.UUID
+	Token        string
+	PasswordHash pgtype.Text
+	ExpiresAt    pgtype.Timestamptz
+	ViewCount    int32
+	LastViewedAt pgtype.Timestamptz
+	CreatedBy    pgtype.UUID
+	CreatedAt    pgtype.Timestamptz
 }
 
 type User struct {
@@ -30,3 +316,20 @@ type User struct {
 	Password string
 	Admin    bool
 }
+
+type Wishlist struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	Name       string
+	IsDefault  bool
+	ShareToken pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+}
+
+type WishlistItem struct {
+	WishlistID    pgtype.UUID
+	DestinationID pgtype.UUID
+	Note          string
+	Position      int32
+	AddedAt       pgtype.Timestamptz
+}
@@ -11,315 +11,5188 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addDestinationTags = `-- name: AddDestinationTags :execrows
+INSERT INTO destination_tag (destination_id, tag_id)
+SELECT $1::uuid, id FROM tag
+ WHERE slug = ANY($2::text[])
+`
+
+type AddDestinationTagsParams struct {
+	DestinationID pgtype.UUID
+	Slugs         []string
+}
+
+// unknown slugs are skipped, compare the rows with the number of slugs
+func (q *Queries) AddDestinationTags(ctx context.Context, arg AddDestinationTagsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addDestinationTags, arg.DestinationID, arg.Slugs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const addTripMember = `-- name: AddTripMember :exec
+INSERT INTO trip_member (trip_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (trip_id, user_id) DO UPDATE
+ SET role = EXCLUDED.role
+WHERE trip_member.role <> 'owner'
+`
+
+type AddTripMemberParams struct {
+	TripID pgtype.UUID
+	UserID pgtype.UUID
+	Role   string
+}
+
+// the role of existing members is replaced, except the owner's
+func (q *Queries) AddTripMember(ctx context.Context, arg AddTripMemberParams) error {
+	_, err := q.db.Exec(ctx, addTripMember, arg.TripID, arg.UserID, arg.Role)
+	return err
+}
+
+const addWishlistItem = `-- name: AddWishlistItem :exec
+INSERT INTO wishlist_item (
+  wishlist_id, destination_id, note, position
+) VALUES (
+  $1, $2, $3,
+  (SELECT coalesce(max(position) + 1, 0) FROM wishlist_item WHERE wishlist_id = $1)
+)
+ON CONFLICT (wishlist_id, destination_id) DO UPDATE
+ SET note = EXCLUDED.note
+`
+
+type AddWishlistItemParams struct {
+	WishlistID    pgtype.UUID
+	DestinationID pgtype.UUID
+	Note          string
+}
+
+// appended to the end of the list, adding it again only updates the note
+func (q *Queries) AddWishlistItem(ctx context.Context, arg AddWishlistItemParams) error {
+	_, err := q.db.Exec(ctx, addWishlistItem, arg.WishlistID, arg.DestinationID, arg.Note)
+	return err
+}
+
+const clearDestinationTags = `-- name: ClearDestinationTags :exec
+DELETE FROM destination_tag
+ WHERE destination_id = $1
+`
+
+func (q *Queries) ClearDestinationTags(ctx context.Context, destinationID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, clearDestinationTags, destinationID)
+	return err
+}
+
+const copyWishlistToTrip = `-- name: CopyWishlistToTrip :exec
+INSERT INTO trip_destination (trip_id, destination_id, position)
+SELECT $1::uuid, i.destination_id, i.position FROM wishlist_item i
+JOIN destination d ON d.id = i.destination_id
+WHERE i.wishlist_id = $2 AND d.deleted_at IS NULL
+`
+
+type CopyWishlistToTripParams struct {
+	TripID     pgtype.UUID
+	WishlistID pgtype.UUID
+}
+
+func (q *Queries) CopyWishlistToTrip(ctx context.Context, arg CopyWishlistToTripParams) error {
+	_, err := q.db.Exec(ctx, copyWishlistToTrip, arg.TripID, arg.WishlistID)
+	return err
+}
+
+const createActivity = `-- name: CreateActivity :exec
+INSERT INTO activity (
+  id, trip_id, title, category, day, start_time, end_time,
+  location, destination_id, attraction_id, notes
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+`
+
+type CreateActivityParams struct {
+	ID            pgtype.UUID
+	TripID        pgtype.UUID
+	Title         string
+	Category      string
+	Day           pgtype.Date
+	StartTime     pgtype.Time
+	EndTime       pgtype.Time
+	Location      string
+	DestinationID pgtype.UUID
+	AttractionID  pgtype.UUID
+	Notes         string
+}
+
+func (q *Queries) CreateActivity(ctx context.Context, arg CreateActivityParams) error {
+	_, err := q.db.Exec(ctx, createActivity,
+		arg.ID,
+		arg.TripID,
+		arg.Title,
+		arg.Category,
+		arg.Day,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Location,
+		arg.DestinationID,
+		arg.AttractionID,
+		arg.Notes,
+	)
+	return err
+}
+
+const createAttraction = `-- name: CreateAttraction :exec
+INSERT INTO attraction (
+  id, destination_id, name, category, description, opening_hours,
+  visit_duration_minutes, price_level, latitude, longitude
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+`
+
+type CreateAttractionParams struct {
+	ID                   pgtype.UUID
+	DestinationID        pgtype.UUID
+	Name                 string
+	Category             string
+	Description          string
+	OpeningHours         pgtype.Text
+	VisitDurationMinutes pgtype.Int4
+	PriceLevel           pgtype.Int4
+	Latitude             pgtype.Float8
+	Longitude            pgtype.Float8
+}
+
+func (q *Queries) CreateAttraction(ctx context.Context, arg CreateAttractionParams) error {
+	_, err := q.db.Exec(ctx, createAttraction,
+		arg.ID,
+		arg.DestinationID,
+		arg.Name,
+		arg.Category,
+		arg.Description,
+		arg.OpeningHours,
+		arg.VisitDurationMinutes,
+		arg.PriceLevel,
+		arg.Latitude,
+		arg.Longitude,
+	)
+	return err
+}
+
+const createBooking = `-- name: CreateBooking :exec
+INSERT INTO booking (
+  id, trip_id, kind, title, provider, confirmation_code, starts_at, start_zone,
+  ends_at, end_zone, address, cost, currency, notes, created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+)
+`
+
+type CreateBookingParams struct {
+	ID               pgtype.UUID
+	TripID           pgtype.UUID
+	Kind             string
+	Title            string
+	Provider         string
+	ConfirmationCode string
+	StartsAt         pgtype.Timestamptz
+	StartZone        string
+	EndsAt           pgtype.Timestamptz
+	EndZone          pgtype.Text
+	Address          string
+	Cost             pgtype.Int8
+	Currency         pgtype.Text
+	Notes            string
+	CreatedBy        pgtype.UUID
+}
+
+func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) error {
+	_, err := q.db.Exec(ctx, createBooking,
+		arg.ID,
+		arg.TripID,
+		arg.Kind,
+		arg.Title,
+		arg.Provider,
+		arg.ConfirmationCode,
+		arg.StartsAt,
+		arg.StartZone,
+		arg.EndsAt,
+		arg.EndZone,
+		arg.Address,
+		arg.Cost,
+		arg.Currency,
+		arg.Notes,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const createBookingAttachment = `-- name: CreateBookingAttachment :execrows
+INSERT INTO booking_attachment (id, booking_id, key, filename, content_type, size)
+SELECT $1::uuid, b.id, $2::text, $3::text, $4::text, $5::bigint
+FROM booking b
+WHERE b.id = $6 AND b.trip_id = $7
+`
+
+type CreateBookingAttachmentParams struct {
+	ID          pgtype.UUID
+	Key         string
+	Filename    string
+	ContentType string
+	Size        int64
+	BookingID   pgtype.UUID
+	TripID      pgtype.UUID
+}
+
+// nothing is inserted if the booking isn't one of the trip's
+func (q *Queries) CreateBookingAttachment(ctx context.Context, arg CreateBookingAttachmentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createBookingAttachment,
+		arg.ID,
+		arg.Key,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.BookingID,
+		arg.TripID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createChecklist = `-- name: CreateChecklist :exec
+INSERT INTO checklist (
+  id, trip_id, name, template_id
+) VALUES (
+  $1, $2, $3, $4
+)
+`
+
+type CreateChecklistParams struct {
+	ID         pgtype.UUID
+	TripID     pgtype.UUID
+	Name       string
+	TemplateID pgtype.UUID
+}
+
+func (q *Queries) CreateChecklist(ctx context.Context, arg CreateChecklistParams) error {
+	_, err := q.db.Exec(ctx, createChecklist,
+		arg.ID,
+		arg.TripID,
+		arg.Name,
+		arg.TemplateID,
+	)
+	return err
+}
+
+const createChecklistItem = `-- name: CreateChecklistItem :execrows
+INSERT INTO checklist_item (id, checklist_id, title, assignee_id, position)
+SELECT $1::uuid, c.id, $2::text, $3::uuid,
+ coalesce((SELECT max(i.position) + 1 FROM checklist_item i WHERE i.checklist_id = c.id), 0)
+FROM checklist c
+WHERE c.id = $4 AND c.trip_id = $5
+`
+
+type CreateChecklistItemParams struct {
+	ID          pgtype.UUID
+	Title       string
+	AssigneeID  pgtype.UUID
+	ChecklistID pgtype.UUID
+	TripID      pgtype.UUID
+}
+
+// appended to the end, nothing is inserted if the checklist isn't one of
+// the trip's
+func (q *Queries) CreateChecklistItem(ctx context.Context, arg CreateChecklistItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createChecklistItem,
+		arg.ID,
+		arg.Title,
+		arg.AssigneeID,
+		arg.ChecklistID,
+		arg.TripID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createChecklistItems = `-- name: CreateChecklistItems :exec
+INSERT INTO checklist_item (id, checklist_id, title, position)
+SELECT o.id, $1::uuid, o.title, (o.position - 1)::integer
+FROM unnest($2::uuid[], $3::text[]) WITH ORDINALITY AS o(id, title, position)
+`
+
+type CreateChecklistItemsParams struct {
+	ChecklistID pgtype.UUID
+	Ids         []pgtype.UUID
+	Titles      []string
+}
+
+// the arrays are zipped, positions follow their order
+func (q *Queries) CreateChecklistItems(ctx context.Context, arg CreateChecklistItemsParams) error {
+	_, err := q.db.Exec(ctx, createChecklistItems, arg.ChecklistID, arg.Ids, arg.Titles)
+	return err
+}
+
+const createChecklistTemplate = `-- name: CreateChecklistTemplate :exec
+INSERT INTO checklist_template (
+  id, user_id, name, description, min_days, max_days, tag_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+`
+
+type CreateChecklistTemplateParams struct {
+	ID          pgtype.UUID
+	UserID      pgtype.UUID
+	Name        string
+	Description string
+	MinDays     pgtype.Int4
+	MaxDays     pgtype.Int4
+	TagID       pgtype.UUID
+}
+
+func (q *Queries) CreateChecklistTemplate(ctx context.Context, arg CreateChecklistTemplateParams) error {
+	_, err := q.db.Exec(ctx, createChecklistTemplate,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.Description,
+		arg.MinDays,
+		arg.MaxDays,
+		arg.TagID,
+	)
+	return err
+}
+
+const createChecklistTemplateItems = `-- name: CreateChecklistTemplateItems :exec
+INSERT INTO checklist_template_item (id, template_id, title, position)
+SELECT o.id, $1::uuid, o.title, (o.position - 1)::integer
+FROM unnest($2::uuid[], $3::text[]) WITH ORDINALITY AS o(id, title, position)
+`
+
+type CreateChecklistTemplateItemsParams struct {
+	TemplateID pgtype.UUID
+	Ids        []pgtype.UUID
+	Titles     []string
+}
+
+// the arrays are zipped, positions follow their order
+func (q *Queries) CreateChecklistTemplateItems(ctx context.Context, arg CreateChecklistTemplateItemsParams) error {
+	_, err := q.db.Exec(ctx, createChecklistTemplateItems, arg.TemplateID, arg.Ids, arg.Titles)
+	return err
+}
+
 const createDestination = `-- name: CreateDestination :exec
 INSERT INTO destination (
-  id, name, description, attraction
+  id, name, description, attraction,
+  latitude, longitude, country, region, city, place_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+`
+
+type CreateDestinationParams struct {
+	ID          pgtype.UUID
+	Name        string
+	Description string
+	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+	PlaceID     pgtype.UUID
+}
+
+func (q *Queries) CreateDestination(ctx context.Context, arg CreateDestinationParams) error {
+	_, err := q.db.Exec(ctx, createDestination,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Attraction,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Country,
+		arg.Region,
+		arg.City,
+		arg.PlaceID,
+	)
+	return err
+}
+
+const createDestinationRevision = `-- name: CreateDestinationRevision :exec
+INSERT INTO destination_revision (
+  destination_id, version, action, snapshot, editor_id
+)
+SELECT d.id,
+ coalesce((SELECT max(version) FROM destination_revision WHERE destination_id = d.id), 0) + 1,
+ $1::text, to_jsonb(d) - 'id' - 'deleted_at', $2::uuid
+FROM destination d
+WHERE d.id = $3::uuid
+`
+
+type CreateDestinationRevisionParams struct {
+	Action        string
+	EditorID      pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+// snapshot of the destination as it is now, run in the same transaction
+// as the change, the destination row lock keeps versions in order
+func (q *Queries) CreateDestinationRevision(ctx context.Context, arg CreateDestinationRevisionParams) error {
+	_, err := q.db.Exec(ctx, createDestinationRevision, arg.Action, arg.EditorID, arg.DestinationID)
+	return err
+}
+
+const createExpense = `-- name: CreateExpense :exec
+INSERT INTO expense (id, trip_id, amount, currency, category, paid_by, spent_on, note, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreateExpenseParams struct {
+	ID        pgtype.UUID
+	TripID    pgtype.UUID
+	Amount    int64
+	Currency  string
+	Category  string
+	PaidBy    pgtype.UUID
+	SpentOn   pgtype.Date
+	Note      string
+	CreatedBy pgtype.UUID
+}
+
+func (q *Queries) CreateExpense(ctx context.Context, arg CreateExpenseParams) error {
+	_, err := q.db.Exec(ctx, createExpense,
+		arg.ID,
+		arg.TripID,
+		arg.Amount,
+		arg.Currency,
+		arg.Category,
+		arg.PaidBy,
+		arg.SpentOn,
+		arg.Note,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const createImportJob = `-- name: CreateImportJob :exec
+INSERT INTO import_job (
+  id, format, mode, dry_run, total_rows, editor_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+`
+
+type CreateImportJobParams struct {
+	ID        pgtype.UUID
+	Format    string
+	Mode      string
+	DryRun    bool
+	TotalRows int32
+	EditorID  pgtype.UUID
+}
+
+func (q *Queries) CreateImportJob(ctx context.Context, arg CreateImportJobParams) error {
+	_, err := q.db.Exec(ctx, createImportJob,
+		arg.ID,
+		arg.Format,
+		arg.Mode,
+		arg.DryRun,
+		arg.TotalRows,
+		arg.EditorID,
+	)
+	return err
+}
+
+const createLedgerParticipant = `-- name: CreateLedgerParticipant :exec
+INSERT INTO ledger_participant (id, trip_id, name, user_id)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateLedgerParticipantParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+	Name   string
+	UserID pgtype.UUID
+}
+
+func (q *Queries) CreateLedgerParticipant(ctx context.Context, arg CreateLedgerParticipantParams) error {
+	_, err := q.db.Exec(ctx, createLedgerParticipant,
+		arg.ID,
+		arg.TripID,
+		arg.Name,
+		arg.UserID,
+	)
+	return err
+}
+
+const createLedgerPayment = `-- name: CreateLedgerPayment :exec
+INSERT INTO ledger_payment (id, trip_id, paid_by, amount, currency, description, split, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateLedgerPaymentParams struct {
+	ID          pgtype.UUID
+	TripID      pgtype.UUID
+	PaidBy      pgtype.UUID
+	Amount      int64
+	Currency    string
+	Description string
+	Split       string
+	CreatedBy   pgtype.UUID
+}
+
+func (q *Queries) CreateLedgerPayment(ctx context.Context, arg CreateLedgerPaymentParams) error {
+	_, err := q.db.Exec(ctx, createLedgerPayment,
+		arg.ID,
+		arg.TripID,
+		arg.PaidBy,
+		arg.Amount,
+		arg.Currency,
+		arg.Description,
+		arg.Split,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const createLedgerSplits = `-- name: CreateLedgerSplits :exec
+INSERT INTO ledger_split (payment_id, participant_id, shares, amount)
+SELECT $1::uuid, s.participant_id, s.shares, s.amount
+FROM unnest($2::uuid[], $3::int4[], $4::int8[]) AS s(participant_id, shares, amount)
+`
+
+type CreateLedgerSplitsParams struct {
+	PaymentID      pgtype.UUID
+	ParticipantIds []pgtype.UUID
+	Shares         []int32
+	Amounts        []int64
+}
+
+// the arrays are zipped, one row per participant
+func (q *Queries) CreateLedgerSplits(ctx context.Context, arg CreateLedgerSplitsParams) error {
+	_, err := q.db.Exec(ctx, createLedgerSplits,
+		arg.PaymentID,
+		arg.ParticipantIds,
+		arg.Shares,
+		arg.Amounts,
+	)
+	return err
+}
+
+const createMediaFile = `-- name: CreateMediaFile :exec
+INSERT INTO media_file (
+  id, destination_id, key, thumbnail_key, content_type, size, width, height, caption, position
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9,
+  (SELECT coalesce(max(position) + 1, 0) FROM media_file WHERE destination_id = $2)
+)
+`
+
+type CreateMediaFileParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	Key           string
+	ThumbnailKey  string
+	ContentType   string
+	Size          int64
+	Width         int32
+	Height        int32
+	Caption       string
+}
+
+// appended to the end of the gallery
+func (q *Queries) CreateMediaFile(ctx context.Context, arg CreateMediaFileParams) error {
+	_, err := q.db.Exec(ctx, createMediaFile,
+		arg.ID,
+		arg.DestinationID,
+		arg.Key,
+		arg.ThumbnailKey,
+		arg.ContentType,
+		arg.Size,
+		arg.Width,
+		arg.Height,
+		arg.Caption,
+	)
+	return err
+}
+
+const createReview = `-- name: CreateReview :exec
+INSERT INTO review (
+  id, destination_id, user_id, rating, title, body, visit_date
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+`
+
+type CreateReviewParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	UserID        pgtype.UUID
+	Rating        int32
+	Title         string
+	Body          string
+	VisitDate     pgtype.Date
+}
+
+func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) error {
+	_, err := q.db.Exec(ctx, createReview,
+		arg.ID,
+		arg.DestinationID,
+		arg.UserID,
+		arg.Rating,
+		arg.Title,
+		arg.Body,
+		arg.VisitDate,
+	)
+	return err
+}
+
+const createTag = `-- name: CreateTag :exec
+INSERT INTO tag (
+  id, slug, name, category
 ) VALUES (
   $1, $2, $3, $4
 )
 `
 
-type CreateDestinationParams struct {
+type CreateTagParams struct {
+	ID       pgtype.UUID
+	Slug     string
+	Name     string
+	Category string
+}
+
+func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) error {
+	_, err := q.db.Exec(ctx, createTag,
+		arg.ID,
+		arg.Slug,
+		arg.Name,
+		arg.Category,
+	)
+	return err
+}
+
+const createTrip = `-- name: CreateTrip :exec
+WITH created AS (
+  INSERT INTO trip (
+    id, name, start_date, end_date, destination_id, user_id
+  ) VALUES (
+    $1, $2, $3, $4, $5, $6
+  )
+  RETURNING id, user_id
+)
+INSERT INTO trip_member (trip_id, user_id, role)
+SELECT id, user_id, 'owner' FROM created
+ WHERE user_id IS NOT NULL
+`
+
+type CreateTripParams struct {
+	ID            pgtype.UUID
+	Name          string
+	StartDate     string
+	EndDate       string
+	DestinationID pgtype.UUID
+	UserID        pgtype.UUID
+}
+
+// the user becomes the owner
+func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) error {
+	_, err := q.db.Exec(ctx, createTrip,
+		arg.ID,
+		arg.Name,
+		arg.StartDate,
+		arg.EndDate,
+		arg.DestinationID,
+		arg.UserID,
+	)
+	return err
+}
+
+const createTripInvitation = `-- name: CreateTripInvitation :exec
+INSERT INTO trip_invitation (
+  id, trip_id, email, role, token, invited_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+`
+
+type CreateTripInvitationParams struct {
+	ID        pgtype.UUID
+	TripID    pgtype.UUID
+	Email     string
+	Role      string
+	Token     string
+	InvitedBy pgtype.UUID
+}
+
+func (q *Queries) CreateTripInvitation(ctx context.Context, arg CreateTripInvitationParams) error {
+	_, err := q.db.Exec(ctx, createTripInvitation,
+		arg.ID,
+		arg.TripID,
+		arg.Email,
+		arg.Role,
+		arg.Token,
+		arg.InvitedBy,
+	)
+	return err
+}
+
+const createTripShare = `-- name: CreateTripShare :exec
+INSERT INTO trip_share (
+  id, trip_id, token, password_hash, expires_at, created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+`
+
+type CreateTripShareParams struct {
+	ID           pgtype.UUID
+	TripID       pgtype.UUID
+	Token        string
+	PasswordHash pgtype.Text
+	ExpiresAt    pgtype.Timestamptz
+	CreatedBy    pgtype.UUID
+}
+
+func (q *Queries) CreateTripShare(ctx context.Context, arg CreateTripShareParams) error {
+	_, err := q.db.Exec(ctx, createTripShare,
+		arg.ID,
+		arg.TripID,
+		arg.Token,
+		arg.PasswordHash,
+		arg.ExpiresAt,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :exec
+INSERT INTO users (
+  id, email, name, password
+) VALUES (
+  $1, $2, $3, $4
+)
+`
+
+type CreateUserParams struct {
+	ID       pgtype.UUID
+	Email    string
+	Name     string
+	Password string
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
+	_, err := q.db.Exec(ctx, createUser,
+		arg.ID,
+		arg.Email,
+		arg.Name,
+		arg.Password,
+	)
+	return err
+}
+
+const createWishlist = `-- name: CreateWishlist :exec
+INSERT INTO wishlist (
+  id, user_id, name
+) VALUES (
+  $1, $2, $3
+)
+`
+
+type CreateWishlistParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+	Name   string
+}
+
+func (q *Queries) CreateWishlist(ctx context.Context, arg CreateWishlistParams) error {
+	_, err := q.db.Exec(ctx, createWishlist, arg.ID, arg.UserID, arg.Name)
+	return err
+}
+
+const deleteActivity = `-- name: DeleteActivity :execrows
+DELETE FROM activity
+WHERE id = $1 AND trip_id = $2
+`
+
+type DeleteActivityParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteActivity(ctx context.Context, arg DeleteActivityParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteActivity, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteAttraction = `-- name: DeleteAttraction :execrows
+DELETE FROM attraction
+ WHERE id = $1 AND destination_id = $2
+`
+
+type DeleteAttractionParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+func (q *Queries) DeleteAttraction(ctx context.Context, arg DeleteAttractionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAttraction, arg.ID, arg.DestinationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteBooking = `-- name: DeleteBooking :execrows
+DELETE FROM booking
+WHERE id = $1 AND trip_id = $2
+`
+
+type DeleteBookingParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteBooking(ctx context.Context, arg DeleteBookingParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBooking, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteBookingAttachment = `-- name: DeleteBookingAttachment :one
+DELETE FROM booking_attachment a
+USING booking b
+WHERE a.booking_id = b.id AND a.id = $1 AND b.id = $2
+ AND b.trip_id = $3
+RETURNING a.key
+`
+
+type DeleteBookingAttachmentParams struct {
+	ID        pgtype.UUID
+	BookingID pgtype.UUID
+	TripID    pgtype.UUID
+}
+
+func (q *Queries) DeleteBookingAttachment(ctx context.Context, arg DeleteBookingAttachmentParams) (string, error) {
+	row := q.db.QueryRow(ctx, deleteBookingAttachment, arg.ID, arg.BookingID, arg.TripID)
+	var key string
+	err := row.Scan(&key)
+	return key, err
+}
+
+const deleteBookingAttachments = `-- name: DeleteBookingAttachments :many
+DELETE FROM booking_attachment a
+USING booking b
+WHERE a.booking_id = b.id AND b.id = $1 AND b.trip_id = $2
+RETURNING a.key
+`
+
+type DeleteBookingAttachmentsParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+// attachment rows go first so their files can be removed from the store
+func (q *Queries) DeleteBookingAttachments(ctx context.Context, arg DeleteBookingAttachmentsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, deleteBookingAttachments, arg.ID, arg.TripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteCalendarToken = `-- name: DeleteCalendarToken :execrows
+DELETE FROM calendar_feed
+ WHERE user_id = $1
+`
+
+func (q *Queries) DeleteCalendarToken(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCalendarToken, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteChecklist = `-- name: DeleteChecklist :execrows
+DELETE FROM checklist
+WHERE id = $1 AND trip_id = $2
+`
+
+type DeleteChecklistParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteChecklist(ctx context.Context, arg DeleteChecklistParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteChecklist, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteChecklistItem = `-- name: DeleteChecklistItem :execrows
+DELETE FROM checklist_item i
+USING checklist c
+WHERE i.checklist_id = c.id AND i.id = $1 AND c.id = $2 AND c.trip_id = $3
+`
+
+type DeleteChecklistItemParams struct {
+	ID          pgtype.UUID
+	ChecklistID pgtype.UUID
+	TripID      pgtype.UUID
+}
+
+func (q *Queries) DeleteChecklistItem(ctx context.Context, arg DeleteChecklistItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteChecklistItem, arg.ID, arg.ChecklistID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteChecklistTemplate = `-- name: DeleteChecklistTemplate :execrows
+DELETE FROM checklist_template
+WHERE id = $1 AND (user_id = $2 OR ($3::boolean AND user_id IS NULL))
+`
+
+type DeleteChecklistTemplateParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+	Admin  bool
+}
+
+func (q *Queries) DeleteChecklistTemplate(ctx context.Context, arg DeleteChecklistTemplateParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteChecklistTemplate, arg.ID, arg.UserID, arg.Admin)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteChecklistTemplateItems = `-- name: DeleteChecklistTemplateItems :exec
+DELETE FROM checklist_template_item
+WHERE template_id = $1
+`
+
+func (q *Queries) DeleteChecklistTemplateItems(ctx context.Context, templateID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteChecklistTemplateItems, templateID)
+	return err
+}
+
+const deleteDestination = `-- name: DeleteDestination :execrows
+UPDATE destination
+ SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+// moves it to the trash
+func (q *Queries) DeleteDestination(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDestination, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteDestinationTranslation = `-- name: DeleteDestinationTranslation :execrows
+DELETE FROM destination_translation
+ WHERE destination_id = $1 AND locale = $2
+`
+
+type DeleteDestinationTranslationParams struct {
+	DestinationID pgtype.UUID
+	Locale        string
+}
+
+func (q *Queries) DeleteDestinationTranslation(ctx context.Context, arg DeleteDestinationTranslationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDestinationTranslation, arg.DestinationID, arg.Locale)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExchangeRate = `-- name: DeleteExchangeRate :execrows
+DELETE FROM exchange_rate
+WHERE base = $1 AND quote = $2
+`
+
+type DeleteExchangeRateParams struct {
+	Base  string
+	Quote string
+}
+
+func (q *Queries) DeleteExchangeRate(ctx context.Context, arg DeleteExchangeRateParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExchangeRate, arg.Base, arg.Quote)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpense = `-- name: DeleteExpense :execrows
+DELETE FROM expense
+WHERE id = $1 AND trip_id = $2
+`
+
+type DeleteExpenseParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteExpense(ctx context.Context, arg DeleteExpenseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpense, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLedgerParticipant = `-- name: DeleteLedgerParticipant :execrows
+DELETE FROM ledger_participant
+WHERE id = $1 AND trip_id = $2
+`
+
+type DeleteLedgerParticipantParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteLedgerParticipant(ctx context.Context, arg DeleteLedgerParticipantParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteLedgerParticipant, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLedgerPayment = `-- name: DeleteLedgerPayment :execrows
+DELETE FROM ledger_payment
+WHERE id = $1 AND trip_id = $2
+`
+
+type DeleteLedgerPaymentParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteLedgerPayment(ctx context.Context, arg DeleteLedgerPaymentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteLedgerPayment, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteMediaFile = `-- name: DeleteMediaFile :one
+DELETE FROM media_file
+ WHERE id = $1 AND destination_id = $2
+RETURNING key, thumbnail_key
+`
+
+type DeleteMediaFileParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+type DeleteMediaFileRow struct {
+	Key          string
+	ThumbnailKey string
+}
+
+func (q *Queries) DeleteMediaFile(ctx context.Context, arg DeleteMediaFileParams) (DeleteMediaFileRow, error) {
+	row := q.db.QueryRow(ctx, deleteMediaFile, arg.ID, arg.DestinationID)
+	var i DeleteMediaFileRow
+	err := row.Scan(&i.Key, &i.ThumbnailKey)
+	return i, err
+}
+
+const deletePurgedBookingAttachments = `-- name: DeletePurgedBookingAttachments :many
+DELETE FROM booking_attachment a
+USING booking b, trip t
+WHERE a.booking_id = b.id AND b.trip_id = t.id AND t.deleted_at < $1
+RETURNING a.key
+`
+
+// attachments of the trips PurgeTrips is about to delete
+func (q *Queries) DeletePurgedBookingAttachments(ctx context.Context, deletedAt pgtype.Timestamptz) ([]string, error) {
+	rows, err := q.db.Query(ctx, deletePurgedBookingAttachments, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deletePurgedMedia = `-- name: DeletePurgedMedia :many
+DELETE FROM media_file
+ WHERE destination_id IN (
+   SELECT id FROM destination WHERE deleted_at < $1
+ )
+RETURNING key, thumbnail_key
+`
+
+type DeletePurgedMediaRow struct {
+	Key          string
+	ThumbnailKey string
+}
+
+// media of the destinations about to be purged, the files are removed
+// from the store by the caller
+func (q *Queries) DeletePurgedMedia(ctx context.Context, deletedAt pgtype.Timestamptz) ([]DeletePurgedMediaRow, error) {
+	rows, err := q.db.Query(ctx, deletePurgedMedia, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeletePurgedMediaRow
+	for rows.Next() {
+		var i DeletePurgedMediaRow
+		if err := rows.Scan(&i.Key, &i.ThumbnailKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteReview = `-- name: DeleteReview :execrows
+DELETE FROM review
+ WHERE id = $1 AND destination_id = $2 AND user_id = $3
+`
+
+type DeleteReviewParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	UserID        pgtype.UUID
+}
+
+func (q *Queries) DeleteReview(ctx context.Context, arg DeleteReviewParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteReview, arg.ID, arg.DestinationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTag = `-- name: DeleteTag :execrows
+DELETE FROM tag
+ WHERE id = $1
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTag, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTrip = `-- name: DeleteTrip :execrows
+UPDATE trip
+ SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+// moves it to the trash
+func (q *Queries) DeleteTrip(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTrip, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTripInvitation = `-- name: DeleteTripInvitation :execrows
+DELETE FROM trip_invitation
+ WHERE id = $1 AND trip_id = $2 AND status = 'pending'
+`
+
+type DeleteTripInvitationParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteTripInvitation(ctx context.Context, arg DeleteTripInvitationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTripInvitation, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTripShare = `-- name: DeleteTripShare :execrows
+DELETE FROM trip_share
+ WHERE id = $1 AND trip_id = $2
+`
+
+type DeleteTripShareParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+}
+
+func (q *Queries) DeleteTripShare(ctx context.Context, arg DeleteTripShareParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTripShare, arg.ID, arg.TripID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWishlist = `-- name: DeleteWishlist :execrows
+DELETE FROM wishlist
+ WHERE id = $1 AND user_id = $2 AND NOT is_default
+`
+
+type DeleteWishlistParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) DeleteWishlist(ctx context.Context, arg DeleteWishlistParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWishlist, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const demoteAdmin = `-- name: DemoteAdmin :exec
+UPDATE users SET admin = false
+ WHERE email = $1
+`
+
+func (q *Queries) DemoteAdmin(ctx context.Context, email string) error {
+	_, err := q.db.Exec(ctx, demoteAdmin, email)
+	return err
+}
+
+const ensureDefaultWishlist = `-- name: EnsureDefaultWishlist :exec
+INSERT INTO wishlist (
+  id, user_id, name, is_default
+) VALUES (
+  $1, $2, 'Favorites', true
+)
+ON CONFLICT (user_id) WHERE is_default DO NOTHING
+`
+
+type EnsureDefaultWishlistParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) EnsureDefaultWishlist(ctx context.Context, arg EnsureDefaultWishlistParams) error {
+	_, err := q.db.Exec(ctx, ensureDefaultWishlist, arg.ID, arg.UserID)
+	return err
+}
+
+const expenseTotalsByCategory = `-- name: ExpenseTotalsByCategory :many
+SELECT currency, category, sum(amount)::int8 AS total FROM expense
+WHERE trip_id = $1
+GROUP BY currency, category
+`
+
+type ExpenseTotalsByCategoryRow struct {
+	Currency string
+	Category string
+	Total    int64
+}
+
+func (q *Queries) ExpenseTotalsByCategory(ctx context.Context, tripID pgtype.UUID) ([]ExpenseTotalsByCategoryRow, error) {
+	rows, err := q.db.Query(ctx, expenseTotalsByCategory, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpenseTotalsByCategoryRow
+	for rows.Next() {
+		var i ExpenseTotalsByCategoryRow
+		if err := rows.Scan(&i.Currency, &i.Category, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const expenseTotalsByDay = `-- name: ExpenseTotalsByDay :many
+SELECT currency, spent_on, sum(amount)::int8 AS total FROM expense
+WHERE trip_id = $1
+GROUP BY currency, spent_on
+ORDER BY spent_on
+`
+
+type ExpenseTotalsByDayRow struct {
+	Currency string
+	SpentOn  pgtype.Date
+	Total    int64
+}
+
+func (q *Queries) ExpenseTotalsByDay(ctx context.Context, tripID pgtype.UUID) ([]ExpenseTotalsByDayRow, error) {
+	rows, err := q.db.Query(ctx, expenseTotalsByDay, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpenseTotalsByDayRow
+	for rows.Next() {
+		var i ExpenseTotalsByDayRow
+		if err := rows.Scan(&i.Currency, &i.SpentOn, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const failInterruptedImportJobs = `-- name: FailInterruptedImportJobs :execrows
+UPDATE import_job
+ SET status = 'failed',
+ errors = errors || '[{"Row": 0, "Error": "import was interrupted by a restart"}]'::jsonb,
+ finished_at = now()
+WHERE status = 'running'
+`
+
+// jobs left running by a previous process, nothing can finish them
+func (q *Queries) FailInterruptedImportJobs(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, failInterruptedImportJobs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const findCountryPlace = `-- name: FindCountryPlace :one
+SELECT id FROM place
+ WHERE kind = 'country' AND country_code = $1
+LIMIT 1
+`
+
+func (q *Queries) FindCountryPlace(ctx context.Context, countryCode string) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, findCountryPlace, countryCode)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const findDestinationsByName = `-- name: FindDestinationsByName :many
+SELECT id FROM destination
+ WHERE lower(name) = lower($1) AND deleted_at IS NULL
+ORDER BY id
+LIMIT 2
+`
+
+// names are only unique within a city, two rows are enough to tell the
+// name is ambiguous
+func (q *Queries) FindDestinationsByName(ctx context.Context, lower string) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, findDestinationsByName, lower)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findRegionPlace = `-- name: FindRegionPlace :one
+SELECT id FROM place
+ WHERE kind = 'region' AND country_code = $1 AND admin1_code = $2
+LIMIT 1
+`
+
+type FindRegionPlaceParams struct {
+	CountryCode string
+	Admin1Code  pgtype.Text
+}
+
+func (q *Queries) FindRegionPlace(ctx context.Context, arg FindRegionPlaceParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, findRegionPlace, arg.CountryCode, arg.Admin1Code)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const finishImportJob = `-- name: FinishImportJob :exec
+UPDATE import_job
+ SET status = $2,
+ processed_rows = $3,
+ created_rows = $4,
+ updated_rows = $5,
+ failed_rows = $6,
+ errors = $7,
+ finished_at = now()
+WHERE id = $1
+`
+
+type FinishImportJobParams struct {
+	ID            pgtype.UUID
+	Status        string
+	ProcessedRows int32
+	CreatedRows   int32
+	UpdatedRows   int32
+	FailedRows    int32
+	Errors        []byte
+}
+
+func (q *Queries) FinishImportJob(ctx context.Context, arg FinishImportJobParams) error {
+	_, err := q.db.Exec(ctx, finishImportJob,
+		arg.ID,
+		arg.Status,
+		arg.ProcessedRows,
+		arg.CreatedRows,
+		arg.UpdatedRows,
+		arg.FailedRows,
+		arg.Errors,
+	)
+	return err
+}
+
+const getAttraction = `-- name: GetAttraction :one
+SELECT a.id, a.destination_id, a.name, a.category, a.description, a.opening_hours, a.visit_duration_minutes, a.price_level, a.latitude, a.longitude FROM attraction a
+JOIN destination d ON d.id = a.destination_id
+WHERE a.id = $1 AND a.destination_id = $2 AND d.deleted_at IS NULL LIMIT 1
+`
+
+type GetAttractionParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+func (q *Queries) GetAttraction(ctx context.Context, arg GetAttractionParams) (Attraction, error) {
+	row := q.db.QueryRow(ctx, getAttraction, arg.ID, arg.DestinationID)
+	var i Attraction
+	err := row.Scan(
+		&i.ID,
+		&i.DestinationID,
+		&i.Name,
+		&i.Category,
+		&i.Description,
+		&i.OpeningHours,
+		&i.VisitDurationMinutes,
+		&i.PriceLevel,
+		&i.Latitude,
+		&i.Longitude,
+	)
+	return i, err
+}
+
+const getAttractionDestination = `-- name: GetAttractionDestination :one
+SELECT a.destination_id FROM attraction a
+JOIN destination d ON d.id = a.destination_id
+WHERE a.id = $1 AND d.deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetAttractionDestination(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, getAttractionDestination, id)
+	var destinationID pgtype.UUID
+	err := row.Scan(&destinationID)
+	return destinationID, err
+}
+
+const getCalendarFeedUser = `-- name: GetCalendarFeedUser :one
+SELECT user_id FROM calendar_feed
+ WHERE token = $1 LIMIT 1
+`
+
+func (q *Queries) GetCalendarFeedUser(ctx context.Context, token string) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, getCalendarFeedUser, token)
+	var userID pgtype.UUID
+	err := row.Scan(&userID)
+	return userID, err
+}
+
+const getChecklistTemplate = `-- name: GetChecklistTemplate :one
+SELECT id, user_id, name, description, min_days, max_days, tag_id, created_at FROM checklist_template
+WHERE id = $1 AND (user_id IS NULL OR user_id = $2)
+`
+
+type GetChecklistTemplateParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) GetChecklistTemplate(ctx context.Context, arg GetChecklistTemplateParams) (ChecklistTemplate, error) {
+	row := q.db.QueryRow(ctx, getChecklistTemplate, arg.ID, arg.UserID)
+	var i ChecklistTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.MinDays,
+		&i.MaxDays,
+		&i.TagID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDefaultWishlist = `-- name: GetDefaultWishlist :one
+SELECT id, user_id, name, is_default, share_token, created_at FROM wishlist
+ WHERE user_id = $1 AND is_default LIMIT 1
+`
+
+func (q *Queries) GetDefaultWishlist(ctx context.Context, userID pgtype.UUID) (Wishlist, error) {
+	row := q.db.QueryRow(ctx, getDefaultWishlist, userID)
+	var i Wishlist
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.IsDefault,
+		&i.ShareToken,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDestination = `-- name: GetDestination :one
+SELECT name, description, attraction,
+ latitude, longitude, country, region, city, place_id FROM destination
+ WHERE id = $1 AND deleted_at IS NULL LIMIT 1
+`
+
+type GetDestinationRow struct {
+	Name        string
+	Description string
+	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+	PlaceID     pgtype.UUID
+}
+
+func (q *Queries) GetDestination(ctx context.Context, id pgtype.UUID) (GetDestinationRow, error) {
+	row := q.db.QueryRow(ctx, getDestination, id)
+	var i GetDestinationRow
+	err := row.Scan(
+		&i.Name,
+		&i.Description,
+		&i.Attraction,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Country,
+		&i.Region,
+		&i.City,
+		&i.PlaceID,
+	)
+	return i, err
+}
+
+const getDestinationRating = `-- name: GetDestinationRating :one
+SELECT coalesce(avg(rating), 0)::float8 AS average_rating, count(*) AS review_count
+FROM review
+WHERE destination_id = $1 AND status <> 'hidden'
+`
+
+type GetDestinationRatingRow struct {
+	AverageRating float64
+	ReviewCount   int64
+}
+
+func (q *Queries) GetDestinationRating(ctx context.Context, destinationID pgtype.UUID) (GetDestinationRatingRow, error) {
+	row := q.db.QueryRow(ctx, getDestinationRating, destinationID)
+	var i GetDestinationRatingRow
+	err := row.Scan(&i.AverageRating, &i.ReviewCount)
+	return i, err
+}
+
+const getImportJob = `-- name: GetImportJob :one
+SELECT id, status, format, mode, dry_run, total_rows, processed_rows, created_rows, updated_rows, failed_rows, errors, editor_id, created_at, finished_at FROM import_job
+ WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetImportJob(ctx context.Context, id pgtype.UUID) (ImportJob, error) {
+	row := q.db.QueryRow(ctx, getImportJob, id)
+	var i ImportJob
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.Format,
+		&i.Mode,
+		&i.DryRun,
+		&i.TotalRows,
+		&i.ProcessedRows,
+		&i.CreatedRows,
+		&i.UpdatedRows,
+		&i.FailedRows,
+		&i.Errors,
+		&i.EditorID,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getMediaFile = `-- name: GetMediaFile :one
+SELECT id, destination_id, key, thumbnail_key, content_type, size, width, height, caption, position, is_cover, created_at FROM media_file
+ WHERE id = $1 AND destination_id = $2 LIMIT 1
+`
+
+type GetMediaFileParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+func (q *Queries) GetMediaFile(ctx context.Context, arg GetMediaFileParams) (MediaFile, error) {
+	row := q.db.QueryRow(ctx, getMediaFile, arg.ID, arg.DestinationID)
+	var i MediaFile
+	err := row.Scan(
+		&i.ID,
+		&i.DestinationID,
+		&i.Key,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.Size,
+		&i.Width,
+		&i.Height,
+		&i.Caption,
+		&i.Position,
+		&i.IsCover,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPass = `-- name: GetPass :one
+SELECT id, password, name, admin FROM users
+ WHERE email = $1 LIMIT 1
+`
+
+type GetPassRow struct {
+	ID       pgtype.UUID
+	Password string
+	Name     string
+	Admin    bool
+}
+
+func (q *Queries) GetPass(ctx context.Context, email string) (GetPassRow, error) {
+	row := q.db.QueryRow(ctx, getPass, email)
+	var i GetPassRow
+	err := row.Scan(
+		&i.ID,
+		&i.Password,
+		&i.Name,
+		&i.Admin,
+	)
+	return i, err
+}
+
+const getPlace = `-- name: GetPlace :one
+SELECT id, parent_id, kind, name, country_code, geoname_id, latitude, longitude, admin1_code FROM place
+ WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPlace(ctx context.Context, id pgtype.UUID) (Place, error) {
+	row := q.db.QueryRow(ctx, getPlace, id)
+	var i Place
+	err := row.Scan(
+		&i.ID,
+		&i.ParentID,
+		&i.Kind,
+		&i.Name,
+		&i.CountryCode,
+		&i.GeonameID,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Admin1Code,
+	)
+	return i, err
+}
+
+const getSharedTrip = `-- name: GetSharedTrip :one
+SELECT s.id AS share_id, s.password_hash, t.id AS trip_id, t.name, t.start_date, t.end_date,
+ d.name AS destination_name, d.description AS destination_description,
+ d.attraction AS destination_attraction, d.latitude, d.longitude, d.country, d.region, d.city
+FROM trip_share s
+JOIN trip t ON t.id = s.trip_id
+LEFT JOIN destination d ON d.id = t.destination_id AND d.deleted_at IS NULL
+WHERE s.token = $1 AND (s.expires_at IS NULL OR s.expires_at > now())
+ AND t.deleted_at IS NULL
+LIMIT 1
+`
+
+type GetSharedTripRow struct {
+	ShareID                pgtype.UUID
+	PasswordHash           pgtype.Text
+	TripID                 pgtype.UUID
+	Name                   string
+	StartDate              string
+	EndDate                string
+	DestinationName        pgtype.Text
+	DestinationDescription pgtype.Text
+	DestinationAttraction  pgtype.Text
+	Latitude               pgtype.Float8
+	Longitude              pgtype.Float8
+	Country                pgtype.Text
+	Region                 pgtype.Text
+	City                   pgtype.Text
+}
+
+// expired links and trips in the trash aren't shown
+func (q *Queries) GetSharedTrip(ctx context.Context, token string) (GetSharedTripRow, error) {
+	row := q.db.QueryRow(ctx, getSharedTrip, token)
+	var i GetSharedTripRow
+	err := row.Scan(
+		&i.ShareID,
+		&i.PasswordHash,
+		&i.TripID,
+		&i.Name,
+		&i.StartDate,
+		&i.EndDate,
+		&i.DestinationName,
+		&i.DestinationDescription,
+		&i.DestinationAttraction,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Country,
+		&i.Region,
+		&i.City,
+	)
+	return i, err
+}
+
+const getSharedWishlist = `-- name: GetSharedWishlist :one
+SELECT id, user_id, name, is_default, share_token, created_at FROM wishlist
+ WHERE share_token = $1 LIMIT 1
+`
+
+func (q *Queries) GetSharedWishlist(ctx context.Context, shareToken pgtype.Text) (Wishlist, error) {
+	row := q.db.QueryRow(ctx, getSharedWishlist, shareToken)
+	var i Wishlist
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.IsDefault,
+		&i.ShareToken,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTrip = `-- name: GetTrip :one
+SELECT t.name, t.start_date, t.end_date, t.destination_id, t.budget, t.currency,
+ (t.destination_id IS NULL OR d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE t.id = $1 AND t.deleted_at IS NULL LIMIT 1
+`
+
+type GetTripRow struct {
+	Name               string
+	StartDate          string
+	EndDate            string
+	DestinationID      pgtype.UUID
+	Budget             pgtype.Int8
+	Currency           string
+	DestinationDeleted bool
+}
+
+func (q *Queries) GetTrip(ctx context.Context, id pgtype.UUID) (GetTripRow, error) {
+	row := q.db.QueryRow(ctx, getTrip, id)
+	var i GetTripRow
+	err := row.Scan(
+		&i.Name,
+		&i.StartDate,
+		&i.EndDate,
+		&i.DestinationID,
+		&i.Budget,
+		&i.Currency,
+		&i.DestinationDeleted,
+	)
+	return i, err
+}
+
+const getTripBudget = `-- name: GetTripBudget :one
+SELECT budget, currency FROM trip
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1
+`
+
+type GetTripBudgetRow struct {
+	Budget   pgtype.Int8
+	Currency string
+}
+
+func (q *Queries) GetTripBudget(ctx context.Context, id pgtype.UUID) (GetTripBudgetRow, error) {
+	row := q.db.QueryRow(ctx, getTripBudget, id)
+	var i GetTripBudgetRow
+	err := row.Scan(&i.Budget, &i.Currency)
+	return i, err
+}
+
+const getTripEvent = `-- name: GetTripEvent :one
+SELECT t.id, t.name, t.start_date, t.end_date, t.updated_at, t.sequence,
+ d.name AS destination_name, d.description AS destination_description
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE t.id = $1 AND t.deleted_at IS NULL LIMIT 1
+`
+
+type GetTripEventRow struct {
+	ID                     pgtype.UUID
+	Name                   string
+	StartDate              string
+	EndDate                string
+	UpdatedAt              pgtype.Timestamptz
+	Sequence               int32
+	DestinationName        pgtype.Text
+	DestinationDescription pgtype.Text
+}
+
+func (q *Queries) GetTripEvent(ctx context.Context, id pgtype.UUID) (GetTripEventRow, error) {
+	row := q.db.QueryRow(ctx, getTripEvent, id)
+	var i GetTripEventRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartDate,
+		&i.EndDate,
+		&i.UpdatedAt,
+		&i.Sequence,
+		&i.DestinationName,
+		&i.DestinationDescription,
+	)
+	return i, err
+}
+
+const getTripRole = `-- name: GetTripRole :one
+SELECT m.role FROM trip_member m
+JOIN trip t ON t.id = m.trip_id
+WHERE m.trip_id = $1 AND m.user_id = $2 AND t.deleted_at IS NULL
+LIMIT 1
+`
+
+type GetTripRoleParams struct {
+	TripID pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) GetTripRole(ctx context.Context, arg GetTripRoleParams) (string, error) {
+	row := q.db.QueryRow(ctx, getTripRole, arg.TripID, arg.UserID)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getWishlist = `-- name: GetWishlist :one
+SELECT id, user_id, name, is_default, share_token, created_at FROM wishlist
+ WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetWishlistParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) GetWishlist(ctx context.Context, arg GetWishlistParams) (Wishlist, error) {
+	row := q.db.QueryRow(ctx, getWishlist, arg.ID, arg.UserID)
+	var i Wishlist
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.IsDefault,
+		&i.ShareToken,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const ledgerBalances = `-- name: LedgerBalances :many
+SELECT pp.id AS participant_id, pp.name, e.currency,
+ coalesce(sum(e.paid), 0)::int8 AS paid,
+ coalesce(sum(e.owed), 0)::int8 AS owed
+FROM ledger_participant pp
+LEFT JOIN (
+  SELECT paid_by AS participant_id, currency, amount AS paid, 0::int8 AS owed
+  FROM ledger_payment
+  WHERE trip_id = $1
+  UNION ALL
+  SELECT s.participant_id, p.currency, 0::int8, s.amount
+  FROM ledger_split s
+  JOIN ledger_payment p ON p.id = s.payment_id
+  WHERE p.trip_id = $1
+  UNION ALL
+  SELECT l.id, x.currency, x.amount, 0::int8
+  FROM expense x
+  JOIN ledger_participant l ON l.trip_id = x.trip_id AND l.user_id = x.paid_by
+  WHERE x.trip_id = $1
+  UNION ALL
+  SELECT l.id, x.currency, 0::int8,
+   x.amount / l.n + CASE WHEN l.rank <= x.amount % l.n THEN 1 ELSE 0 END
+  FROM expense x
+  JOIN (
+    SELECT id, row_number() OVER (ORDER BY name, id) AS rank, count(*) OVER () AS n
+    FROM ledger_participant
+    WHERE trip_id = $1 AND user_id IS NOT NULL
+  ) l ON true
+  WHERE x.trip_id = $1 AND EXISTS (
+    SELECT 1 FROM ledger_participant payer
+    WHERE payer.trip_id = x.trip_id AND payer.user_id = x.paid_by
+  )
+) e ON e.participant_id = pp.id
+WHERE pp.trip_id = $1
+GROUP BY pp.id, pp.name, e.currency
+ORDER BY pp.name, pp.id
+`
+
+type LedgerBalancesRow struct {
+	ParticipantID pgtype.UUID
+	Name          string
+	Currency      pgtype.Text
+	Paid          int64
+	Owed          int64
+}
+
+// what each participant paid and owes per currency, participants with
+// no payments have a single row without a currency. Trip expenses paid
+// by a linked participant are split equally between every linked
+// participant, the first ones by name get the leftover minor units
+func (q *Queries) LedgerBalances(ctx context.Context, tripID pgtype.UUID) ([]LedgerBalancesRow, error) {
+	rows, err := q.db.Query(ctx, ledgerBalances, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LedgerBalancesRow
+	for rows.Next() {
+		var i LedgerBalancesRow
+		if err := rows.Scan(
+			&i.ParticipantID,
+			&i.Name,
+			&i.Currency,
+			&i.Paid,
+			&i.Owed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAttractions = `-- name: ListAttractions :many
+SELECT a.id, a.destination_id, a.name, a.category, a.description, a.opening_hours, a.visit_duration_minutes, a.price_level, a.latitude, a.longitude FROM attraction a
+JOIN destination d ON d.id = a.destination_id
+WHERE a.destination_id = $1 AND d.deleted_at IS NULL
+ORDER BY a.name
+`
+
+// none for destinations in the trash
+func (q *Queries) ListAttractions(ctx context.Context, destinationID pgtype.UUID) ([]Attraction, error) {
+	rows, err := q.db.Query(ctx, listAttractions, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attraction
+	for rows.Next() {
+		var i Attraction
+		if err := rows.Scan(
+			&i.ID,
+			&i.DestinationID,
+			&i.Name,
+			&i.Category,
+			&i.Description,
+			&i.OpeningHours,
+			&i.VisitDurationMinutes,
+			&i.PriceLevel,
+			&i.Latitude,
+			&i.Longitude,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingEvents = `-- name: ListBookingEvents :many
+SELECT b.id, b.kind, b.title, b.provider, b.confirmation_code, b.starts_at, b.ends_at,
+ b.address, b.updated_at, b.sequence
+FROM booking b
+JOIN trip t ON t.id = b.trip_id
+JOIN trip_member m ON m.trip_id = t.id
+WHERE m.user_id = $1 AND t.deleted_at IS NULL
+ORDER BY b.starts_at, b.id
+`
+
+type ListBookingEventsRow struct {
+	ID               pgtype.UUID
+	Kind             string
+	Title            string
+	Provider         string
+	ConfirmationCode string
+	StartsAt         pgtype.Timestamptz
+	EndsAt           pgtype.Timestamptz
+	Address          string
+	UpdatedAt        pgtype.Timestamptz
+	Sequence         int32
+}
+
+// bookings of every trip the user is a member of
+func (q *Queries) ListBookingEvents(ctx context.Context, userID pgtype.UUID) ([]ListBookingEventsRow, error) {
+	rows, err := q.db.Query(ctx, listBookingEvents, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookingEventsRow
+	for rows.Next() {
+		var i ListBookingEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Title,
+			&i.Provider,
+			&i.ConfirmationCode,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Address,
+			&i.UpdatedAt,
+			&i.Sequence,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChecklistTemplateItems = `-- name: ListChecklistTemplateItems :many
+SELECT id, template_id, title, position FROM checklist_template_item
+WHERE template_id = $1
+ORDER BY position
+`
+
+func (q *Queries) ListChecklistTemplateItems(ctx context.Context, templateID pgtype.UUID) ([]ChecklistTemplateItem, error) {
+	rows, err := q.db.Query(ctx, listChecklistTemplateItems, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChecklistTemplateItem
+	for rows.Next() {
+		var i ChecklistTemplateItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.TemplateID,
+			&i.Title,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChecklistTemplates = `-- name: ListChecklistTemplates :many
+SELECT t.id, t.user_id, t.name, t.description, t.min_days, t.max_days, t.tag_id,
+ t.created_at, count(i.id) AS item_count
+FROM checklist_template t
+LEFT JOIN checklist_template_item i ON i.template_id = t.id
+WHERE t.user_id IS NULL OR t.user_id = $1
+GROUP BY t.id
+ORDER BY t.user_id NULLS FIRST, t.name, t.id
+`
+
+type ListChecklistTemplatesRow struct {
+	ID          pgtype.UUID
+	UserID      pgtype.UUID
+	Name        string
+	Description string
+	MinDays     pgtype.Int4
+	MaxDays     pgtype.Int4
+	TagID       pgtype.UUID
+	CreatedAt   pgtype.Timestamptz
+	ItemCount   int64
+}
+
+// templates provided by admins and those of the user
+func (q *Queries) ListChecklistTemplates(ctx context.Context, userID pgtype.UUID) ([]ListChecklistTemplatesRow, error) {
+	rows, err := q.db.Query(ctx, listChecklistTemplates, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChecklistTemplatesRow
+	for rows.Next() {
+		var i ListChecklistTemplatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.MinDays,
+			&i.MaxDays,
+			&i.TagID,
+			&i.CreatedAt,
+			&i.ItemCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChildPlaces = `-- name: ListChildPlaces :many
+SELECT id, parent_id, kind, name, country_code, geoname_id, latitude, longitude, admin1_code FROM place
+ WHERE parent_id = $1
+ ORDER BY kind, name
+`
+
+func (q *Queries) ListChildPlaces(ctx context.Context, parentID pgtype.UUID) ([]Place, error) {
+	rows, err := q.db.Query(ctx, listChildPlaces, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Place
+	for rows.Next() {
+		var i Place
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Kind,
+			&i.Name,
+			&i.CountryCode,
+			&i.GeonameID,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Admin1Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCountries = `-- name: ListCountries :many
+SELECT id, parent_id, kind, name, country_code, geoname_id, latitude, longitude, admin1_code FROM place
+ WHERE parent_id IS NULL
+ ORDER BY name
+`
+
+func (q *Queries) ListCountries(ctx context.Context) ([]Place, error) {
+	rows, err := q.db.Query(ctx, listCountries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Place
+	for rows.Next() {
+		var i Place
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Kind,
+			&i.Name,
+			&i.CountryCode,
+			&i.GeonameID,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Admin1Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedDestinations = `-- name: ListDeletedDestinations :many
+SELECT id, name, country, city, deleted_at FROM destination
+ WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+type ListDeletedDestinationsRow struct {
+	ID        pgtype.UUID
+	Name      string
+	Country   pgtype.Text
+	City      pgtype.Text
+	DeletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListDeletedDestinations(ctx context.Context) ([]ListDeletedDestinationsRow, error) {
+	rows, err := q.db.Query(ctx, listDeletedDestinations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeletedDestinationsRow
+	for rows.Next() {
+		var i ListDeletedDestinationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Country,
+			&i.City,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedTrips = `-- name: ListDeletedTrips :many
+SELECT id, name, start_date, end_date, user_id, deleted_at FROM trip
+ WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+type ListDeletedTripsRow struct {
+	ID        pgtype.UUID
+	Name      string
+	StartDate string
+	EndDate   string
+	UserID    pgtype.UUID
+	DeletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListDeletedTrips(ctx context.Context) ([]ListDeletedTripsRow, error) {
+	rows, err := q.db.Query(ctx, listDeletedTrips)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeletedTripsRow
+	for rows.Next() {
+		var i ListDeletedTripsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartDate,
+			&i.EndDate,
+			&i.UserID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDestinationRatings = `-- name: ListDestinationRatings :many
+SELECT destination_id, avg(rating)::float8 AS average_rating, count(*) AS review_count
+FROM review
+WHERE status <> 'hidden'
+GROUP BY destination_id
+`
+
+type ListDestinationRatingsRow struct {
+	DestinationID pgtype.UUID
+	AverageRating float64
+	ReviewCount   int64
+}
+
+// destinations without visible reviews are left out
+func (q *Queries) ListDestinationRatings(ctx context.Context) ([]ListDestinationRatingsRow, error) {
+	rows, err := q.db.Query(ctx, listDestinationRatings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDestinationRatingsRow
+	for rows.Next() {
+		var i ListDestinationRatingsRow
+		if err := rows.Scan(&i.DestinationID, &i.AverageRating, &i.ReviewCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDestinationReviews = `-- name: ListDestinationReviews :many
+SELECT r.id, r.user_id, u.name AS author, r.rating, r.title, r.body,
+ r.visit_date, r.status, r.created_at, r.updated_at
+FROM review r
+JOIN users u ON u.id = r.user_id
+JOIN destination d ON d.id = r.destination_id
+WHERE r.destination_id = $1 AND r.status <> 'hidden' AND d.deleted_at IS NULL
+ORDER BY r.created_at DESC
+`
+
+type ListDestinationReviewsRow struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	Author    string
+	Rating    int32
+	Title     string
+	Body      string
+	VisitDate pgtype.Date
+	Status    string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListDestinationReviews(ctx context.Context, destinationID pgtype.UUID) ([]ListDestinationReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listDestinationReviews, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDestinationReviewsRow
+	for rows.Next() {
+		var i ListDestinationReviewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Author,
+			&i.Rating,
+			&i.Title,
+			&i.Body,
+			&i.VisitDate,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDestinationRevisions = `-- name: ListDestinationRevisions :many
+SELECT r.version, r.action, r.snapshot, r.editor_id, u.name AS editor_name, r.created_at
+FROM destination_revision r
+LEFT JOIN users u ON u.id = r.editor_id
+WHERE r.destination_id = $1
+ORDER BY r.version
+`
+
+type ListDestinationRevisionsRow struct {
+	Version    int32
+	Action     string
+	Snapshot   []byte
+	EditorID   pgtype.UUID
+	EditorName pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) ListDestinationRevisions(ctx context.Context, destinationID pgtype.UUID) ([]ListDestinationRevisionsRow, error) {
+	rows, err := q.db.Query(ctx, listDestinationRevisions, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDestinationRevisionsRow
+	for rows.Next() {
+		var i ListDestinationRevisionsRow
+		if err := rows.Scan(
+			&i.Version,
+			&i.Action,
+			&i.Snapshot,
+			&i.EditorID,
+			&i.EditorName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDestinationTags = `-- name: ListDestinationTags :many
+SELECT dt.destination_id, t.slug, t.name, t.category
+FROM destination_tag dt
+JOIN tag t ON t.id = dt.tag_id
+WHERE dt.destination_id = ANY($1::uuid[])
+ORDER BY t.category, t.name
+`
+
+type ListDestinationTagsRow struct {
+	DestinationID pgtype.UUID
+	Slug          string
+	Name          string
+	Category      string
+}
+
+// tags of each of the given destinations
+func (q *Queries) ListDestinationTags(ctx context.Context, destinationIds []pgtype.UUID) ([]ListDestinationTagsRow, error) {
+	rows, err := q.db.Query(ctx, listDestinationTags, destinationIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDestinationTagsRow
+	for rows.Next() {
+		var i ListDestinationTagsRow
+		if err := rows.Scan(
+			&i.DestinationID,
+			&i.Slug,
+			&i.Name,
+			&i.Category,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDestinationTranslations = `-- name: ListDestinationTranslations :many
+SELECT destination_id, locale, name, description, attraction, updated_at FROM destination_translation
+ WHERE destination_id = $1
+ORDER BY locale
+`
+
+func (q *Queries) ListDestinationTranslations(ctx context.Context, destinationID pgtype.UUID) ([]DestinationTranslation, error) {
+	rows, err := q.db.Query(ctx, listDestinationTranslations, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DestinationTranslation
+	for rows.Next() {
+		var i DestinationTranslation
+		if err := rows.Scan(
+			&i.DestinationID,
+			&i.Locale,
+			&i.Name,
+			&i.Description,
+			&i.Attraction,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDestinations = `-- name: ListDestinations :many
+SELECT id, name, description, attraction, latitude, longitude, country, region, city, place_id, deleted_at FROM destination
+ WHERE deleted_at IS NULL
+`
+
+func (q *Queries) ListDestinations(ctx context.Context) ([]Destination, error) {
+	rows, err := q.db.Query(ctx, listDestinations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Destination
+	for rows.Next() {
+		var i Destination
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Attraction,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Country,
+			&i.Region,
+			&i.City,
+			&i.PlaceID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDestinationsByTags = `-- name: ListDestinationsByTags :many
+SELECT id, name, description, attraction, latitude, longitude, country, region, city, place_id, deleted_at FROM destination
+WHERE deleted_at IS NULL AND id IN (
+  SELECT dt.destination_id
+  FROM destination_tag dt
+  JOIN tag t ON t.id = dt.tag_id
+  WHERE t.slug = ANY($1::text[])
+  GROUP BY dt.destination_id
+  HAVING count(*) = cardinality($1::text[])
+)
+`
+
+// destinations having every one of the given tags, slugs must be distinct
+func (q *Queries) ListDestinationsByTags(ctx context.Context, slugs []string) ([]Destination, error) {
+	rows, err := q.db.Query(ctx, listDestinationsByTags, slugs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Destination
+	for rows.Next() {
+		var i Destination
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Attraction,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Country,
+			&i.Region,
+			&i.City,
+			&i.PlaceID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExchangeRates = `-- name: ListExchangeRates :many
+SELECT base, quote, rate::text AS rate, updated_at FROM exchange_rate
+ORDER BY base, quote
+`
+
+type ListExchangeRatesRow struct {
+	Base      string
+	Quote     string
+	Rate      string
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListExchangeRates(ctx context.Context) ([]ListExchangeRatesRow, error) {
+	rows, err := q.db.Query(ctx, listExchangeRates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExchangeRatesRow
+	for rows.Next() {
+		var i ListExchangeRatesRow
+		if err := rows.Scan(
+			&i.Base,
+			&i.Quote,
+			&i.Rate,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlaggedReviews = `-- name: ListFlaggedReviews :many
+SELECT r.id, r.destination_id, r.user_id, u.name AS author, r.rating, r.title,
+ r.body, r.visit_date, r.status, r.created_at, r.updated_at
+FROM review r
+JOIN users u ON u.id = r.user_id
+WHERE r.status = 'flagged'
+ORDER BY r.updated_at
+`
+
+type ListFlaggedReviewsRow struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	UserID        pgtype.UUID
+	Author        string
+	Rating        int32
+	Title         string
+	Body          string
+	VisitDate     pgtype.Date
+	Status        string
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) ListFlaggedReviews(ctx context.Context) ([]ListFlaggedReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listFlaggedReviews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFlaggedReviewsRow
+	for rows.Next() {
+		var i ListFlaggedReviewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DestinationID,
+			&i.UserID,
+			&i.Author,
+			&i.Rating,
+			&i.Title,
+			&i.Body,
+			&i.VisitDate,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerParticipants = `-- name: ListLedgerParticipants :many
+SELECT id, name, user_id, created_at FROM ledger_participant
+WHERE trip_id = $1
+ORDER BY name, id
+`
+
+type ListLedgerParticipantsRow struct {
+	ID        pgtype.UUID
+	Name      string
+	UserID    pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListLedgerParticipants(ctx context.Context, tripID pgtype.UUID) ([]ListLedgerParticipantsRow, error) {
+	rows, err := q.db.Query(ctx, listLedgerParticipants, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLedgerParticipantsRow
+	for rows.Next() {
+		var i ListLedgerParticipantsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.UserID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerPayments = `-- name: ListLedgerPayments :many
+SELECT p.id, p.paid_by, pp.name AS payer_name, p.amount, p.currency, p.description,
+ p.split, p.created_at
+FROM ledger_payment p
+JOIN ledger_participant pp ON pp.id = p.paid_by
+WHERE p.trip_id = $1
+ORDER BY p.created_at, p.id
+`
+
+type ListLedgerPaymentsRow struct {
+	ID          pgtype.UUID
+	PaidBy      pgtype.UUID
+	PayerName   string
+	Amount      int64
+	Currency    string
+	Description string
+	Split       string
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) ListLedgerPayments(ctx context.Context, tripID pgtype.UUID) ([]ListLedgerPaymentsRow, error) {
+	rows, err := q.db.Query(ctx, listLedgerPayments, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLedgerPaymentsRow
+	for rows.Next() {
+		var i ListLedgerPaymentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PaidBy,
+			&i.PayerName,
+			&i.Amount,
+			&i.Currency,
+			&i.Description,
+			&i.Split,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerSplits = `-- name: ListLedgerSplits :many
+SELECT s.payment_id, s.participant_id, pp.name, s.shares, s.amount
+FROM ledger_split s
+JOIN ledger_payment p ON p.id = s.payment_id
+JOIN ledger_participant pp ON pp.id = s.participant_id
+WHERE p.trip_id = $1
+ORDER BY pp.name, pp.id
+`
+
+type ListLedgerSplitsRow struct {
+	PaymentID     pgtype.UUID
+	ParticipantID pgtype.UUID
+	Name          string
+	Shares        int32
+	Amount        int64
+}
+
+func (q *Queries) ListLedgerSplits(ctx context.Context, tripID pgtype.UUID) ([]ListLedgerSplitsRow, error) {
+	rows, err := q.db.Query(ctx, listLedgerSplits, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLedgerSplitsRow
+	for rows.Next() {
+		var i ListLedgerSplitsRow
+		if err := rows.Scan(
+			&i.PaymentID,
+			&i.ParticipantID,
+			&i.Name,
+			&i.Shares,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMediaFiles = `-- name: ListMediaFiles :many
+SELECT m.id, m.destination_id, m.key, m.thumbnail_key, m.content_type, m.size, m.width, m.height, m.caption, m.position, m.is_cover, m.created_at FROM media_file m
+JOIN destination d ON d.id = m.destination_id
+WHERE m.destination_id = $1 AND d.deleted_at IS NULL
+ORDER BY m.position, m.created_at
+`
+
+// none for destinations in the trash
+func (q *Queries) ListMediaFiles(ctx context.Context, destinationID pgtype.UUID) ([]MediaFile, error) {
+	rows, err := q.db.Query(ctx, listMediaFiles, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MediaFile
+	for rows.Next() {
+		var i MediaFile
+		if err := rows.Scan(
+			&i.ID,
+			&i.DestinationID,
+			&i.Key,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Size,
+			&i.Width,
+			&i.Height,
+			&i.Caption,
+			&i.Position,
+			&i.IsCover,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberTrips = `-- name: ListMemberTrips :many
+SELECT t.id, t.name, t.start_date, t.end_date, t.destination_id, t.user_id, t.deleted_at, t.updated_at, t.sequence, t.budget, t.currency,
+ (t.destination_id IS NULL OR d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip t
+JOIN trip_member m ON m.trip_id = t.id
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE m.user_id = $1 AND t.deleted_at IS NULL
+`
+
+type ListMemberTripsRow struct {
+	ID                 pgtype.UUID
+	Name               string
+	StartDate          string
+	EndDate            string
+	DestinationID      pgtype.UUID
+	UserID             pgtype.UUID
+	DeletedAt          pgtype.Timestamptz
+	UpdatedAt          pgtype.Timestamptz
+	Sequence           int32
+	Budget             pgtype.Int8
+	Currency           string
+	DestinationDeleted bool
+}
+
+// trips the user is a member of
+func (q *Queries) ListMemberTrips(ctx context.Context, userID pgtype.UUID) ([]ListMemberTripsRow, error) {
+	rows, err := q.db.Query(ctx, listMemberTrips, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMemberTripsRow
+	for rows.Next() {
+		var i ListMemberTripsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartDate,
+			&i.EndDate,
+			&i.DestinationID,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.UpdatedAt,
+			&i.Sequence,
+			&i.Budget,
+			&i.Currency,
+			&i.DestinationDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNearbyDestinations = `-- name: ListNearbyDestinations :many
+SELECT id, name, description, attraction,
+ latitude, longitude, country, region, city, place_id,
+ (6371 * 2 * asin(least(1, sqrt(
+   power(sin(radians(latitude - $1::float8) / 2), 2) +
+   cos(radians($1::float8)) * cos(radians(latitude)) *
+   power(sin(radians(longitude - $2::float8) / 2), 2)
+ ))))::float8 AS distance_km
+FROM destination
+WHERE deleted_at IS NULL
+ AND latitude BETWEEN $3::float8 AND $4::float8
+ AND longitude BETWEEN $5::float8 AND $6::float8
+ AND 6371 * 2 * asin(least(1, sqrt(
+   power(sin(radians(latitude - $1::float8) / 2), 2) +
+   cos(radians($1::float8)) * cos(radians(latitude)) *
+   power(sin(radians(longitude - $2::float8) / 2), 2)
+ ))) <= $7::float8
+ORDER BY distance_km
+LIMIT $8
+`
+
+type ListNearbyDestinationsParams struct {
+	Lat        float64
+	Lng        float64
+	MinLat     float64
+	MaxLat     float64
+	MinLng     float64
+	MaxLng     float64
+	RadiusKm   float64
+	MaxResults int32
+}
+
+type ListNearbyDestinationsRow struct {
+	ID          pgtype.UUID
+	Name        string
+	Description string
+	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+	PlaceID     pgtype.UUID
+	DistanceKm  float64
+}
+
+// great-circle distance using the haversine formula, the bounding box
+// lets the coordinates index skip destinations that are too far away
+func (q *Queries) ListNearbyDestinations(ctx context.Context, arg ListNearbyDestinationsParams) ([]ListNearbyDestinationsRow, error) {
+	rows, err := q.db.Query(ctx, listNearbyDestinations,
+		arg.Lat,
+		arg.Lng,
+		arg.MinLat,
+		arg.MaxLat,
+		arg.MinLng,
+		arg.MaxLng,
+		arg.RadiusKm,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNearbyDestinationsRow
+	for rows.Next() {
+		var i ListNearbyDestinationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Attraction,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Country,
+			&i.Region,
+			&i.City,
+			&i.PlaceID,
+			&i.DistanceKm,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaceAncestors = `-- name: ListPlaceAncestors :many
+WITH RECURSIVE ancestor AS (
+  SELECT id, parent_id, kind, name, country_code FROM place
+   WHERE id = ANY($1::uuid[])
+  UNION
+  SELECT p.id, p.parent_id, p.kind, p.name, p.country_code FROM place p
+   JOIN ancestor a ON p.id = a.parent_id
+)
+SELECT id, parent_id, kind, name, country_code FROM ancestor
+`
+
+type ListPlaceAncestorsRow struct {
+	ID          pgtype.UUID
+	ParentID    pgtype.UUID
+	Kind        string
+	Name        string
+	CountryCode string
+}
+
+// the given places and all of their ancestors, used to build breadcrumbs
+func (q *Queries) ListPlaceAncestors(ctx context.Context, ids []pgtype.UUID) ([]ListPlaceAncestorsRow, error) {
+	rows, err := q.db.Query(ctx, listPlaceAncestors, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPlaceAncestorsRow
+	for rows.Next() {
+		var i ListPlaceAncestorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Kind,
+			&i.Name,
+			&i.CountryCode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaceDestinations = `-- name: ListPlaceDestinations :many
+SELECT id, name, description, attraction, latitude, longitude, country, region, city, place_id, deleted_at FROM destination
+ WHERE place_id = $1 AND deleted_at IS NULL
+ ORDER BY name
+`
+
+func (q *Queries) ListPlaceDestinations(ctx context.Context, placeID pgtype.UUID) ([]Destination, error) {
+	rows, err := q.db.Query(ctx, listPlaceDestinations, placeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Destination
+	for rows.Next() {
+		var i Destination
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Attraction,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Country,
+			&i.Region,
+			&i.City,
+			&i.PlaceID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSharedTripStops = `-- name: ListSharedTripStops :many
+SELECT td.position, d.name, d.description, d.attraction,
+ d.latitude, d.longitude, d.country, d.region, d.city
+FROM trip_destination td
+JOIN destination d ON d.id = td.destination_id
+WHERE td.trip_id = $1 AND d.deleted_at IS NULL
+ORDER BY td.position
+`
+
+type ListSharedTripStopsRow struct {
+	Position    int32
+	Name        string
+	Description string
+	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+}
+
+// stops of trips made from a wishlist, deleted destinations left out
+func (q *Queries) ListSharedTripStops(ctx context.Context, tripID pgtype.UUID) ([]ListSharedTripStopsRow, error) {
+	rows, err := q.db.Query(ctx, listSharedTripStops, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSharedTripStopsRow
+	for rows.Next() {
+		var i ListSharedTripStopsRow
+		if err := rows.Scan(
+			&i.Position,
+			&i.Name,
+			&i.Description,
+			&i.Attraction,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Country,
+			&i.Region,
+			&i.City,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT t.id, t.slug, t.name, t.category,
+ count(d.id) AS destination_count
+FROM tag t
+LEFT JOIN destination_tag dt ON dt.tag_id = t.id
+LEFT JOIN destination d ON d.id = dt.destination_id AND d.deleted_at IS NULL
+GROUP BY t.id
+ORDER BY t.category, t.name
+`
+
+type ListTagsRow struct {
+	ID               pgtype.UUID
+	Slug             string
+	Name             string
+	Category         string
+	DestinationCount int64
+}
+
+func (q *Queries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	rows, err := q.db.Query(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsRow
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Name,
+			&i.Category,
+			&i.DestinationCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranslatedLocales = `-- name: ListTranslatedLocales :many
+SELECT destination_id, locale FROM destination_translation
+ WHERE destination_id = ANY($1::uuid[])
+ORDER BY locale
+`
+
+type ListTranslatedLocalesRow struct {
+	DestinationID pgtype.UUID
+	Locale        string
+}
+
+func (q *Queries) ListTranslatedLocales(ctx context.Context, destinationIds []pgtype.UUID) ([]ListTranslatedLocalesRow, error) {
+	rows, err := q.db.Query(ctx, listTranslatedLocales, destinationIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTranslatedLocalesRow
+	for rows.Next() {
+		var i ListTranslatedLocalesRow
+		if err := rows.Scan(&i.DestinationID, &i.Locale); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranslations = `-- name: ListTranslations :many
+SELECT destination_id, locale, name, description, attraction, updated_at FROM destination_translation
+ WHERE destination_id = ANY($1::uuid[])
+ AND locale = ANY($2::text[])
+`
+
+type ListTranslationsParams struct {
+	DestinationIds []pgtype.UUID
+	Locales        []string
+}
+
+// translations of the given destinations in the given locales
+func (q *Queries) ListTranslations(ctx context.Context, arg ListTranslationsParams) ([]DestinationTranslation, error) {
+	rows, err := q.db.Query(ctx, listTranslations, arg.DestinationIds, arg.Locales)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DestinationTranslation
+	for rows.Next() {
+		var i DestinationTranslation
+		if err := rows.Scan(
+			&i.DestinationID,
+			&i.Locale,
+			&i.Name,
+			&i.Description,
+			&i.Attraction,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripActivities = `-- name: ListTripActivities :many
+SELECT a.id, a.title, a.category, a.day,
+ to_char(a.start_time, 'HH24:MI') AS start_time,
+ to_char(a.end_time, 'HH24:MI') AS end_time,
+ a.location, a.destination_id, d.name AS destination_name,
+ a.attraction_id, at.name AS attraction_name, a.notes
+FROM activity a
+LEFT JOIN destination d ON d.id = a.destination_id AND d.deleted_at IS NULL
+LEFT JOIN attraction at ON at.id = a.attraction_id
+WHERE a.trip_id = $1
+ORDER BY a.day, a.start_time, a.id
+`
+
+type ListTripActivitiesRow struct {
+	ID              pgtype.UUID
+	Title           string
+	Category        string
+	Day             pgtype.Date
+	StartTime       string
+	EndTime         string
+	Location        string
+	DestinationID   pgtype.UUID
+	DestinationName pgtype.Text
+	AttractionID    pgtype.UUID
+	AttractionName  pgtype.Text
+	Notes           string
+}
+
+func (q *Queries) ListTripActivities(ctx context.Context, tripID pgtype.UUID) ([]ListTripActivitiesRow, error) {
+	rows, err := q.db.Query(ctx, listTripActivities, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripActivitiesRow
+	for rows.Next() {
+		var i ListTripActivitiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Category,
+			&i.Day,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Location,
+			&i.DestinationID,
+			&i.DestinationName,
+			&i.AttractionID,
+			&i.AttractionName,
+			&i.Notes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripBookingAttachments = `-- name: ListTripBookingAttachments :many
+SELECT a.id, a.booking_id, a.key, a.filename, a.content_type, a.size, a.created_at
+FROM booking_attachment a
+JOIN booking b ON b.id = a.booking_id
+WHERE b.trip_id = $1
+ORDER BY a.created_at, a.id
+`
+
+type ListTripBookingAttachmentsRow struct {
+	ID          pgtype.UUID
+	BookingID   pgtype.UUID
+	Key         string
+	Filename    string
+	ContentType string
+	Size        int64
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) ListTripBookingAttachments(ctx context.Context, tripID pgtype.UUID) ([]ListTripBookingAttachmentsRow, error) {
+	rows, err := q.db.Query(ctx, listTripBookingAttachments, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripBookingAttachmentsRow
+	for rows.Next() {
+		var i ListTripBookingAttachmentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.Key,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripBookingEvents = `-- name: ListTripBookingEvents :many
+SELECT id, kind, title, provider, confirmation_code, starts_at, ends_at,
+ address, updated_at, sequence
+FROM booking
+WHERE trip_id = $1
+ORDER BY starts_at, id
+`
+
+type ListTripBookingEventsRow struct {
+	ID               pgtype.UUID
+	Kind             string
+	Title            string
+	Provider         string
+	ConfirmationCode string
+	StartsAt         pgtype.Timestamptz
+	EndsAt           pgtype.Timestamptz
+	Address          string
+	UpdatedAt        pgtype.Timestamptz
+	Sequence         int32
+}
+
+func (q *Queries) ListTripBookingEvents(ctx context.Context, tripID pgtype.UUID) ([]ListTripBookingEventsRow, error) {
+	rows, err := q.db.Query(ctx, listTripBookingEvents, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripBookingEventsRow
+	for rows.Next() {
+		var i ListTripBookingEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Title,
+			&i.Provider,
+			&i.ConfirmationCode,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Address,
+			&i.UpdatedAt,
+			&i.Sequence,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripBookings = `-- name: ListTripBookings :many
+SELECT id, trip_id, kind, title, provider, confirmation_code, starts_at, start_zone, ends_at, end_zone, address, cost, currency, notes, created_by, created_at, updated_at, sequence FROM booking
+WHERE trip_id = $1
+ORDER BY starts_at, id
+`
+
+func (q *Queries) ListTripBookings(ctx context.Context, tripID pgtype.UUID) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listTripBookings, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.TripID,
+			&i.Kind,
+			&i.Title,
+			&i.Provider,
+			&i.ConfirmationCode,
+			&i.StartsAt,
+			&i.StartZone,
+			&i.EndsAt,
+			&i.EndZone,
+			&i.Address,
+			&i.Cost,
+			&i.Currency,
+			&i.Notes,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Sequence,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripChecklistItems = `-- name: ListTripChecklistItems :many
+SELECT i.id, i.checklist_id, i.title, i.assignee_id, u.name AS assignee_name,
+ i.done, i.done_at, i.position, i.created_at
+FROM checklist_item i
+JOIN checklist c ON c.id = i.checklist_id
+LEFT JOIN users u ON u.id = i.assignee_id
+WHERE c.trip_id = $1
+ORDER BY i.position, i.id
+`
+
+type ListTripChecklistItemsRow struct {
+	ID           pgtype.UUID
+	ChecklistID  pgtype.UUID
+	Title        string
+	AssigneeID   pgtype.UUID
+	AssigneeName pgtype.Text
+	Done         bool
+	DoneAt       pgtype.Timestamptz
+	Position     int32
+	CreatedAt    pgtype.Timestamptz
+}
+
+func (q *Queries) ListTripChecklistItems(ctx context.Context, tripID pgtype.UUID) ([]ListTripChecklistItemsRow, error) {
+	rows, err := q.db.Query(ctx, listTripChecklistItems, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripChecklistItemsRow
+	for rows.Next() {
+		var i ListTripChecklistItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChecklistID,
+			&i.Title,
+			&i.AssigneeID,
+			&i.AssigneeName,
+			&i.Done,
+			&i.DoneAt,
+			&i.Position,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripChecklists = `-- name: ListTripChecklists :many
+SELECT id, trip_id, name, template_id, created_at FROM checklist
+WHERE trip_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListTripChecklists(ctx context.Context, tripID pgtype.UUID) ([]Checklist, error) {
+	rows, err := q.db.Query(ctx, listTripChecklists, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Checklist
+	for rows.Next() {
+		var i Checklist
+		if err := rows.Scan(
+			&i.ID,
+			&i.TripID,
+			&i.Name,
+			&i.TemplateID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripDestinations = `-- name: ListTripDestinations :many
+SELECT td.destination_id, d.name, td.position,
+ (d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip_destination td
+JOIN destination d ON d.id = td.destination_id
+WHERE td.trip_id = $1
+ORDER BY td.position
+`
+
+type ListTripDestinationsRow struct {
+	DestinationID      pgtype.UUID
+	Name               string
+	Position           int32
+	DestinationDeleted bool
+}
+
+// deleted destinations stay on the trip until they are purged
+func (q *Queries) ListTripDestinations(ctx context.Context, tripID pgtype.UUID) ([]ListTripDestinationsRow, error) {
+	rows, err := q.db.Query(ctx, listTripDestinations, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripDestinationsRow
+	for rows.Next() {
+		var i ListTripDestinationsRow
+		if err := rows.Scan(
+			&i.DestinationID,
+			&i.Name,
+			&i.Position,
+			&i.DestinationDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripEvents = `-- name: ListTripEvents :many
+SELECT t.id, t.name, t.start_date, t.end_date, t.updated_at, t.sequence,
+ d.name AS destination_name, d.description AS destination_description
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+JOIN trip_member m ON m.trip_id = t.id
+WHERE m.user_id = $1 AND t.deleted_at IS NULL
+ORDER BY t.start_date, t.name
+`
+
+type ListTripEventsRow struct {
+	ID                     pgtype.UUID
+	Name                   string
+	StartDate              string
+	EndDate                string
+	UpdatedAt              pgtype.Timestamptz
+	Sequence               int32
+	DestinationName        pgtype.Text
+	DestinationDescription pgtype.Text
+}
+
+func (q *Queries) ListTripEvents(ctx context.Context, userID pgtype.UUID) ([]ListTripEventsRow, error) {
+	rows, err := q.db.Query(ctx, listTripEvents, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripEventsRow
+	for rows.Next() {
+		var i ListTripEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartDate,
+			&i.EndDate,
+			&i.UpdatedAt,
+			&i.Sequence,
+			&i.DestinationName,
+			&i.DestinationDescription,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripExpenses = `-- name: ListTripExpenses :many
+SELECT e.id, e.amount, e.currency, e.category, e.paid_by, u.name AS payer_name,
+ e.spent_on, e.note, e.created_at
+FROM expense e
+LEFT JOIN users u ON u.id = e.paid_by
+WHERE e.trip_id = $1
+ORDER BY e.spent_on, e.created_at
+`
+
+type ListTripExpensesRow struct {
+	ID        pgtype.UUID
+	Amount    int64
+	Currency  string
+	Category  string
+	PaidBy    pgtype.UUID
+	PayerName pgtype.Text
+	SpentOn   pgtype.Date
+	Note      string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListTripExpenses(ctx context.Context, tripID pgtype.UUID) ([]ListTripExpensesRow, error) {
+	rows, err := q.db.Query(ctx, listTripExpenses, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripExpensesRow
+	for rows.Next() {
+		var i ListTripExpensesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.Currency,
+			&i.Category,
+			&i.PaidBy,
+			&i.PayerName,
+			&i.SpentOn,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripInvitations = `-- name: ListTripInvitations :many
+SELECT i.id, i.email, i.role, i.invited_by, u.name AS invited_by_name, i.created_at
+FROM trip_invitation i
+LEFT JOIN users u ON u.id = i.invited_by
+WHERE i.trip_id = $1 AND i.status = 'pending'
+ORDER BY i.created_at
+`
+
+type ListTripInvitationsRow struct {
+	ID            pgtype.UUID
+	Email         string
+	Role          string
+	InvitedBy     pgtype.UUID
+	InvitedByName pgtype.Text
+	CreatedAt     pgtype.Timestamptz
+}
+
+// pending ones, tokens are only sent to the invitee
+func (q *Queries) ListTripInvitations(ctx context.Context, tripID pgtype.UUID) ([]ListTripInvitationsRow, error) {
+	rows, err := q.db.Query(ctx, listTripInvitations, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripInvitationsRow
+	for rows.Next() {
+		var i ListTripInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.InvitedByName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripMembers = `-- name: ListTripMembers :many
+SELECT m.user_id, u.name, m.role, m.created_at FROM trip_member m
+JOIN users u ON u.id = m.user_id
+WHERE m.trip_id = $1
+ORDER BY array_position(ARRAY['owner', 'editor', 'viewer'], m.role), u.name
+`
+
+type ListTripMembersRow struct {
+	UserID    pgtype.UUID
+	Name      string
+	Role      string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListTripMembers(ctx context.Context, tripID pgtype.UUID) ([]ListTripMembersRow, error) {
+	rows, err := q.db.Query(ctx, listTripMembers, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripMembersRow
+	for rows.Next() {
+		var i ListTripMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Name,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripShares = `-- name: ListTripShares :many
+SELECT id, token, (password_hash IS NOT NULL)::bool AS has_password,
+ expires_at, view_count, last_viewed_at, created_at
+FROM trip_share
+WHERE trip_id = $1
+ORDER BY created_at DESC
+`
+
+type ListTripSharesRow struct {
+	ID           pgtype.UUID
+	Token        string
+	HasPassword  bool
+	ExpiresAt    pgtype.Timestamptz
+	ViewCount    int32
+	LastViewedAt pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+}
+
+func (q *Queries) ListTripShares(ctx context.Context, tripID pgtype.UUID) ([]ListTripSharesRow, error) {
+	rows, err := q.db.Query(ctx, listTripShares, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripSharesRow
+	for rows.Next() {
+		var i ListTripSharesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Token,
+			&i.HasPassword,
+			&i.ExpiresAt,
+			&i.ViewCount,
+			&i.LastViewedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrips = `-- name: ListTrips :many
+SELECT t.id, t.name, t.start_date, t.end_date, t.destination_id, t.user_id, t.deleted_at, t.updated_at, t.sequence, t.budget, t.currency,
+ (t.destination_id IS NULL OR d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE t.deleted_at IS NULL
+`
+
+type ListTripsRow struct {
+	ID                 pgtype.UUID
+	Name               string
+	StartDate          string
+	EndDate            string
+	DestinationID      pgtype.UUID
+	UserID             pgtype.UUID
+	DeletedAt          pgtype.Timestamptz
+	UpdatedAt          pgtype.Timestamptz
+	Sequence           int32
+	Budget             pgtype.Int8
+	Currency           string
+	DestinationDeleted bool
+}
+
+func (q *Queries) ListTrips(ctx context.Context) ([]ListTripsRow, error) {
+	rows, err := q.db.Query(ctx, listTrips)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTripsRow
+	for rows.Next() {
+		var i ListTripsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartDate,
+			&i.EndDate,
+			&i.DestinationID,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.UpdatedAt,
+			&i.Sequence,
+			&i.Budget,
+			&i.Currency,
+			&i.DestinationDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserInvitations = `-- name: ListUserInvitations :many
+SELECT i.id, i.trip_id, t.name AS trip_name, i.role,
+ u.name AS invited_by_name, i.created_at
+FROM trip_invitation i
+JOIN trip t ON t.id = i.trip_id
+JOIN users me ON lower(me.email) = lower(i.email)
+LEFT JOIN users u ON u.id = i.invited_by
+WHERE me.id = $1 AND i.status = 'pending' AND t.deleted_at IS NULL
+ORDER BY i.created_at DESC
+`
+
+type ListUserInvitationsRow struct {
+	ID            pgtype.UUID
+	TripID        pgtype.UUID
+	TripName      string
+	Role          string
+	InvitedByName pgtype.Text
+	CreatedAt     pgtype.Timestamptz
+}
+
+// pending invitations sent to the email of the user, tokens are only
+// sent by email
+func (q *Queries) ListUserInvitations(ctx context.Context, id pgtype.UUID) ([]ListUserInvitationsRow, error) {
+	rows, err := q.db.Query(ctx, listUserInvitations, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserInvitationsRow
+	for rows.Next() {
+		var i ListUserInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TripID,
+			&i.TripName,
+			&i.Role,
+			&i.InvitedByName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWishlistItems = `-- name: ListWishlistItems :many
+SELECT i.destination_id, d.name, d.country, d.city, i.note, i.position, i.added_at
+FROM wishlist_item i
+JOIN destination d ON d.id = i.destination_id
+WHERE i.wishlist_id = $1 AND d.deleted_at IS NULL
+ORDER BY i.position
+`
+
+type ListWishlistItemsRow struct {
+	DestinationID pgtype.UUID
+	Name          string
+	Country       pgtype.Text
+	City          pgtype.Text
+	Note          string
+	Position      int32
+	AddedAt       pgtype.Timestamptz
+}
+
+func (q *Queries) ListWishlistItems(ctx context.Context, wishlistID pgtype.UUID) ([]ListWishlistItemsRow, error) {
+	rows, err := q.db.Query(ctx, listWishlistItems, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWishlistItemsRow
+	for rows.Next() {
+		var i ListWishlistItemsRow
+		if err := rows.Scan(
+			&i.DestinationID,
+			&i.Name,
+			&i.Country,
+			&i.City,
+			&i.Note,
+			&i.Position,
+			&i.AddedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWishlists = `-- name: ListWishlists :many
+SELECT w.id, w.name, w.is_default, w.share_token, w.created_at,
+ count(d.id) AS item_count
+FROM wishlist w
+LEFT JOIN wishlist_item i ON i.wishlist_id = w.id
+LEFT JOIN destination d ON d.id = i.destination_id AND d.deleted_at IS NULL
+WHERE w.user_id = $1
+GROUP BY w.id
+ORDER BY w.is_default DESC, w.name
+`
+
+type ListWishlistsRow struct {
+	ID         pgtype.UUID
+	Name       string
+	IsDefault  bool
+	ShareToken pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	ItemCount  int64
+}
+
+func (q *Queries) ListWishlists(ctx context.Context, userID pgtype.UUID) ([]ListWishlistsRow, error) {
+	rows, err := q.db.Query(ctx, listWishlists, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWishlistsRow
+	for rows.Next() {
+		var i ListWishlistsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.IsDefault,
+			&i.ShareToken,
+			&i.CreatedAt,
+			&i.ItemCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockTripDates = `-- name: LockTripDates :one
+SELECT start_date, end_date FROM trip
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type LockTripDatesRow struct {
+	StartDate string
+	EndDate   string
+}
+
+// held until the end of the transaction so activities are checked for
+// overlaps one at a time
+func (q *Queries) LockTripDates(ctx context.Context, id pgtype.UUID) (LockTripDatesRow, error) {
+	row := q.db.QueryRow(ctx, lockTripDates, id)
+	var i LockTripDatesRow
+	err := row.Scan(&i.StartDate, &i.EndDate)
+	return i, err
+}
+
+const overlappingActivity = `-- name: OverlappingActivity :one
+SELECT id, title FROM activity
+WHERE trip_id = $1 AND day = $2
+ AND start_time < $3 AND end_time > $4
+ AND id <> $5
+ORDER BY start_time
+LIMIT 1
+`
+
+type OverlappingActivityParams struct {
+	TripID    pgtype.UUID
+	Day       pgtype.Date
+	EndTime   pgtype.Time
+	StartTime pgtype.Time
+	ID        pgtype.UUID
+}
+
+type OverlappingActivityRow struct {
+	ID    pgtype.UUID
+	Title string
+}
+
+// the first activity of the day sharing some time with the given one,
+// other than itself
+func (q *Queries) OverlappingActivity(ctx context.Context, arg OverlappingActivityParams) (OverlappingActivityRow, error) {
+	row := q.db.QueryRow(ctx, overlappingActivity,
+		arg.TripID,
+		arg.Day,
+		arg.EndTime,
+		arg.StartTime,
+		arg.ID,
+	)
+	var i OverlappingActivityRow
+	err := row.Scan(&i.ID, &i.Title)
+	return i, err
+}
+
+const promoteAdmin = `-- name: PromoteAdmin :exec
+UPDATE users SET admin = true
+ WHERE email = $1
+`
+
+func (q *Queries) PromoteAdmin(ctx context.Context, email string) error {
+	_, err := q.db.Exec(ctx, promoteAdmin, email)
+	return err
+}
+
+const purgeDestinations = `-- name: PurgeDestinations :execrows
+DELETE FROM destination
+ WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDestinations(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDestinations, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeTrips = `-- name: PurgeTrips :execrows
+DELETE FROM trip
+ WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeTrips(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeTrips, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordTripShareView = `-- name: RecordTripShareView :exec
+UPDATE trip_share
+ SET view_count = view_count + 1,
+ last_viewed_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) RecordTripShareView(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, recordTripShareView, id)
+	return err
+}
+
+const removeTripMember = `-- name: RemoveTripMember :execrows
+DELETE FROM trip_member
+ WHERE trip_id = $1 AND user_id = $2 AND role <> 'owner'
+`
+
+type RemoveTripMemberParams struct {
+	TripID pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) RemoveTripMember(ctx context.Context, arg RemoveTripMemberParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeTripMember, arg.TripID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const removeWishlistItem = `-- name: RemoveWishlistItem :execrows
+DELETE FROM wishlist_item
+ WHERE wishlist_id = $1 AND destination_id = $2
+`
+
+type RemoveWishlistItemParams struct {
+	WishlistID    pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+func (q *Queries) RemoveWishlistItem(ctx context.Context, arg RemoveWishlistItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeWishlistItem, arg.WishlistID, arg.DestinationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const renameChecklist = `-- name: RenameChecklist :execrows
+UPDATE checklist
+ SET name = $3
+WHERE id = $1 AND trip_id = $2
+`
+
+type RenameChecklistParams struct {
+	ID     pgtype.UUID
+	TripID pgtype.UUID
+	Name   string
+}
+
+func (q *Queries) RenameChecklist(ctx context.Context, arg RenameChecklistParams) (int64, error) {
+	result, err := q.db.Exec(ctx, renameChecklist, arg.ID, arg.TripID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const renameWishlist = `-- name: RenameWishlist :execrows
+UPDATE wishlist
+ SET name = $3
+WHERE id = $1 AND user_id = $2 AND NOT is_default
+`
+
+type RenameWishlistParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+	Name   string
+}
+
+func (q *Queries) RenameWishlist(ctx context.Context, arg RenameWishlistParams) (int64, error) {
+	result, err := q.db.Exec(ctx, renameWishlist, arg.ID, arg.UserID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reorderMediaFiles = `-- name: ReorderMediaFiles :execrows
+UPDATE media_file m
+ SET position = o.position
+FROM unnest($1::uuid[]) WITH ORDINALITY AS o(id, position)
+WHERE m.id = o.id AND m.destination_id = $2
+`
+
+type ReorderMediaFilesParams struct {
+	Ids           []pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+// positions follow the order of ids
+func (q *Queries) ReorderMediaFiles(ctx context.Context, arg ReorderMediaFilesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reorderMediaFiles, arg.Ids, arg.DestinationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reorderWishlistItems = `-- name: ReorderWishlistItems :execrows
+UPDATE wishlist_item i
+ SET position = o.position
+FROM unnest($1::uuid[]) WITH ORDINALITY AS o(destination_id, position)
+WHERE i.destination_id = o.destination_id AND i.wishlist_id = $2
+`
+
+type ReorderWishlistItemsParams struct {
+	DestinationIds []pgtype.UUID
+	WishlistID     pgtype.UUID
+}
+
+// positions follow the order of destination_ids
+func (q *Queries) ReorderWishlistItems(ctx context.Context, arg ReorderWishlistItemsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reorderWishlistItems, arg.DestinationIds, arg.WishlistID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const respondTripInvitation = `-- name: RespondTripInvitation :one
+UPDATE trip_invitation i
+ SET status = $1,
+ responded_at = now()
+FROM users me, trip t
+WHERE i.token = $2 AND i.status = 'pending'
+ AND me.id = $3 AND lower(me.email) = lower(i.email)
+ AND t.id = i.trip_id AND t.deleted_at IS NULL
+RETURNING i.trip_id, i.role
+`
+
+type RespondTripInvitationParams struct {
+	Status string
+	Token  string
+	UserID pgtype.UUID
+}
+
+type RespondTripInvitationRow struct {
+	TripID pgtype.UUID
+	Role   string
+}
+
+// only the user with the invited email can answer
+func (q *Queries) RespondTripInvitation(ctx context.Context, arg RespondTripInvitationParams) (RespondTripInvitationRow, error) {
+	row := q.db.QueryRow(ctx, respondTripInvitation, arg.Status, arg.Token, arg.UserID)
+	var i RespondTripInvitationRow
+	err := row.Scan(&i.TripID, &i.Role)
+	return i, err
+}
+
+const restoreDestination = `-- name: RestoreDestination :execrows
+UPDATE destination
+ SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreDestination(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreDestination, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreTrip = `-- name: RestoreTrip :execrows
+UPDATE trip
+ SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreTrip(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreTrip, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const rollbackDestination = `-- name: RollbackDestination :execrows
+UPDATE destination d
+ SET name = s.name,
+ description = s.description,
+ attraction = s.attraction,
+ latitude = s.latitude,
+ longitude = s.longitude,
+ country = s.country,
+ region = s.region,
+ city = s.city,
+ place_id = (SELECT p.id FROM place p WHERE p.id = s.place_id)
+FROM destination_revision r,
+ jsonb_populate_record(NULL::destination, r.snapshot) s
+WHERE d.id = $1 AND d.deleted_at IS NULL
+ AND r.destination_id = d.id AND r.version = $2
+`
+
+type RollbackDestinationParams struct {
+	DestinationID pgtype.UUID
+	Version       int32
+}
+
+// places deleted since the revision are left unset
+func (q *Queries) RollbackDestination(ctx context.Context, arg RollbackDestinationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rollbackDestination, arg.DestinationID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setCalendarToken = `-- name: SetCalendarToken :exec
+INSERT INTO calendar_feed (user_id, token)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+ SET token = EXCLUDED.token,
+ created_at = now()
+`
+
+type SetCalendarTokenParams struct {
+	UserID pgtype.UUID
+	Token  string
+}
+
+// replaces the previous token, the old feed URL stops working
+func (q *Queries) SetCalendarToken(ctx context.Context, arg SetCalendarTokenParams) error {
+	_, err := q.db.Exec(ctx, setCalendarToken, arg.UserID, arg.Token)
+	return err
+}
+
+const setExchangeRate = `-- name: SetExchangeRate :exec
+INSERT INTO exchange_rate (base, quote, rate)
+VALUES ($1, $2, $3::text::numeric)
+ON CONFLICT (base, quote) DO UPDATE
+SET rate = excluded.rate, updated_at = now()
+`
+
+type SetExchangeRateParams struct {
+	Base  string
+	Quote string
+	Rate  string
+}
+
+func (q *Queries) SetExchangeRate(ctx context.Context, arg SetExchangeRateParams) error {
+	_, err := q.db.Exec(ctx, setExchangeRate, arg.Base, arg.Quote, arg.Rate)
+	return err
+}
+
+const setMediaCover = `-- name: SetMediaCover :execrows
+UPDATE media_file
+ SET is_cover = (id = $1)
+WHERE destination_id = $2
+ AND EXISTS (
+   SELECT 1 FROM media_file
+    WHERE id = $1 AND destination_id = $2
+ )
+`
+
+type SetMediaCoverParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+}
+
+// a single statement so there is never more than one cover,
+// nothing changes if the file isn't part of the destination
+func (q *Queries) SetMediaCover(ctx context.Context, arg SetMediaCoverParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setMediaCover, arg.ID, arg.DestinationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setReviewStatus = `-- name: SetReviewStatus :execrows
+UPDATE review
+ SET status = $3
+WHERE id = $1 AND destination_id = $2
+`
+
+type SetReviewStatusParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	Status        string
+}
+
+func (q *Queries) SetReviewStatus(ctx context.Context, arg SetReviewStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setReviewStatus, arg.ID, arg.DestinationID, arg.Status)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setWishlistShareToken = `-- name: SetWishlistShareToken :execrows
+UPDATE wishlist
+ SET share_token = $3
+WHERE id = $1 AND user_id = $2
+`
+
+type SetWishlistShareTokenParams struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	ShareToken pgtype.Text
+}
+
+// a NULL token stops sharing
+func (q *Queries) SetWishlistShareToken(ctx context.Context, arg SetWishlistShareTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setWishlistShareToken, arg.ID, arg.UserID, arg.ShareToken)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const suggestChecklistTemplates = `-- name: SuggestChecklistTemplates :many
+SELECT t.id, t.user_id, t.name, t.description, t.min_days, t.max_days, t.tag_id,
+ t.created_at, count(i.id) AS item_count
+FROM checklist_template t
+LEFT JOIN checklist_template_item i ON i.template_id = t.id
+WHERE (t.user_id IS NULL OR t.user_id = $1)
+ AND (t.min_days IS NULL OR t.min_days <= $2::integer)
+ AND (t.max_days IS NULL OR t.max_days >= $2::integer)
+ AND (t.tag_id IS NULL OR t.tag_id IN (
+   SELECT dt.tag_id FROM destination_tag dt
+   WHERE dt.destination_id IN (
+     SELECT tr.destination_id FROM trip tr WHERE tr.id = $3
+     UNION
+     SELECT td.destination_id FROM trip_destination td WHERE td.trip_id = $3
+   )
+ ))
+GROUP BY t.id
+ORDER BY (t.tag_id IS NOT NULL) DESC,
+ (t.min_days IS NOT NULL OR t.max_days IS NOT NULL) DESC, t.name, t.id
+`
+
+type SuggestChecklistTemplatesParams struct {
+	UserID pgtype.UUID
+	Days   pgtype.Int4
+	TripID pgtype.UUID
+}
+
+type SuggestChecklistTemplatesRow struct {
+	ID          pgtype.UUID
+	UserID      pgtype.UUID
+	Name        string
+	Description string
+	MinDays     pgtype.Int4
+	MaxDays     pgtype.Int4
+	TagID       pgtype.UUID
+	CreatedAt   pgtype.Timestamptz
+	ItemCount   int64
+}
+
+// templates whose length and tag fit the trip, the most specific first,
+// days is NULL if the trip's dates can't be read
+func (q *Queries) SuggestChecklistTemplates(ctx context.Context, arg SuggestChecklistTemplatesParams) ([]SuggestChecklistTemplatesRow, error) {
+	rows, err := q.db.Query(ctx, suggestChecklistTemplates, arg.UserID, arg.Days, arg.TripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SuggestChecklistTemplatesRow
+	for rows.Next() {
+		var i SuggestChecklistTemplatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.MinDays,
+			&i.MaxDays,
+			&i.TagID,
+			&i.CreatedAt,
+			&i.ItemCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateActivity = `-- name: UpdateActivity :execrows
+UPDATE activity
+ SET title = $3,
+ category = $4,
+ day = $5,
+ start_time = $6,
+ end_time = $7,
+ location = $8,
+ destination_id = $9,
+ attraction_id = $10,
+ notes = $11
+WHERE id = $1 AND trip_id = $2
+`
+
+type UpdateActivityParams struct {
+	ID            pgtype.UUID
+	TripID        pgtype.UUID
+	Title         string
+	Category      string
+	Day           pgtype.Date
+	StartTime     pgtype.Time
+	EndTime       pgtype.Time
+	Location      string
+	DestinationID pgtype.UUID
+	AttractionID  pgtype.UUID
+	Notes         string
+}
+
+func (q *Queries) UpdateActivity(ctx context.Context, arg UpdateActivityParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateActivity,
+		arg.ID,
+		arg.TripID,
+		arg.Title,
+		arg.Category,
+		arg.Day,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Location,
+		arg.DestinationID,
+		arg.AttractionID,
+		arg.Notes,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateAttraction = `-- name: UpdateAttraction :execrows
+UPDATE attraction
+ SET name = $3,
+ category = $4,
+ description = $5,
+ opening_hours = $6,
+ visit_duration_minutes = $7,
+ price_level = $8,
+ latitude = $9,
+ longitude = $10
+WHERE id = $1 AND destination_id = $2
+`
+
+type UpdateAttractionParams struct {
+	ID                   pgtype.UUID
+	DestinationID        pgtype.UUID
+	Name                 string
+	Category             string
+	Description          string
+	OpeningHours         pgtype.Text
+	VisitDurationMinutes pgtype.Int4
+	PriceLevel           pgtype.Int4
+	Latitude             pgtype.Float8
+	Longitude            pgtype.Float8
+}
+
+func (q *Queries) UpdateAttraction(ctx context.Context, arg UpdateAttractionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateAttraction,
+		arg.ID,
+		arg.DestinationID,
+		arg.Name,
+		arg.Category,
+		arg.Description,
+		arg.OpeningHours,
+		arg.VisitDurationMinutes,
+		arg.PriceLevel,
+		arg.Latitude,
+		arg.Longitude,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateBooking = `-- name: UpdateBooking :execrows
+UPDATE booking
+ SET kind = $3,
+ title = $4,
+ provider = $5,
+ confirmation_code = $6,
+ starts_at = $7,
+ start_zone = $8,
+ ends_at = $9,
+ end_zone = $10,
+ address = $11,
+ cost = $12,
+ currency = $13,
+ notes = $14,
+ updated_at = now(),
+ sequence = sequence + 1
+WHERE id = $1 AND trip_id = $2
+`
+
+type UpdateBookingParams struct {
+	ID               pgtype.UUID
+	TripID           pgtype.UUID
+	Kind             string
+	Title            string
+	Provider         string
+	ConfirmationCode string
+	StartsAt         pgtype.Timestamptz
+	StartZone        string
+	EndsAt           pgtype.Timestamptz
+	EndZone          pgtype.Text
+	Address          string
+	Cost             pgtype.Int8
+	Currency         pgtype.Text
+	Notes            string
+}
+
+func (q *Queries) UpdateBooking(ctx context.Context, arg UpdateBookingParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateBooking,
+		arg.ID,
+		arg.TripID,
+		arg.Kind,
+		arg.Title,
+		arg.Provider,
+		arg.ConfirmationCode,
+		arg.StartsAt,
+		arg.StartZone,
+		arg.EndsAt,
+		arg.EndZone,
+		arg.Address,
+		arg.Cost,
+		arg.Currency,
+		arg.Notes,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateChecklistItem = `-- name: UpdateChecklistItem :execrows
+UPDATE checklist_item i
+ SET title = $1,
+ assignee_id = $2,
+ done = $3,
+ done_at = CASE WHEN $3::boolean THEN coalesce(i.done_at, now()) END
+FROM checklist c
+WHERE i.checklist_id = c.id AND i.id = $4 AND c.id = $5 AND c.trip_id = $6
+`
+
+type UpdateChecklistItemParams struct {
+	Title       string
+	AssigneeID  pgtype.UUID
+	Done        bool
+	ID          pgtype.UUID
+	ChecklistID pgtype.UUID
+	TripID      pgtype.UUID
+}
+
+// done_at is kept while the item stays done
+func (q *Queries) UpdateChecklistItem(ctx context.Context, arg UpdateChecklistItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateChecklistItem,
+		arg.Title,
+		arg.AssigneeID,
+		arg.Done,
+		arg.ID,
+		arg.ChecklistID,
+		arg.TripID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateChecklistTemplate = `-- name: UpdateChecklistTemplate :execrows
+UPDATE checklist_template
+ SET name = $1,
+ description = $2,
+ min_days = $3,
+ max_days = $4,
+ tag_id = $5
+WHERE id = $6 AND (user_id = $7 OR ($8::boolean AND user_id IS NULL))
+`
+
+type UpdateChecklistTemplateParams struct {
+	Name        string
+	Description string
+	MinDays     pgtype.Int4
+	MaxDays     pgtype.Int4
+	TagID       pgtype.UUID
+	ID          pgtype.UUID
+	UserID      pgtype.UUID
+	Admin       bool
+}
+
+// users change their own templates, admins the provided ones
+func (q *Queries) UpdateChecklistTemplate(ctx context.Context, arg UpdateChecklistTemplateParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateChecklistTemplate,
+		arg.Name,
+		arg.Description,
+		arg.MinDays,
+		arg.MaxDays,
+		arg.TagID,
+		arg.ID,
+		arg.UserID,
+		arg.Admin,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateDestination = `-- name: UpdateDestination :execrows
+UPDATE destination
+ SET name = $2,
+ description = $3,
+ attraction = $4,
+ latitude = $5,
+ longitude = $6,
+ country = $7,
+ region = $8,
+ city = $9,
+ place_id = $10
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+type UpdateDestinationParams struct {
 	ID          pgtype.UUID
 	Name        string
 	Description string
 	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+	PlaceID     pgtype.UUID
 }
 
-func (q *Queries) CreateDestination(ctx context.Context, arg CreateDestinationParams) error {
-	_, err := q.db.Exec(ctx, createDestination,
+func (q *Queries) UpdateDestination(ctx context.Context, arg UpdateDestinationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateDestination,
 		arg.ID,
 		arg.Name,
 		arg.Description,
 		arg.Attraction,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Country,
+		arg.Region,
+		arg.City,
+		arg.PlaceID,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const createTrip = `-- name: CreateTrip :exec
-INSERT INTO trip (
-  id, name, start_date, end_date, destination_id
-) VALUES (
-  $1, $2, $3, $4, $5
-)
+const updateExpense = `-- name: UpdateExpense :execrows
+UPDATE expense
+SET amount = $3, currency = $4, category = $5, paid_by = $6, spent_on = $7, note = $8
+WHERE id = $1 AND trip_id = $2
 `
 
-type CreateTripParams struct {
-	ID            pgtype.UUID
-	Name          string
-	StartDate     string
-	EndDate       string
-	DestinationID pgtype.UUID
+type UpdateExpenseParams struct {
+	ID       pgtype.UUID
+	TripID   pgtype.UUID
+	Amount   int64
+	Currency string
+	Category string
+	PaidBy   pgtype.UUID
+	SpentOn  pgtype.Date
+	Note     string
 }
 
-func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) error {
-	_, err := q.db.Exec(ctx, createTrip,
+func (q *Queries) UpdateExpense(ctx context.Context, arg UpdateExpenseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateExpense,
 		arg.ID,
-		arg.Name,
-		arg.StartDate,
-		arg.EndDate,
-		arg.DestinationID,
+		arg.TripID,
+		arg.Amount,
+		arg.Currency,
+		arg.Category,
+		arg.PaidBy,
+		arg.SpentOn,
+		arg.Note,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const createUser = `-- name: CreateUser :exec
-INSERT INTO users (
-  id, email, name, password
-) VALUES (
-  $1, $2, $3, $4
-)
+const updateImportJobProgress = `-- name: UpdateImportJobProgress :exec
+UPDATE import_job
+ SET processed_rows = $2,
+ created_rows = $3,
+ updated_rows = $4,
+ failed_rows = $5
+WHERE id = $1
 `
 
-type CreateUserParams struct {
-	ID       pgtype.UUID
-	Email    string
-	Name     string
-	Password string
+type UpdateImportJobProgressParams struct {
+	ID            pgtype.UUID
+	ProcessedRows int32
+	CreatedRows   int32
+	UpdatedRows   int32
+	FailedRows    int32
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
-	_, err := q.db.Exec(ctx, createUser,
+func (q *Queries) UpdateImportJobProgress(ctx context.Context, arg UpdateImportJobProgressParams) error {
+	_, err := q.db.Exec(ctx, updateImportJobProgress,
 		arg.ID,
-		arg.Email,
-		arg.Name,
-		arg.Password,
+		arg.ProcessedRows,
+		arg.CreatedRows,
+		arg.UpdatedRows,
+		arg.FailedRows,
 	)
 	return err
 }
 
-const deleteDestination = `-- name: DeleteDestination :exec
-DELETE FROM destination
- WHERE id = $1
-`
-
-func (q *Queries) DeleteDestination(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteDestination, id)
-	return err
-}
-
-const deleteTrip = `-- name: DeleteTrip :exec
-DELETE FROM trip
- WHERE id = $1
+const updateMediaCaption = `-- name: UpdateMediaCaption :execrows
+UPDATE media_file
+ SET caption = $3
+WHERE id = $1 AND destination_id = $2
 `
 
-func (q *Queries) DeleteTrip(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteTrip, id)
-	return err
+type UpdateMediaCaptionParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	Caption       string
 }
 
-const demoteAdmin = `-- name: DemoteAdmin :exec
-UPDATE users SET admin = false
- WHERE email = $1
-`
-
-func (q *Queries) DemoteAdmin(ctx context.Context, email string) error {
-	_, err := q.db.Exec(ctx, demoteAdmin, email)
-	return err
+func (q *Queries) UpdateMediaCaption(ctx context.Context, arg UpdateMediaCaptionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateMediaCaption, arg.ID, arg.DestinationID, arg.Caption)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const getDestination = `-- name: GetDestination :one
-SELECT name, description, attraction FROM destination
- WHERE id = $1 LIMIT 1
+const updateReview = `-- name: UpdateReview :execrows
+UPDATE review
+ SET rating = $4,
+ title = $5,
+ body = $6,
+ visit_date = $7,
+ updated_at = now()
+WHERE id = $1 AND destination_id = $2 AND user_id = $3
 `
 
-type GetDestinationRow struct {
-	Name        string
-	Description string
-	Attraction  string
+type UpdateReviewParams struct {
+	ID            pgtype.UUID
+	DestinationID pgtype.UUID
+	UserID        pgtype.UUID
+	Rating        int32
+	Title         string
+	Body          string
+	VisitDate     pgtype.Date
 }
 
-func (q *Queries) GetDestination(ctx context.Context, id pgtype.UUID) (GetDestinationRow, error) {
-	row := q.db.QueryRow(ctx, getDestination, id)
-	var i GetDestinationRow
-	err := row.Scan(&i.Name, &i.Description, &i.Attraction)
-	return i, err
+// only the author can edit their review
+func (q *Queries) UpdateReview(ctx context.Context, arg UpdateReviewParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateReview,
+		arg.ID,
+		arg.DestinationID,
+		arg.UserID,
+		arg.Rating,
+		arg.Title,
+		arg.Body,
+		arg.VisitDate,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const getPass = `-- name: GetPass :one
-SELECT id, password, name, admin FROM users
- WHERE email = $1 LIMIT 1
+const updateTag = `-- name: UpdateTag :execrows
+UPDATE tag
+ SET slug = $2, name = $3, category = $4
+WHERE id = $1
 `
 
-type GetPassRow struct {
+type UpdateTagParams struct {
 	ID       pgtype.UUID
-	Password string
+	Slug     string
 	Name     string
-	Admin    bool
+	Category string
 }
 
-func (q *Queries) GetPass(ctx context.Context, email string) (GetPassRow, error) {
-	row := q.db.QueryRow(ctx, getPass, email)
-	var i GetPassRow
-	err := row.Scan(
-		&i.ID,
-		&i.Password,
-		&i.Name,
-		&i.Admin,
+func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateTag,
+		arg.ID,
+		arg.Slug,
+		arg.Name,
+		arg.Category,
 	)
-	return i, err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const getTrip = `-- name: GetTrip :one
-SELECT name, start_date, end_date, destination_id FROM trip
- WHERE id = $1 LIMIT 1
+const updateTrip = `-- name: UpdateTrip :execrows
+UPDATE trip
+ SET name = $2,
+ start_date = $3,
+ end_date = $4,
+ destination_id = $5,
+ updated_at = now(),
+ sequence = sequence + 1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
-type GetTripRow struct {
+type UpdateTripParams struct {
+	ID            pgtype.UUID
 	Name          string
 	StartDate     string
 	EndDate       string
 	DestinationID pgtype.UUID
 }
 
-func (q *Queries) GetTrip(ctx context.Context, id pgtype.UUID) (GetTripRow, error) {
-	row := q.db.QueryRow(ctx, getTrip, id)
-	var i GetTripRow
-	err := row.Scan(
-		&i.Name,
-		&i.StartDate,
-		&i.EndDate,
-		&i.DestinationID,
+func (q *Queries) UpdateTrip(ctx context.Context, arg UpdateTripParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateTrip,
+		arg.ID,
+		arg.Name,
+		arg.StartDate,
+		arg.EndDate,
+		arg.DestinationID,
 	)
-	return i, err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const listDestinations = `-- name: ListDestinations :many
-SELECT id, name, description, attraction FROM destination
+const updateTripBudget = `-- name: UpdateTripBudget :execrows
+UPDATE trip SET budget = $2, currency = $3
+WHERE id = $1 AND deleted_at IS NULL
 `
 
-func (q *Queries) ListDestinations(ctx context.Context) ([]Destination, error) {
-	rows, err := q.db.Query(ctx, listDestinations)
+type UpdateTripBudgetParams struct {
+	ID       pgtype.UUID
+	Budget   pgtype.Int8
+	Currency string
+}
+
+func (q *Queries) UpdateTripBudget(ctx context.Context, arg UpdateTripBudgetParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateTripBudget, arg.ID, arg.Budget, arg.Currency)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Destination
-	for rows.Next() {
-		var i Destination
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.Attraction,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const listTrips = `-- name: ListTrips :many
-SELECT id, name, start_date, end_date, destination_id FROM trip
+const updateTripMemberRole = `-- name: UpdateTripMemberRole :execrows
+UPDATE trip_member
+ SET role = $3
+WHERE trip_id = $1 AND user_id = $2 AND role <> 'owner'
 `
 
-func (q *Queries) ListTrips(ctx context.Context) ([]Trip, error) {
-	rows, err := q.db.Query(ctx, listTrips)
+type UpdateTripMemberRoleParams struct {
+	TripID pgtype.UUID
+	UserID pgtype.UUID
+	Role   string
+}
+
+func (q *Queries) UpdateTripMemberRole(ctx context.Context, arg UpdateTripMemberRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateTripMemberRole, arg.TripID, arg.UserID, arg.Role)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Trip
-	for rows.Next() {
-		var i Trip
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.StartDate,
-			&i.EndDate,
-			&i.DestinationID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const promoteAdmin = `-- name: PromoteAdmin :exec
-UPDATE users SET admin = true
- WHERE email = $1
+const updateUser = `-- name: UpdateUser :exec
+UPDATE users
+ SET email = $2,
+ name = $3
+WHERE email = $1
 `
 
-func (q *Queries) PromoteAdmin(ctx context.Context, email string) error {
-	_, err := q.db.Exec(ctx, promoteAdmin, email)
+type UpdateUserParams struct {
+	Email   string
+	Email_2 string
+	Name    string
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
+	_, err := q.db.Exec(ctx, updateUser, arg.Email, arg.Email_2, arg.Name)
 	return err
 }
 
-const updateDestination = `-- name: UpdateDestination :exec
-UPDATE destination
- SET name = $2,
- description = $3,
- attraction = $4
-WHERE id = $1
+const upsertDestination = `-- name: UpsertDestination :one
+INSERT INTO destination (
+  id, name, description, attraction,
+  latitude, longitude, country, region, city, place_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (lower(name), coalesce(country, ''), coalesce(city, '')) WHERE deleted_at IS NULL
+DO UPDATE
+ SET name = EXCLUDED.name,
+ description = EXCLUDED.description,
+ attraction = EXCLUDED.attraction,
+ latitude = EXCLUDED.latitude,
+ longitude = EXCLUDED.longitude,
+ region = EXCLUDED.region,
+ place_id = EXCLUDED.place_id
+RETURNING id, (xmax = 0)::bool AS inserted
 `
 
-type UpdateDestinationParams struct {
+type UpsertDestinationParams struct {
 	ID          pgtype.UUID
 	Name        string
 	Description string
 	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+	PlaceID     pgtype.UUID
+}
+
+type UpsertDestinationRow struct {
+	ID       pgtype.UUID
+	Inserted bool
 }
 
-func (q *Queries) UpdateDestination(ctx context.Context, arg UpdateDestinationParams) error {
-	_, err := q.db.Exec(ctx, updateDestination,
+// matched on the natural key, name is case insensitive
+func (q *Queries) UpsertDestination(ctx context.Context, arg UpsertDestinationParams) (UpsertDestinationRow, error) {
+	row := q.db.QueryRow(ctx, upsertDestination,
 		arg.ID,
 		arg.Name,
 		arg.Description,
 		arg.Attraction,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Country,
+		arg.Region,
+		arg.City,
+		arg.PlaceID,
 	)
-	return err
+	var i UpsertDestinationRow
+	err := row.Scan(&i.ID, &i.Inserted)
+	return i, err
 }
 
-const updateTrip = `-- name: UpdateTrip :exec
-UPDATE trip
- SET name = $2,
- start_date = $3,
- end_date = $4,
- destination_id = $5
-WHERE id = $1
+const upsertDestinationTranslation = `-- name: UpsertDestinationTranslation :exec
+INSERT INTO destination_translation (
+  destination_id, locale, name, description, attraction
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (destination_id, locale) DO UPDATE
+ SET name = EXCLUDED.name,
+     description = EXCLUDED.description,
+     attraction = EXCLUDED.attraction,
+     updated_at = now()
 `
 
-type UpdateTripParams struct {
-	ID            pgtype.UUID
-	Name          string
-	StartDate     string
-	EndDate       string
+type UpsertDestinationTranslationParams struct {
 	DestinationID pgtype.UUID
+	Locale        string
+	Name          string
+	Description   string
+	Attraction    string
 }
 
-func (q *Queries) UpdateTrip(ctx context.Context, arg UpdateTripParams) error {
-	_, err := q.db.Exec(ctx, updateTrip,
-		arg.ID,
-		arg.Name,
-		arg.StartDate,
-		arg.EndDate,
+func (q *Queries) UpsertDestinationTranslation(ctx context.Context, arg UpsertDestinationTranslationParams) error {
+	_, err := q.db.Exec(ctx, upsertDestinationTranslation,
 		arg.DestinationID,
+		arg.Locale,
+		arg.Name,
+		arg.Description,
+		arg.Attraction,
 	)
 	return err
 }
 
-const updateUser = `-- name: UpdateUser :exec
-UPDATE users
- SET email = $2,
- name = $3
-WHERE email = $1
+const upsertPlace = `-- name: UpsertPlace :one
+INSERT INTO place (
+  id, parent_id, kind, name, country_code, geoname_id, latitude, longitude, admin1_code
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (geoname_id) DO UPDATE
+ SET parent_id = EXCLUDED.parent_id,
+ kind = EXCLUDED.kind,
+ name = EXCLUDED.name,
+ country_code = EXCLUDED.country_code,
+ latitude = EXCLUDED.latitude,
+ longitude = EXCLUDED.longitude,
+ admin1_code = EXCLUDED.admin1_code
+RETURNING id
 `
 
-type UpdateUserParams struct {
-	Email   string
-	Email_2 string
-	Name    string
+type UpsertPlaceParams struct {
+	ID          pgtype.UUID
+	ParentID    pgtype.UUID
+	Kind        string
+	Name        string
+	CountryCode string
+	GeonameID   pgtype.Int8
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Admin1Code  pgtype.Text
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
-	_, err := q.db.Exec(ctx, updateUser, arg.Email, arg.Email_2, arg.Name)
-	return err
+func (q *Queries) UpsertPlace(ctx context.Context, arg UpsertPlaceParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, upsertPlace,
+		arg.ID,
+		arg.ParentID,
+		arg.Kind,
+		arg.Name,
+		arg.CountryCode,
+		arg.GeonameID,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Admin1Code,
+	)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
 }
@@ -0,0 +1,269 @@
+// Package geonames seeds the place hierarchy from a GeoNames style CSV
+//
+// The CSV has a header row and the columns geonameid, name, feature_code,
+// country_code, admin1_code, latitude and longitude. PCL* rows become
+// countries, ADM1 rows regions and PPL* rows cities. Regions are attached
+// to the country with the same country_code and cities to the region with
+// the same country_code and admin1_code, or the country if there is none.
+// Parents are looked up in the file first, then among the places already
+// imported, so a file of cities can be added to existing countries.
+package geonames
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+)
+
+// Bundled is a small sample of countries, regions and cities
+//
+//go:embed places.csv
+var Bundled string
+
+var columns = []string{"geonameid", "name", "feature_code", "country_code", "admin1_code", "latitude", "longitude"}
+
+type row struct {
+	line        int
+	kind        string
+	geonameID   int64
+	name        string
+	countryCode string
+	admin1Code  string
+	latitude    pgtype.Float8
+	longitude   pgtype.Float8
+}
+
+// kinds in the order they have to be imported, parents first
+var kindOrder = map[string]int{"country": 0, "region": 1, "city": 2}
+
+// kind maps a GeoNames feature code to a place kind
+func kind(featureCode string) string {
+	switch {
+	case strings.HasPrefix(featureCode, "PCL"):
+		return "country"
+	case featureCode == "ADM1":
+		return "region"
+	case strings.HasPrefix(featureCode, "PPL"):
+		return "city"
+	}
+	return ""
+}
+
+// Beginner starts the transaction an import runs in, such as a
+// *pgxpool.Pool
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Import upserts every place in r and returns how many were imported
+// rows with other feature codes are skipped, importing is idempotent
+// since places are matched by geonameid. Either every place is imported
+// or none is
+func Import(ctx context.Context, conn Beginner, r io.Reader) (int, error) {
+	rows, err := read(r)
+	if err != nil {
+		return 0, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return kindOrder[rows[i].kind] < kindOrder[rows[j].kind]
+	})
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	p := parents{
+		q:         db.New(tx),
+		countries: make(map[string]pgtype.UUID),
+		regions:   make(map[string]pgtype.UUID),
+	}
+
+	for _, row := range rows {
+		var parent pgtype.UUID
+
+		switch row.kind {
+		case "region":
+			parent, err = p.country(ctx, row.countryCode)
+		case "city":
+			parent, err = p.region(ctx, row.countryCode, row.admin1Code)
+			if err == nil && !parent.Valid {
+				parent, err = p.country(ctx, row.countryCode)
+			}
+		}
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", row.line, err)
+		}
+
+		if row.kind != "country" && !parent.Valid {
+			return 0, fmt.Errorf("line %d: no country %q for %s %q", row.line, row.countryCode, row.kind, row.name)
+		}
+
+		id, err := p.q.UpsertPlace(ctx, db.UpsertPlaceParams{
+			ID:          pgtype.UUID{Bytes: uuid.New(), Valid: true},
+			ParentID:    parent,
+			Kind:        row.kind,
+			Name:        row.name,
+			CountryCode: row.countryCode,
+			GeonameID:   pgtype.Int8{Int64: row.geonameID, Valid: true},
+			Latitude:    row.latitude,
+			Longitude:   row.longitude,
+			Admin1Code:  pgtype.Text{String: row.admin1Code, Valid: row.admin1Code != ""},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", row.line, err)
+		}
+
+		switch row.kind {
+		case "country":
+			p.countries[row.countryCode] = id
+		case "region":
+			p.regions[row.countryCode+"."+row.admin1Code] = id
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(rows), nil
+}
+
+// parents finds the country and region places are attached to, in the
+// rows imported so far or else in the database
+type parents struct {
+	q         *db.Queries
+	countries map[string]pgtype.UUID
+	regions   map[string]pgtype.UUID
+}
+
+// country returns the country with the code, invalid if there is none
+func (p parents) country(ctx context.Context, code string) (pgtype.UUID, error) {
+	if id, ok := p.countries[code]; ok {
+		return id, nil
+	}
+
+	id, err := p.q.FindCountryPlace(ctx, code)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return id, err
+	}
+
+	p.countries[code] = id
+	return id, nil
+}
+
+// region returns the region of a country with the admin1 code, invalid
+// if there is none
+func (p parents) region(ctx context.Context, countryCode, admin1Code string) (pgtype.UUID, error) {
+	key := countryCode + "." + admin1Code
+	if id, ok := p.regions[key]; ok {
+		return id, nil
+	}
+
+	var id pgtype.UUID
+	if admin1Code == "" {
+		return id, nil
+	}
+
+	id, err := p.q.FindRegionPlace(ctx, db.FindRegionPlaceParams{
+		CountryCode: countryCode,
+		Admin1Code:  pgtype.Text{String: admin1Code, Valid: true},
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return id, err
+	}
+
+	p.regions[key] = id
+	return id, nil
+}
+
+// read parses and validates the CSV, keeping only known feature codes
+func read(r io.Reader) ([]row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(columns)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	for i, column := range columns {
+		if header[i] != column {
+			return nil, fmt.Errorf("header: expected column %d to be %q, got %q", i+1, column, header[i])
+		}
+	}
+
+	var rows []row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := row{
+			line:        line,
+			kind:        kind(record[2]),
+			name:        strings.TrimSpace(record[1]),
+			countryCode: strings.ToUpper(record[3]),
+			admin1Code:  record[4],
+		}
+		if row.kind == "" {
+			continue
+		}
+
+		row.geonameID, err = strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid geonameid %q", line, record[0])
+		}
+		if row.name == "" {
+			return nil, fmt.Errorf("line %d: empty name", line)
+		}
+		if len(row.countryCode) != 2 {
+			return nil, fmt.Errorf("line %d: invalid country code %q", line, record[3])
+		}
+		if row.latitude, err = coordinate(record[5], 90); err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude %q", line, record[5])
+		}
+		if row.longitude, err = coordinate(record[6], 180); err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude %q", line, record[6])
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// coordinate parses an optional coordinate within -limit and limit
+func coordinate(s string, limit float64) (pgtype.Float8, error) {
+	if s == "" {
+		return pgtype.Float8{}, nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return pgtype.Float8{}, err
+	}
+	if v < -limit || v > limit {
+		return pgtype.Float8{}, fmt.Errorf("out of range")
+	}
+
+	return pgtype.Float8{Float64: v, Valid: true}, nil
+}
@@ -3,6 +3,7 @@ module github.com/Trisamudrisvara/goTrip
 go 1.23.0
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/bytedance/sonic v1.12.2
 	github.com/goccy/go-json v0.10.3
 	github.com/gofiber/contrib/jwt v1.0.10
@@ -14,6 +15,9 @@ require (
 	github.com/jackc/pgx/v5 v5.6.0
 	github.com/joho/godotenv v1.5.1
 	golang.org/x/crypto v0.19.0
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.16.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -57,7 +61,5 @@ require (
 	golang.org/x/arch v0.0.0-20210923205945-b76863e36670 // indirect
 	golang.org/x/sync v0.7.0 // indirect
 	golang.org/x/sys v0.19.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,321 @@
+// Package ical writes and reads the subset of RFC 5545 iCalendar needed
+// for trips, all day VEVENTs with a summary, description and location,
+// and timed ones for bookings
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	prodID = "-//goTrip//Trips//EN"
+	// content lines longer than this are folded, in octets
+	lineLimit = 75
+	// layout of DATE values
+	dateLayout = "20060102"
+	// layout of UTC DATE-TIME values
+	dateTimeLayout = "20060102T150405Z"
+)
+
+var (
+	ErrNoCalendar = errors.New("ical: no VCALENDAR found")
+	ErrNoStart    = errors.New("ical: event has no valid DTSTART")
+)
+
+// Event is an all day event spanning one or more days, or a timed one
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	// dates only, End is the last day of the event, not the day after
+	Start time.Time
+	End   time.Time
+	// Start and End are instants instead, written in UTC. End is when the
+	// event ends, the same as Start if it's a point in time
+	Timed bool
+	// incremented each time the event changes, calendar apps use it
+	// along with Modified to pick up updates
+	Sequence int
+	Modified time.Time
+}
+
+// Calendar is a named list of events
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// unescape reverses escape
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// writeLine writes a content line, folded so no line is longer than
+// 75 octets without splitting a character
+func writeLine(w *bufio.Writer, line string) {
+	limit := lineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of continuation lines counts
+		limit = lineLimit - 1
+	}
+
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// Encode writes the calendar, stamped with the current time
+func (cal Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(dateTimeLayout)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+prodID)
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	if cal.Name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escape(cal.Name))
+	}
+
+	for _, event := range cal.Events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+escape(event.UID))
+		writeLine(bw, "DTSTAMP:"+stamp)
+		if event.Timed {
+			end := event.End
+			if end.Before(event.Start) {
+				end = event.Start
+			}
+			writeLine(bw, "DTSTART:"+event.Start.UTC().Format(dateTimeLayout))
+			writeLine(bw, "DTEND:"+end.UTC().Format(dateTimeLayout))
+		} else {
+			writeLine(bw, "DTSTART;VALUE=DATE:"+event.Start.Format(dateLayout))
+			// DTEND is exclusive
+			writeLine(bw, "DTEND;VALUE=DATE:"+event.End.AddDate(0, 0, 1).Format(dateLayout))
+		}
+		writeLine(bw, "SUMMARY:"+escape(event.Summary))
+		if event.Location != "" {
+			writeLine(bw, "LOCATION:"+escape(event.Location))
+		}
+		if event.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escape(event.Description))
+		}
+		writeLine(bw, "SEQUENCE:"+strconv.Itoa(event.Sequence))
+		if !event.Modified.IsZero() {
+			writeLine(bw, "LAST-MODIFIED:"+event.Modified.UTC().Format(dateTimeLayout))
+		}
+		// all day trips don't block the calendar, bookings do
+		if !event.Timed {
+			writeLine(bw, "TRANSP:TRANSPARENT")
+		}
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// contentLine is a parsed "NAME;PARAM=VALUE:value" line
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseLine(line string) (contentLine, bool) {
+	// the value starts at the first colon outside of a quoted param
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return contentLine{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	cl := contentLine{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string, len(parts)-1),
+		value:  line[colon+1:],
+	}
+
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		cl.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return cl, true
+}
+
+// parseDate reads the date of a DATE or DATE-TIME value, the time of
+// day and zone are dropped, allDay tells which of the two it was
+func parseDate(cl contentLine) (date time.Time, allDay bool, err error) {
+	value := cl.value
+	if len(value) < len(dateLayout) {
+		return date, false, ErrNoStart
+	}
+
+	date, err = time.Parse(dateLayout, value[:len(dateLayout)])
+	if err != nil {
+		return date, false, ErrNoStart
+	}
+
+	allDay = cl.params["VALUE"] == "DATE" || len(value) == len(dateLayout)
+	return date, allDay, nil
+}
+
+// Decode reads the events of every VCALENDAR in r, recurrence rules
+// are ignored so only the first occurrence of repeating events is kept
+func Decode(r io.Reader) ([]Event, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	// unfold the content lines first
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		events   []Event
+		event    *Event
+		calendar bool
+		// nested components such as VALARM
+		depth  int
+		hasEnd bool
+	)
+
+	for n, line := range lines {
+		cl, ok := parseLine(line)
+		if !ok {
+			return nil, fmt.Errorf("ical: line %d: invalid content line", n+1)
+		}
+
+		switch {
+		case cl.name == "BEGIN" && strings.EqualFold(cl.value, "VCALENDAR"):
+			calendar = true
+			continue
+		case cl.name == "BEGIN" && strings.EqualFold(cl.value, "VEVENT") && event == nil:
+			event = &Event{}
+			hasEnd = false
+			continue
+		case cl.name == "BEGIN":
+			depth++
+			continue
+		case cl.name == "END" && depth > 0:
+			depth--
+			continue
+		case cl.name == "END" && strings.EqualFold(cl.value, "VEVENT") && event != nil:
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: %w", n+1, ErrNoStart)
+			}
+
+			if !hasEnd || event.End.Before(event.Start) {
+				event.End = event.Start
+			}
+
+			events = append(events, *event)
+			event = nil
+			continue
+		}
+
+		if event == nil || depth > 0 {
+			continue
+		}
+
+		switch cl.name {
+		case "UID":
+			event.UID = unescape(cl.value)
+		case "SUMMARY":
+			event.Summary = unescape(cl.value)
+		case "DESCRIPTION":
+			event.Description = unescape(cl.value)
+		case "LOCATION":
+			event.Location = unescape(cl.value)
+		case "SEQUENCE":
+			event.Sequence, _ = strconv.Atoi(cl.value)
+		case "DTSTART":
+			start, _, err := parseDate(cl)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			event.Start = start
+		case "DTEND":
+			end, allDay, err := parseDate(cl)
+			if err != nil {
+				return nil, fmt.Errorf("ical: line %d: invalid DTEND", n+1)
+			}
+			event.End, hasEnd = end, true
+			// a DATE end is the day after the event
+			if allDay {
+				event.End = end.AddDate(0, 0, -1)
+			}
+		}
+	}
+
+	if !calendar {
+		return nil, ErrNoCalendar
+	}
+
+	return events, nil
+}
@@ -0,0 +1,253 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// calendar wraps content lines in a VCALENDAR with CRLF line endings
+func calendar(lines ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCALENDAR\r\n"
+}
+
+func decode(t *testing.T, s string) []Event {
+	t.Helper()
+
+	events, err := Decode(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestRoundTrip(t *testing.T) {
+	cal := Calendar{
+		Name: "Trips, 2024",
+		Events: []Event{
+			{
+				UID:         "trip-1@gotrip",
+				Summary:     "Lisbon; Porto, and back",
+				Description: "Flights booked\nHotel: C:\\bookings\\lisbon",
+				Location:    "Lisbon, Portugal",
+				Start:       date(2024, 3, 1),
+				End:         date(2024, 3, 5),
+				Sequence:    3,
+			},
+			{
+				UID:     "trip-2@gotrip",
+				Summary: "Day trip",
+				Start:   date(2024, 12, 31),
+				End:     date(2024, 12, 31),
+			},
+			{
+				UID:     "trip-3@gotrip",
+				Summary: "Über den Wolken, 東京から大阪へ, " + strings.Repeat("très long ", 20),
+				Start:   date(2024, 2, 28),
+				End:     date(2024, 3, 1),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got := decode(t, buf.String())
+	if len(got) != len(cal.Events) {
+		t.Fatalf("got %d events, want %d", len(got), len(cal.Events))
+	}
+
+	for i, want := range cal.Events {
+		if got[i] != want {
+			t.Errorf("event %d:\ngot  %+v\nwant %+v", i, got[i], want)
+		}
+	}
+}
+
+// TestRoundTripTimed checks timed events, which decode to their UTC dates
+func TestRoundTripTimed(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	cal := Calendar{Events: []Event{{
+		UID:     "booking-1@gotrip",
+		Summary: "Flight TP 1234",
+		// 00:30 in Paris is still the 1st in UTC
+		Start: time.Date(2024, 3, 2, 0, 30, 0, 0, paris),
+		End:   time.Date(2024, 3, 2, 3, 0, 0, 0, paris),
+		Timed: true,
+	}}}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, line := range []string{"DTSTART:20240301T233000Z\r\n", "DTEND:20240302T020000Z\r\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("%q not found in\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "TRANSP:TRANSPARENT") {
+		t.Error("timed events must block the calendar")
+	}
+
+	got := decode(t, out)
+	if len(got) != 1 || !got[0].Start.Equal(date(2024, 3, 1)) || !got[0].End.Equal(date(2024, 3, 2)) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestEncodeFolding(t *testing.T) {
+	cal := Calendar{Events: []Event{{
+		UID: "fold@gotrip",
+		// two, three and four byte characters all around the fold
+		Summary: strings.Repeat("é東😀a", 40),
+		Start:   date(2024, 1, 1),
+		End:     date(2024, 1, 1),
+	}}}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	folded := 0
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > lineLimit {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("character split across lines: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			folded++
+		}
+	}
+	if folded == 0 {
+		t.Error("the summary wasn't folded")
+	}
+
+	if got := decode(t, buf.String()); got[0].Summary != cal.Events[0].Summary {
+		t.Errorf("got summary %q", got[0].Summary)
+	}
+}
+
+func TestDecodeFolded(t *testing.T) {
+	// folded with a space and a tab, in the middle of a UTF-8 character
+	summary := "SUMMARY:Caf\xc3"
+	events := decode(t, calendar(
+		"BEGIN:VEVENT",
+		"UID:1",
+		summary,
+		" \xa9 de Fl",
+		"\tore",
+		"DTSTART;VALUE=DATE:20240301",
+		"END:VEVENT",
+	))
+
+	if len(events) != 1 || events[0].Summary != "Café de Flore" {
+		t.Errorf("got %+v", events)
+	}
+}
+
+func TestDecodeNested(t *testing.T) {
+	events := decode(t, calendar(
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Paris",
+		"BEGIN:STANDARD",
+		"DTSTART:19701025T030000",
+		"TZOFFSETFROM:+0200",
+		"TZOFFSETTO:+0100",
+		"END:STANDARD",
+		"BEGIN:DAYLIGHT",
+		"DTSTART:19700329T020000",
+		"TZOFFSETFROM:+0100",
+		"TZOFFSETTO:+0200",
+		"END:DAYLIGHT",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:1",
+		"SUMMARY:Museum",
+		"DTSTART;TZID=Europe/Paris:20240301T100000",
+		"DTEND;TZID=Europe/Paris:20240301T120000",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"DESCRIPTION:Reminder",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"LOCATION:Louvre",
+		"END:VEVENT",
+	))
+
+	want := Event{
+		UID:      "1",
+		Summary:  "Museum",
+		Location: "Louvre",
+		Start:    date(2024, 3, 1),
+		End:      date(2024, 3, 1),
+	}
+	if len(events) != 1 || events[0] != want {
+		t.Errorf("got %+v, want %+v", events, want)
+	}
+}
+
+func TestDecodeEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  time.Time
+	}{
+		{"DATE is exclusive", []string{"DTSTART;VALUE=DATE:20240301", "DTEND;VALUE=DATE:20240304"}, date(2024, 3, 3)},
+		{"DATE without VALUE", []string{"DTSTART:20240301", "DTEND:20240304"}, date(2024, 3, 3)},
+		{"one day", []string{"DTSTART;VALUE=DATE:20240301", "DTEND;VALUE=DATE:20240302"}, date(2024, 3, 1)},
+		{"DATE-TIME is inclusive", []string{"DTSTART:20240301T100000Z", "DTEND:20240304T090000Z"}, date(2024, 3, 4)},
+		{"DATE-TIME ending at midnight", []string{"DTSTART:20240301T100000Z", "DTEND:20240304T000000Z"}, date(2024, 3, 4)},
+		{"floating DATE-TIME", []string{"DTSTART:20240301T100000", "DTEND:20240302T100000"}, date(2024, 3, 2)},
+		{"no DTEND", []string{"DTSTART;VALUE=DATE:20240301"}, date(2024, 3, 1)},
+		{"DTEND before DTSTART", []string{"DTSTART:20240305", "DTEND:20240301"}, date(2024, 3, 5)},
+	}
+
+	for _, tt := range tests {
+		lines := append([]string{"BEGIN:VEVENT", "UID:1"}, tt.lines...)
+		events := decode(t, calendar(append(lines, "END:VEVENT")...))
+
+		if len(events) != 1 || !events[0].End.Equal(tt.want) {
+			t.Errorf("%s: got %+v, want end %s", tt.name, events, tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ics  string
+		want error
+	}{
+		{"no calendar", "BEGIN:VCARD\r\nFN:Jane\r\nEND:VCARD\r\n", ErrNoCalendar},
+		{"no start", calendar("BEGIN:VEVENT", "UID:1", "END:VEVENT"), ErrNoStart},
+		{"bad start", calendar("BEGIN:VEVENT", "DTSTART:2024-03-01", "END:VEVENT"), ErrNoStart},
+	}
+
+	for _, tt := range tests {
+		if _, err := Decode(strings.NewReader(tt.ics)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := Decode(strings.NewReader(calendar("no colon"))); err == nil {
+		t.Error("got no error for an invalid content line")
+	}
+}
@@ -0,0 +1,136 @@
+// Package ledger splits shared payments between participants and works
+// out who has to pay whom to settle up, amounts are in minor units
+package ledger
+
+import (
+	"cmp"
+	"errors"
+	"math/big"
+	"slices"
+)
+
+var (
+	ErrNoWeights = errors.New("ledger: nothing to split between")
+	ErrWeight    = errors.New("ledger: weights must be positive")
+	ErrAmount    = errors.New("ledger: amount can't be negative")
+)
+
+// Split divides amount in proportion to weights. The minor units left
+// over by rounding down go to the largest remainders, the earlier weight
+// on ties, so the parts always add up to amount
+func Split(amount int64, weights []int64) ([]int64, error) {
+	if amount < 0 {
+		return nil, ErrAmount
+	}
+
+	if len(weights) == 0 {
+		return nil, ErrNoWeights
+	}
+
+	total := new(big.Int)
+	for _, w := range weights {
+		if w <= 0 {
+			return nil, ErrWeight
+		}
+		total.Add(total, big.NewInt(w))
+	}
+
+	parts := make([]int64, len(weights))
+	remainders := make([]*big.Int, len(weights))
+	left := amount
+	for i, w := range weights {
+		q, m := new(big.Int).QuoRem(
+			new(big.Int).Mul(big.NewInt(amount), big.NewInt(w)), total, new(big.Int))
+		parts[i] = q.Int64()
+		remainders[i] = m
+		left -= parts[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return remainders[b].Cmp(remainders[a])
+	})
+
+	// fewer than len(weights) units are left
+	for _, i := range order[:left] {
+		parts[i]++
+	}
+
+	return parts, nil
+}
+
+// Transfer is a payment settling part of a debt
+type Transfer[K cmp.Ordered] struct {
+	From   K
+	To     K
+	Amount int64
+}
+
+type party[K cmp.Ordered] struct {
+	key    K
+	amount int64
+}
+
+// Settle returns the transfers that bring every balance to zero, a
+// positive balance is money the participant is owed and a negative one
+// money they owe. Debts matching a credit exactly are paid in one
+// transfer first, then the largest debtor pays the largest creditor until
+// everyone is even, which takes at most one transfer less than there are
+// participants. If the balances don't add up to zero, as happens after
+// rounding conversions, what is left over is ignored
+func Settle[K cmp.Ordered](balances map[K]int64) []Transfer[K] {
+	var debtors, creditors []party[K]
+	for key, balance := range balances {
+		switch {
+		case balance < 0:
+			debtors = append(debtors, party[K]{key, -balance})
+		case balance > 0:
+			creditors = append(creditors, party[K]{key, balance})
+		}
+	}
+
+	// largest first, by key on ties so plans don't change between calls
+	largest := func(a, b party[K]) int {
+		if c := cmp.Compare(b.amount, a.amount); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.key, b.key)
+	}
+	slices.SortFunc(debtors, largest)
+	slices.SortFunc(creditors, largest)
+
+	transfers := []Transfer[K]{}
+
+	for i := range debtors {
+		for j := range creditors {
+			if creditors[j].amount != 0 && creditors[j].amount == debtors[i].amount {
+				transfers = append(transfers, Transfer[K]{debtors[i].key, creditors[j].key, debtors[i].amount})
+				debtors[i].amount, creditors[j].amount = 0, 0
+				break
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for {
+		for i < len(debtors) && debtors[i].amount == 0 {
+			i++
+		}
+		for j < len(creditors) && creditors[j].amount == 0 {
+			j++
+		}
+		if i == len(debtors) || j == len(creditors) {
+			break
+		}
+
+		amount := min(debtors[i].amount, creditors[j].amount)
+		transfers = append(transfers, Transfer[K]{debtors[i].key, creditors[j].key, amount})
+		debtors[i].amount -= amount
+		creditors[j].amount -= amount
+	}
+
+	return transfers
+}
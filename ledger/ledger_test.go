@@ -0,0 +1,170 @@
+package ledger
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{1000, []int64{1, 1}, []int64{500, 500}},
+		{0, []int64{1, 2, 3}, []int64{0, 0, 0}},
+		{100, []int64{1}, []int64{100}},
+		// 33.33 each, the earlier weight gets the leftover unit on ties
+		{100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{200, []int64{1, 1, 1}, []int64{67, 67, 66}},
+		{2, []int64{1, 1, 1, 1}, []int64{1, 1, 0, 0}},
+		// 28.57, 57.14 and 14.28, the largest remainders go first
+		{100, []int64{2, 4, 1}, []int64{29, 57, 14}},
+		// 33.3 and 66.6, the second has the larger remainder
+		{100, []int64{1, 2}, []int64{33, 67}},
+		{1001, []int64{3, 1}, []int64{751, 250}},
+		// no overflow on amount * weight
+		{math.MaxInt64, []int64{math.MaxInt64, 1}, []int64{math.MaxInt64 - 1, 1}},
+	}
+
+	for _, tt := range tests {
+		got, err := Split(tt.amount, tt.weights)
+		if err != nil {
+			t.Errorf("Split(%d, %v): %v", tt.amount, tt.weights, err)
+			continue
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Split(%d, %v) = %v, want %v", tt.amount, tt.weights, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSum(t *testing.T) {
+	weights := [][]int64{{1}, {1, 1, 1}, {7, 3}, {5, 5, 1, 9, 2}, {1, 1, 1, 1, 1, 1, 1}}
+
+	for amount := int64(0); amount <= 250; amount++ {
+		for _, w := range weights {
+			parts, err := Split(amount, w)
+			if err != nil {
+				t.Fatalf("Split(%d, %v): %v", amount, w, err)
+			}
+
+			var sum int64
+			for _, p := range parts {
+				sum += p
+			}
+			if sum != amount {
+				t.Errorf("Split(%d, %v) = %v, adds up to %d", amount, w, parts, sum)
+			}
+		}
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	tests := []struct {
+		amount  int64
+		weights []int64
+		want    error
+	}{
+		{-1, []int64{1, 1}, ErrAmount},
+		{100, nil, ErrNoWeights},
+		{100, []int64{1, 0}, ErrWeight},
+		{100, []int64{-1, 2}, ErrWeight},
+	}
+
+	for _, tt := range tests {
+		if _, err := Split(tt.amount, tt.weights); err != tt.want {
+			t.Errorf("Split(%d, %v): got %v, want %v", tt.amount, tt.weights, err, tt.want)
+		}
+	}
+}
+
+func TestSettle(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances map[string]int64
+		want     []Transfer[string]
+	}{
+		{"even", map[string]int64{"a": 0, "b": 0}, []Transfer[string]{}},
+		{"one debt", map[string]int64{"a": 50, "b": -50}, []Transfer[string]{{"b", "a", 50}}},
+		{
+			"exact matches first",
+			map[string]int64{"a": 70, "b": 30, "c": -30, "d": -70},
+			[]Transfer[string]{{"d", "a", 70}, {"c", "b", 30}},
+		},
+		{
+			// without matching, d would pay 60 to a and 10 to b
+			"exact match of a smaller credit",
+			map[string]int64{"a": 60, "b": 40, "c": -30, "d": -70},
+			[]Transfer[string]{{"d", "a", 60}, {"d", "b", 10}, {"c", "b", 30}},
+		},
+		{
+			"largest debtor pays largest creditor",
+			map[string]int64{"a": 100, "b": -20, "c": -30, "d": -50},
+			[]Transfer[string]{{"d", "a", 50}, {"c", "a", 30}, {"b", "a", 20}},
+		},
+		{
+			"ties by key",
+			map[string]int64{"b": 20, "a": 20, "c": -40},
+			[]Transfer[string]{{"c", "a", 20}, {"c", "b", 20}},
+		},
+		{
+			"leftover ignored",
+			map[string]int64{"a": 51, "b": -50},
+			[]Transfer[string]{{"b", "a", 50}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Settle(tt.balances); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSettleTransfers checks that settling evens out every balance in at
+// most one transfer less than there are participants
+func TestSettleTransfers(t *testing.T) {
+	for n := 2; n <= 8; n++ {
+		for seed := int64(1); seed <= 50; seed++ {
+			balances := map[string]int64{}
+			var sum int64
+			for i := 0; i < n-1; i++ {
+				b := (seed*int64(i+7)*7919)%2001 - 1000
+				balances[fmt.Sprint(i)] = b
+				sum += b
+			}
+			balances[fmt.Sprint(n-1)] = -sum
+
+			transfers := Settle(balances)
+
+			participants := 0
+			for _, b := range balances {
+				if b != 0 {
+					participants++
+				}
+			}
+			if participants > 0 && len(transfers) > participants-1 {
+				t.Errorf("%v: %d transfers for %d participants", balances, len(transfers), participants)
+			}
+
+			left := maps.Clone(balances)
+			for _, tr := range transfers {
+				if tr.Amount <= 0 {
+					t.Errorf("%v: transfer %v isn't positive", balances, tr)
+				}
+				left[tr.From] += tr.Amount
+				left[tr.To] -= tr.Amount
+			}
+			for key, b := range left {
+				if b != 0 {
+					t.Errorf("%v: %s is left at %d", balances, key, b)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New creates a JSON logger writing to w at the given level
+// every attribute passes through redact before being written
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}))
+}
+
+// ParseLevel converts a level name such as "debug" or "warn" into a slog.Level
+// an empty name defaults to info
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+
+	if strings.TrimSpace(name) == "" {
+		return slog.LevelInfo, nil
+	}
+
+	err := level.UnmarshalText([]byte(strings.TrimSpace(name)))
+	return level, err
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDKey is the locals key holding the request ID
+	RequestIDKey = "requestid"
+	loggerKey    = "logger"
+)
+
+// incoming request IDs are only accepted if they look sane
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Middleware accepts or generates an X-Request-ID, echoes it in the response,
+// stores a request scoped logger in locals and writes an access log entry
+// for every request
+func Middleware(base *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		// use the client supplied request id if valid, otherwise generate one
+		rid := c.Get(fiber.HeaderXRequestID)
+		if !requestIDPattern.MatchString(rid) {
+			rid = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, rid)
+		c.Locals(RequestIDKey, rid)
+
+		c.Locals(loggerKey, base.With(
+			"request_id", rid,
+			"method", c.Method(),
+			"path", c.Path(),
+		))
+
+		// handle errors here so that the status is known when logging
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		addRequestID(c, rid)
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= fiber.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+
+		FromCtx(c).Log(c.UserContext(), level, "request",
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"ip", c.IP(),
+			"bytes", len(c.Response().Body()),
+			"user_agent", c.Get(fiber.HeaderUserAgent),
+		)
+
+		return nil
+	}
+}
+
+// FromCtx returns the request scoped logger enriched with the matched route
+// and the authenticated user, falls back to the default logger
+func FromCtx(c *fiber.Ctx) *slog.Logger {
+	logger, ok := c.Locals(loggerKey).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+
+	logger = logger.With("route", c.Route().Path)
+
+	if token, ok := c.Locals("user").(*jwt.Token); ok {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if id, ok := claims["id"].(string); ok {
+				logger = logger.With("user", id)
+			}
+		}
+	}
+
+	return logger
+}
+
+// ErrorHandler replaces fiber's default error handler
+// so that errors are logged and returned as JSON
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	msg := "some unknown error occured"
+
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		code = e.Code
+		msg = e.Message
+	}
+
+	if code >= fiber.StatusInternalServerError {
+		FromCtx(c).Error("unhandled error", "err", err)
+	}
+
+	return c.Status(code).JSON(&fiber.Map{"error": msg})
+}
+
+// addRequestID injects the request ID into JSON error bodies
+func addRequestID(c *fiber.Ctx, rid string) {
+	res := c.Response()
+	if res.StatusCode() < fiber.StatusBadRequest ||
+		!strings.HasPrefix(string(res.Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return
+	}
+
+	body := map[string]interface{}{}
+	if err := c.App().Config().JSONDecoder(res.Body(), &body); err != nil {
+		return
+	}
+
+	if _, ok := body["request_id"]; ok {
+		return
+	}
+	body["request_id"] = rid
+
+	data, err := c.App().Config().JSONEncoder(body)
+	if err != nil {
+		return
+	}
+
+	res.SetBodyRaw(data)
+}
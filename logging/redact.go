@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// keys whose values must never reach the logs
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"pass":          true,
+	"secret":        true,
+	"jwt":           true,
+	"token":         true,
+	"csrf":          true,
+	"x-csrf-token":  true,
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// matches JWTs embedded in free-form strings such as error messages
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// redact is used as slog ReplaceAttr hook, it hides sensitive attributes
+// and scrubs tokens that leaked into string values
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); jwtPattern.MatchString(s) {
+			return slog.String(a.Key, jwtPattern.ReplaceAllString(s, redacted))
+		}
+	case slog.KindAny:
+		// errors are rendered through Error(), which may contain tokens
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, jwtPattern.ReplaceAllString(err.Error(), redacted))
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,162 @@
+// Package mailer sends the emails goTrip needs, such as trip invitations
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Trisamudrisvara/goTrip/config"
+)
+
+// how long sending an email may take when the context has no deadline
+const timeout = 30 * time.Second
+
+// Mailer sends plain text emails
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// New returns the mailer selected by the configuration, emails are only
+// logged when SMTP_HOST isn't set
+func New(cfg config.Mail) Mailer {
+	if cfg.Host == "" {
+		return Log{}
+	}
+
+	// the envelope takes the bare address, MAIL_FROM can have a name
+	sender := cfg.From
+	if address, err := mail.ParseAddress(cfg.From); err == nil {
+		sender = address.Address
+	}
+
+	return &SMTP{
+		addr:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:   cfg.Host,
+		user:   cfg.User,
+		pass:   cfg.Pass,
+		from:   cfg.From,
+		sender: sender,
+	}
+}
+
+// SMTP sends emails through an SMTP server, with STARTTLS when the server
+// offers it
+type SMTP struct {
+	addr string
+	host string
+	user string
+	pass string
+	// From header and envelope address
+	from   string
+	sender string
+}
+
+// Send sends an email, to must be a bare address. It gives up when ctx
+// is done, or after timeout if ctx has no deadline
+func (s *SMTP) Send(ctx context.Context, to, subject, body string) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+
+	dialer := net.Dialer{Timeout: time.Until(deadline)}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+
+	// net/smtp has no context support, the deadline and closing the
+	// connection once ctx is done bound every read and write instead
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("mailer: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := s.send(conn, to, message(s.from, to, subject, body)); err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	return nil
+}
+
+// send runs the SMTP session of smtp.SendMail over conn
+func (s *SMTP) send(conn net.Conn, to string, msg []byte) error {
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+
+	if s.user != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.user, s.pass, s.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.sender); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// Log writes emails to the log instead of sending them, for development
+type Log struct{}
+
+// Send logs an email
+func (Log) Send(ctx context.Context, to, subject, body string) error {
+	slog.Info("email not sent, SMTP_HOST isn't set", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+// message builds an RFC 5322 message, line breaks in the headers are
+// dropped so they can't inject more
+func message(from, to, subject, body string) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", header.Replace(to))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", header.Replace(subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
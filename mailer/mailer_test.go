@@ -0,0 +1,144 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Trisamudrisvara/goTrip/config"
+)
+
+// fakeSMTP accepts one connection and answers it with handle
+func fakeSMTP(t *testing.T, handle func(conn net.Conn)) *SMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	mailer := New(config.Mail{Host: host, From: "goTrip <noreply@example.com>"}).(*SMTP)
+	mailer.addr = net.JoinHostPort(host, port)
+	return mailer
+}
+
+func TestSMTPSend(t *testing.T) {
+	received := make(chan string, 1)
+
+	mailer := fakeSMTP(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		var session strings.Builder
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			session.WriteString(line)
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					session.WriteString(line)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- session.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	})
+
+	err := mailer.Send(context.Background(), "jane@example.com", "Invitation to Café trip", "Hello\nJane")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := <-received
+	for _, want := range []string{
+		"MAIL FROM:<noreply@example.com>",
+		"RCPT TO:<jane@example.com>",
+		"To: jane@example.com\r\n",
+		"Subject: =?utf-8?q?Invitation_to_Caf=C3=A9_trip?=\r\n",
+		"\r\nHello\r\nJane",
+	} {
+		if !strings.Contains(session, want) {
+			t.Errorf("%q not found in session:\n%s", want, session)
+		}
+	}
+}
+
+func TestSMTPSendTimeout(t *testing.T) {
+	// accepts the connection but never greets
+	mailer := fakeSMTP(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 1))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := mailer.Send(ctx, "jane@example.com", "Invitation", "Hello"); err == nil {
+		t.Fatal("got no error from a server that never answers")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send took %s, want it to stop at the deadline", elapsed)
+	}
+}
+
+func TestSMTPSendCanceled(t *testing.T) {
+	mailer := fakeSMTP(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 1))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := mailer.Send(ctx, "jane@example.com", "Invitation", "Hello"); err == nil {
+		t.Fatal("got no error after the context was canceled")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send took %s, want it to stop once canceled", elapsed)
+	}
+}
+
+func TestMessageHeaderInjection(t *testing.T) {
+	msg := string(message("noreply@example.com", "jane@example.com\r\nBcc: eve@example.com", "Hi", "Body"))
+
+	if strings.Contains(msg, "\r\nBcc:") {
+		t.Errorf("header injected:\n%s", msg)
+	}
+}
@@ -2,107 +2,95 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"time"
+	// zone database for booking times, in case the host has none
+	_ "time/tzdata"
 
 	// "github.com/ansrivas/fiberprometheus/v2"
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/csrf"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/storage/postgres/v3"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv"
 
+	"github.com/Trisamudrisvara/goTrip/config"
 	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+	"github.com/Trisamudrisvara/goTrip/mailer"
+	"github.com/Trisamudrisvara/goTrip/media"
 	"github.com/Trisamudrisvara/goTrip/routes"
 )
 
-func init() {
-	// Load environment variables
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("error loading .env file:", err)
-	}
-}
-
 func main() {
-	// Set SSL mode, default to "disable" if not specified
-	sslmode := os.Getenv("SSLMODE")
-	if sslmode == "" {
-		sslmode = "disable"
+	// Load and validate configuration, refuse to start if invalid
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
 	}
-
-	dsn := os.Getenv("DB_URL")
-
-	if dsn == "" {
-		// Construct database connection string
-		dsn = fmt.Sprintf(
-			"host=%s user=%s password=%s dbname=%s sslmode=%s",
-			os.Getenv("HOST"), os.Getenv("USER"),
-			os.Getenv("PASS"), os.Getenv("NAME"), sslmode)
-
-		// Add port to connection string if specified
-		port := os.Getenv("PORT")
-		if port != "" {
-			dsn += " port=" + port
-		}
+	if err != nil {
+		slog.Error("error loading configuration", "err", err)
+		os.Exit(1)
 	}
 
+	// Structured JSON logging
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	slog.SetDefault(logging.New(os.Stdout, level))
+
 	// Create database connection
 	ctx := context.Background()
-	conn, err := pgxpool.New(ctx, dsn)
+	conn, err := pgxpool.New(ctx, cfg.DB.DSN())
 	if err != nil {
-		log.Fatal("error connecting db pool:", err)
+		slog.Error("error connecting db pool", "err", err)
+		os.Exit(1)
 	}
 	defer conn.Close()
 
+	// Destination photos, on disk or in an S3 compatible bucket
+	store, err := media.New(cfg.Media, []byte(cfg.Secret))
+	if err != nil {
+		slog.Error("error setting up media store", "err", err)
+		os.Exit(1)
+	}
+
 	// Initialize database queries and repository
 	queries := db.New(conn)
 	repo := &routes.Repo{
 		Ctx:     ctx,
 		Queries: queries,
+		Config:  cfg,
+		Pool:    conn,
+		// Rate limits are kept in postgres so they hold across instances
+		LimiterStorage: postgres.New(postgres.Config{
+			DB:    conn,
+			Table: "rate_limit",
+		}),
+		Media:  store,
+		Mailer: mailer.New(cfg.Mail),
 	}
 
 	// custom JSON encoder/decoder for performance
 	fiberConfig := fiber.Config{
 		// Prefork:     true,
-		JSONEncoder: sonic.Marshal,
-		JSONDecoder: sonic.Unmarshal,
+		JSONEncoder:  sonic.Marshal,
+		JSONDecoder:  sonic.Unmarshal,
+		ErrorHandler: logging.ErrorHandler,
+		// room for a media upload and the rest of the multipart form
+		BodyLimit: max(fiber.DefaultBodyLimit, cfg.Media.MaxSize+1<<20),
 	}
 
 	// Initializing fiber app
 	app := fiber.New(fiberConfig)
 
-	// Configure CSRF middleware
-	csrfConf := csrf.Config{
-		KeyLookup:  "form:csrf",
-		CookieName: "csrf",
-		ContextKey: "csrf",
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			log.Println("CSRF Error:", err)
-			return c.SendStatus(fiber.StatusForbidden)
-		},
-		Storage: postgres.New(postgres.Config{
-			DB:    conn,
-			Table: "csrf_token",
-		})}
-
 	// Configure Swagger
 	swaggerConf := swagger.Config{
 		Title:    "Trip API",
 		FilePath: "swagger.yaml",
-	}
-
-	// Rate Limiter Config
-	limiterConf := limiter.Config{
-		Max:        1,
-		Expiration: time.Second,
+		Path:     "docs",
 	}
 
 	// cache config
@@ -116,14 +104,28 @@ func main() {
 	// prometheus.RegisterAt(app, "/metrics")
 	// prometheus.SetSkipPaths([]string{"/ping", "/favicon.ico"})
 
-	// Middlewares: logger, swagger, recover, cache, rate limiter & CSRF protection
-	app.Use(logger.New(), swagger.New(swaggerConf), recover.New(),
-		limiter.New(limiterConf), csrf.New(csrfConf))
+	// Middlewares: request id & logger, CORS, security headers, swagger,
+	// recover, cache & CSRF protection
+	// rate limits are set per route group in SetupRoutes
+	app.Use(logging.Middleware(slog.Default()), newCORS(cfg),
+		newHelmet(cfg.Headers, "/"+swaggerConf.Path), swagger.New(swaggerConf),
+		recover.New(), newCSRF(cfg.CSRF, conn))
 
 	// Set up routes
 	repo.SetupRoutes(app)
 
+	// imports running when the server last stopped can't be resumed
+	if err := repo.FailInterruptedImports(); err != nil {
+		slog.Error("error in failing interrupted imports", "err", err)
+	}
+
+	// Purge old destinations and trips from the trash in the background
+	go repo.PurgeTrash()
+
 	// Start the server
-	port := ":" + os.Getenv("API_PORT")
-	log.Fatal(app.Listen(port))
+	port := fmt.Sprintf(":%d", cfg.APIPort)
+	if err := app.Listen(port); err != nil {
+		slog.Error("server stopped", "err", err)
+		os.Exit(1)
+	}
 }
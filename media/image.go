@@ -0,0 +1,109 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// largest image decoded, guards against decompression bombs
+const maxPixels = 50_000_000
+
+var (
+	ErrUnsupportedType = errors.New("media: unsupported content type")
+	ErrTooLarge        = errors.New("media: image dimensions are too large")
+)
+
+// file extension of each accepted content type
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Sniff detects the content type from the data itself, ignoring
+// whatever the client claimed, and returns its file extension
+func Sniff(data []byte) (contentType, ext string, err error) {
+	contentType = http.DetectContentType(data)
+
+	ext, ok := extensions[contentType]
+	if !ok {
+		return contentType, "", ErrUnsupportedType
+	}
+
+	return contentType, ext, nil
+}
+
+// file extension of the documents accepted as attachments besides images
+var documentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+}
+
+// SniffAttachment is Sniff for attachments such as tickets, which may be
+// PDF documents as well as images
+func SniffAttachment(data []byte) (contentType, ext string, err error) {
+	contentType, ext, err = Sniff(data)
+	if err == nil {
+		return contentType, ext, nil
+	}
+
+	ext, ok := documentExtensions[contentType]
+	if !ok {
+		return contentType, "", ErrUnsupportedType
+	}
+
+	return contentType, ext, nil
+}
+
+// Thumbnail decodes data and returns a JPEG scaled down to fit within
+// size x size, along with the dimensions of the original image
+func Thumbnail(data []byte, size int) (thumb []byte, width, height int, err error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, 0, 0, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	bounds := src.Bounds()
+	width, height = bounds.Dx(), bounds.Dy()
+
+	// keep the aspect ratio, never scale up
+	w, h := width, height
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// JPEG has no transparency, use a white background
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, 0, 0, err
+	}
+
+	return buf.Bytes(), width, height, nil
+}
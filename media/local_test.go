@@ -0,0 +1,147 @@
+package media
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newLocal returns a store in a temporary directory whose clock is *now
+func newLocal(t *testing.T, now *time.Time) *Local {
+	t.Helper()
+
+	l, err := NewLocal(t.TempDir(), "/media", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return *now }
+
+	return l
+}
+
+// signedURL returns the path, expires and signature of a URL from l
+func signedURL(t *testing.T, l *Local, key string, expiry time.Duration) (path, expires, signature string) {
+	t.Helper()
+
+	raw, err := l.URL(key, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u.Path, u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestLocalURL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newLocal(t, &now)
+
+	path, expires, signature := signedURL(t, l, "destination/a.jpg", time.Hour)
+
+	if path != "/media/destination/a.jpg" {
+		t.Errorf("got path %q", path)
+	}
+	if expires != "1700003600" {
+		t.Errorf("got expires %q, want 1700003600", expires)
+	}
+	if len(signature) != 64 {
+		t.Errorf("got signature %q, want 64 hex digits", signature)
+	}
+
+	if _, err := l.URL("../a.jpg", time.Hour); err != ErrInvalidKey {
+		t.Errorf("got %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestLocalVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newLocal(t, &now)
+
+	_, expires, signature := signedURL(t, l, "destination/a.jpg", time.Hour)
+
+	ttl, err := l.Verify("destination/a.jpg", expires, signature)
+	if err != nil || ttl != time.Hour {
+		t.Errorf("got %v, %v, want 1h0m0s, nil", ttl, err)
+	}
+
+	other := newLocal(t, &now)
+	other.secret = []byte("another secret")
+
+	tests := []struct {
+		name                    string
+		l                       *Local
+		key, expires, signature string
+	}{
+		{"other key", l, "destination/b.jpg", expires, signature},
+		{"later expires", l, "destination/a.jpg", "1700007200", signature},
+		{"bad expires", l, "destination/a.jpg", "soon", signature},
+		{"bad signature", l, "destination/a.jpg", expires, strings.Repeat("0", 64)},
+		{"no signature", l, "destination/a.jpg", expires, ""},
+		{"other secret", other, "destination/a.jpg", expires, signature},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.l.Verify(tt.key, tt.expires, tt.signature); err != ErrInvalidSign {
+			t.Errorf("%s: got %v, want ErrInvalidSign", tt.name, err)
+		}
+	}
+}
+
+func TestLocalVerifyExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newLocal(t, &now)
+
+	_, expires, signature := signedURL(t, l, "destination/a.jpg", time.Hour)
+
+	now = now.Add(59 * time.Minute)
+	if ttl, err := l.Verify("destination/a.jpg", expires, signature); err != nil || ttl != time.Minute {
+		t.Errorf("a minute before expiry: got %v, %v", ttl, err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := l.Verify("destination/a.jpg", expires, signature); err != ErrInvalidSign {
+		t.Errorf("at expiry: got %v, want ErrInvalidSign", err)
+	}
+}
+
+func TestLocalPutDelete(t *testing.T) {
+	now := time.Now()
+	l := newLocal(t, &now)
+	ctx := context.Background()
+
+	if err := l.Put(ctx, "destination/a.jpg", strings.NewReader("photo and more"), 5, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := l.Path("destination/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(name); err != nil || string(data) != "photo" {
+		t.Errorf("got %q, %v, want photo", data, err)
+	}
+
+	if err := l.Delete(ctx, "destination/a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("file still exists after Delete: %v", err)
+	}
+
+	// deleting a missing file isn't an error
+	if err := l.Delete(ctx, "destination/a.jpg"); err != nil {
+		t.Errorf("got %v deleting a missing file", err)
+	}
+
+	if _, err := l.Path("../a.jpg"); err != ErrInvalidKey {
+		t.Errorf("got %v, want ErrInvalidKey", err)
+	}
+}
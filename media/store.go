@@ -0,0 +1,46 @@
+// Package media stores uploaded files and builds the URLs they are served from
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Trisamudrisvara/goTrip/config"
+)
+
+var (
+	ErrInvalidKey  = errors.New("media: invalid key")
+	ErrInvalidSign = errors.New("media: invalid or expired signature")
+)
+
+// Store keeps media files under slash separated keys
+type Store interface {
+	// Put writes size bytes of body under key, replacing any existing file
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete removes the file under key, missing files aren't an error
+	Delete(ctx context.Context, key string) error
+	// URL returns a URL the file can be fetched from until expiry
+	URL(key string, expiry time.Duration) (string, error)
+}
+
+// New returns the store selected by the configuration
+func New(cfg config.Media, secret []byte) (Store, error) {
+	switch cfg.Store {
+	case "local":
+		return NewLocal(cfg.Dir, cfg.BaseURL, secret)
+	case "s3":
+		return NewS3(cfg.S3)
+	}
+	return nil, fmt.Errorf("media: unknown store %q", cfg.Store)
+}
+
+// validKey rejects keys that could escape the store's root
+func validKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") &&
+		path.Clean(key) == key && !strings.HasPrefix(key, "../") && key != ".."
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+	"github.com/gofiber/storage/postgres/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Trisamudrisvara/goTrip/config"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+// newCSRF returns the CSRF middleware
+//
+// The token is read from the configured header or the csrf form field and
+// must match the csrf cookie (double submit). Requests that can't come from
+// a cross-site form, i.e. carrying a bearer token, skip the check when
+// configured to
+func newCSRF(cfg config.CSRF, conn *pgxpool.Pool) fiber.Handler {
+	return csrf.New(csrf.Config{
+		Next: func(c *fiber.Ctx) bool {
+			return cfg.SkipBearer && strings.HasPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		},
+		Extractor: func(c *fiber.Ctx) (string, error) {
+			if token := c.Get(cfg.Header); token != "" {
+				return token, nil
+			}
+			if token := c.FormValue("csrf"); token != "" {
+				return token, nil
+			}
+			return "", csrf.ErrTokenNotFound
+		},
+		CookieName: "csrf",
+		ContextKey: "csrf",
+		Expiration: cfg.Expiration,
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			logging.FromCtx(c).Warn("csrf error", "err", err)
+			return c.Status(fiber.StatusForbidden).JSON(
+				&fiber.Map{"error": "invalid csrf token"})
+		},
+		// expired tokens are purged every PurgeInterval
+		Storage: postgres.New(postgres.Config{
+			DB:         conn,
+			Table:      "csrf_token",
+			GCInterval: cfg.PurgeInterval,
+		}),
+	})
+}
+
+// headers clients need to read, the rate limit ones are set in routes
+var exposedHeaders = []string{
+	fiber.HeaderXRequestID,
+	fiber.HeaderRetryAfter,
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"RateLimit-Policy",
+}
+
+// newCORS returns the CORS middleware, or a no-op one if no origin is allowed
+func newCORS(cfg *config.Config) fiber.Handler {
+	if len(cfg.CORS.AllowOrigins) == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	allowHeaders := []string{
+		fiber.HeaderOrigin,
+		fiber.HeaderContentType,
+		fiber.HeaderAccept,
+		fiber.HeaderAuthorization,
+		fiber.HeaderXRequestID,
+		cfg.CSRF.Header,
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.CORS.AllowOrigins, ","),
+		AllowHeaders:     strings.Join(allowHeaders, ","),
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		ExposeHeaders:    strings.Join(exposedHeaders, ","),
+		MaxAge:           int(cfg.CORS.MaxAge.Seconds()),
+	})
+}
+
+// newHelmet returns the security headers middleware
+// the swagger UI gets its own CSP since it loads scripts and styles from unpkg
+func newHelmet(cfg config.Headers, docsPath string) fiber.Handler {
+	base := helmet.Config{
+		HSTSMaxAge:     int(cfg.HSTSMaxAge.Seconds()),
+		ReferrerPolicy: cfg.ReferrerPolicy,
+		// the API is meant to be used from other origins
+		CrossOriginResourcePolicy: "cross-origin",
+	}
+
+	api := base
+	api.ContentSecurityPolicy = cfg.CSP
+
+	docs := base
+	docs.ContentSecurityPolicy = cfg.DocsCSP
+	// unpkg assets don't send Cross-Origin-Resource-Policy
+	docs.CrossOriginEmbedderPolicy = "unsafe-none"
+
+	apiHelmet := helmet.New(api)
+	docsHelmet := helmet.New(docs)
+
+	return func(c *fiber.Ctx) error {
+		if strings.HasPrefix(c.Path(), docsPath) {
+			return docsHelmet(c)
+		}
+		return apiHelmet(c)
+	}
+}
@@ -0,0 +1,150 @@
+// Package money handles amounts stored as integer minor units of an
+// ISO 4217 currency, e.g. cents, and their conversion between currencies
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrCurrency = errors.New("money: unknown currency")
+	ErrAmount   = errors.New("money: invalid amount")
+	ErrRate     = errors.New("money: invalid exchange rate")
+)
+
+// digits after the decimal point of each supported currency
+var exponents = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BRL": 2,
+	"CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+	"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KES": 2, "KRW": 0, "KWD": 3,
+	"LKR": 2, "MAD": 2, "MXN": 2, "MYR": 2, "NOK": 2, "NPR": 2, "NZD": 2,
+	"OMR": 3, "PEN": 2, "PHP": 2, "PKR": 2, "PLN": 2, "QAR": 2, "RON": 2,
+	"RUB": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2,
+	"TWD": 2, "UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// Currency normalizes a currency code, ok is false if it isn't supported
+func Currency(code string) (currency string, ok bool) {
+	currency = strings.ToUpper(strings.TrimSpace(code))
+	_, ok = exponents[currency]
+	return currency, ok
+}
+
+// Exponent returns the digits after the decimal point of a currency
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[currency]
+	if !ok {
+		return 0, ErrCurrency
+	}
+	return exp, nil
+}
+
+// Parse reads a decimal amount such as "12.5" in minor units of the
+// currency, more decimals than the currency has are rejected
+func Parse(amount, currency string) (int64, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	whole, frac, _ := strings.Cut(strings.TrimSpace(amount), ".")
+	negative := strings.HasPrefix(whole, "-")
+	whole = strings.TrimPrefix(whole, "-")
+
+	if whole == "" && frac == "" || len(frac) > exp || len(whole) > 15 {
+		return 0, ErrAmount
+	}
+
+	var minor int64
+	for _, r := range whole + frac + strings.Repeat("0", exp-len(frac)) {
+		if r < '0' || r > '9' {
+			return 0, ErrAmount
+		}
+		minor = minor*10 + int64(r-'0')
+	}
+
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// Format writes an amount in minor units as a decimal, e.g. 1250 USD as
+// "12.50"
+func Format(minor int64, currency string) string {
+	exp := exponents[currency]
+	return new(big.Rat).SetFrac(big.NewInt(minor), pow10(exp)).FloatString(exp)
+}
+
+// ParseRate reads an exchange rate such as "0.9213", it has to be positive
+func ParseRate(rate string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrRate
+	}
+	return r, nil
+}
+
+// Convert converts an amount in minor units of from into minor units of
+// to, where one unit of from is worth rate units of to. The result is
+// rounded half away from zero
+func Convert(minor int64, from, to string, rate *big.Rat) (int64, error) {
+	fromExp, err := Exponent(from)
+	if err != nil {
+		return 0, err
+	}
+
+	toExp, err := Exponent(to)
+	if err != nil {
+		return 0, err
+	}
+
+	v := new(big.Rat).SetInt64(minor)
+	v.Mul(v, rate)
+	v.Mul(v, new(big.Rat).SetFrac(pow10(toExp), pow10(fromExp)))
+
+	return round(v), nil
+}
+
+// round rounds half away from zero
+func round(v *big.Rat) int64 {
+	num := new(big.Int).Abs(v.Num())
+	q, m := new(big.Int).QuoRem(num, v.Denom(), new(big.Int))
+	if m.Lsh(m, 1).Cmp(v.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+
+	if v.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// Rates holds exchange rates by currency pair, one unit of the first
+// currency is worth the rate in the second
+type Rates map[[2]string]*big.Rat
+
+// Rate returns the rate from one currency to another, falling back to
+// the inverse of the opposite pair
+func (rates Rates) Rate(from, to string) (*big.Rat, bool) {
+	if from == to {
+		return big.NewRat(1, 1), true
+	}
+
+	if rate, ok := rates[[2]string{from, to}]; ok {
+		return rate, true
+	}
+
+	if rate, ok := rates[[2]string{to, from}]; ok {
+		return new(big.Rat).Inv(rate), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,155 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		want             int64
+		err              error
+	}{
+		{"12.5", "USD", 1250, nil},
+		{"12.50", "USD", 1250, nil},
+		{" 12 ", "USD", 1200, nil},
+		{"5.", "USD", 500, nil},
+		{".5", "USD", 50, nil},
+		{"0", "USD", 0, nil},
+		{"-1", "USD", -100, nil},
+		{"-0.01", "USD", -1, nil},
+		{"999999999999999.99", "USD", 99999999999999999, nil},
+		{"1.234", "BHD", 1234, nil},
+		{"1.5", "BHD", 1500, nil},
+		{"1500", "JPY", 1500, nil},
+		{"1500.", "JPY", 1500, nil},
+
+		{"1.234", "USD", 0, ErrAmount},
+		{"1.5", "JPY", 0, ErrAmount},
+		{".5", "JPY", 0, ErrAmount},
+		{"1.2345", "BHD", 0, ErrAmount},
+		{"", "USD", 0, ErrAmount},
+		{".", "USD", 0, ErrAmount},
+		{"-", "USD", 0, ErrAmount},
+		{"--1", "USD", 0, ErrAmount},
+		{"+1", "USD", 0, ErrAmount},
+		{"1,50", "USD", 0, ErrAmount},
+		{"1.2.3", "BHD", 0, ErrAmount},
+		{"1e3", "USD", 0, ErrAmount},
+		{"1 000", "USD", 0, ErrAmount},
+		{"1000000000000000", "USD", 0, ErrAmount},
+		{"12", "XYZ", 0, ErrCurrency},
+		{"12", "usd", 0, ErrCurrency},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.amount, tt.currency)
+		if got != tt.want || err != tt.err {
+			t.Errorf("Parse(%q, %s) = %d, %v, want %d, %v", tt.amount, tt.currency, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{1250, "USD", "12.50"},
+		{5, "USD", "0.05"},
+		{-1, "USD", "-0.01"},
+		{1500, "JPY", "1500"},
+		{1234, "BHD", "1.234"},
+	}
+
+	for _, tt := range tests {
+		if got := Format(tt.minor, tt.currency); got != tt.want {
+			t.Errorf("Format(%d, %s) = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+
+		if back, err := Parse(tt.want, tt.currency); back != tt.minor || err != nil {
+			t.Errorf("Parse(%q, %s) = %d, %v, want %d", tt.want, tt.currency, back, err, tt.minor)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		from, to string
+		rate     string
+		want     int64
+	}{
+		{1000, "USD", "EUR", "0.9213", 921},
+		{100, "USD", "USD", "1", 100},
+		// exactly half a minor unit rounds away from zero
+		{1, "USD", "EUR", "0.5", 1},
+		{-1, "USD", "EUR", "0.5", -1},
+		{3, "USD", "EUR", "0.5", 2},
+		{-3, "USD", "EUR", "0.5", -2},
+		// just below half rounds toward zero
+		{1, "USD", "EUR", "0.4999", 0},
+		{-1, "USD", "EUR", "0.4999", 0},
+		{1, "USD", "EUR", "0.5001", 1},
+		// between exponents
+		{1250, "USD", "JPY", "151.37", 1892},
+		{1250, "USD", "JPY", "150.36", 1880},
+		{-1250, "USD", "JPY", "150.36", -1880},
+		{1892, "JPY", "USD", "0.0066", 1249},
+		{1, "JPY", "USD", "0.005", 1},
+		{1000, "USD", "BHD", "0.376", 3760},
+		{376, "BHD", "USD", "2.66", 100},
+		{0, "USD", "EUR", "0.9213", 0},
+	}
+
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Convert(tt.minor, tt.from, tt.to, rate)
+		if got != tt.want || err != nil {
+			t.Errorf("Convert(%d, %s, %s, %s) = %d, %v, want %d", tt.minor, tt.from, tt.to, tt.rate, got, err, tt.want)
+		}
+	}
+
+	if _, err := Convert(100, "USD", "XYZ", big.NewRat(1, 1)); err != ErrCurrency {
+		t.Errorf("got %v, want ErrCurrency", err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for _, rate := range []string{"", "0", "-1", "0.0", "abc", "1/0"} {
+		if _, err := ParseRate(rate); err != ErrRate {
+			t.Errorf("ParseRate(%q): got %v, want ErrRate", rate, err)
+		}
+	}
+
+	if r, err := ParseRate(" 0.9213 "); err != nil || r.Cmp(big.NewRat(9213, 10000)) != 0 {
+		t.Errorf("got %v, %v", r, err)
+	}
+}
+
+func TestRate(t *testing.T) {
+	rates := Rates{{"USD", "EUR"}: big.NewRat(4, 5)}
+
+	tests := []struct {
+		from, to string
+		want     *big.Rat
+	}{
+		{"USD", "EUR", big.NewRat(4, 5)},
+		{"EUR", "USD", big.NewRat(5, 4)},
+		{"GBP", "GBP", big.NewRat(1, 1)},
+		{"USD", "GBP", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := rates.Rate(tt.from, tt.to)
+		if ok != (tt.want != nil) || ok && got.Cmp(tt.want) != 0 {
+			t.Errorf("Rate(%s, %s) = %v, %v, want %v", tt.from, tt.to, got, ok, tt.want)
+		}
+	}
+}
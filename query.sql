@@ -26,52 +26,1198 @@ UPDATE users SET admin = false
 
 -- name: CreateDestination :exec
 INSERT INTO destination (
-  id, name, description, attraction
+  id, name, description, attraction,
+  latitude, longitude, country, region, city, place_id
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 );
 
 -- name: ListDestinations :many
-SELECT * FROM destination;
+SELECT * FROM destination
+ WHERE deleted_at IS NULL;
 
 -- name: GetDestination :one
-SELECT name, description, attraction FROM destination
- WHERE id = $1 LIMIT 1;
+SELECT name, description, attraction,
+ latitude, longitude, country, region, city, place_id FROM destination
+ WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
 
--- name: UpdateDestination :exec
+-- name: ListNearbyDestinations :many
+-- great-circle distance using the haversine formula, the bounding box
+-- lets the coordinates index skip destinations that are too far away
+SELECT id, name, description, attraction,
+ latitude, longitude, country, region, city, place_id,
+ (6371 * 2 * asin(least(1, sqrt(
+   power(sin(radians(latitude - @lat::float8) / 2), 2) +
+   cos(radians(@lat::float8)) * cos(radians(latitude)) *
+   power(sin(radians(longitude - @lng::float8) / 2), 2)
+ ))))::float8 AS distance_km
+FROM destination
+WHERE deleted_at IS NULL
+ AND latitude BETWEEN @min_lat::float8 AND @max_lat::float8
+ AND longitude BETWEEN @min_lng::float8 AND @max_lng::float8
+ AND 6371 * 2 * asin(least(1, sqrt(
+   power(sin(radians(latitude - @lat::float8) / 2), 2) +
+   cos(radians(@lat::float8)) * cos(radians(latitude)) *
+   power(sin(radians(longitude - @lng::float8) / 2), 2)
+ ))) <= @radius_km::float8
+ORDER BY distance_km
+LIMIT @max_results;
+
+-- name: UpdateDestination :execrows
 UPDATE destination
  SET name = $2,
  description = $3,
- attraction = $4
-WHERE id = $1;
+ attraction = $4,
+ latitude = $5,
+ longitude = $6,
+ country = $7,
+ region = $8,
+ city = $9,
+ place_id = $10
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: DeleteDestination :execrows
+-- moves it to the trash
+UPDATE destination
+ SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListDeletedDestinations :many
+SELECT id, name, country, city, deleted_at FROM destination
+ WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
 
--- name: DeleteDestination :exec
+-- name: RestoreDestination :execrows
+UPDATE destination
+ SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: DeletePurgedMedia :many
+-- media of the destinations about to be purged, the files are removed
+-- from the store by the caller
+DELETE FROM media_file
+ WHERE destination_id IN (
+   SELECT id FROM destination WHERE deleted_at < $1
+ )
+RETURNING key, thumbnail_key;
+
+-- name: PurgeDestinations :execrows
 DELETE FROM destination
- WHERE id = $1;
+ WHERE deleted_at < $1;
 
 
 -- name: CreateTrip :exec
-INSERT INTO trip (
-  id, name, start_date, end_date, destination_id
-) VALUES (
-  $1, $2, $3, $4, $5
-);
+-- the user becomes the owner
+WITH created AS (
+  INSERT INTO trip (
+    id, name, start_date, end_date, destination_id, user_id
+  ) VALUES (
+    $1, $2, $3, $4, $5, $6
+  )
+  RETURNING id, user_id
+)
+INSERT INTO trip_member (trip_id, user_id, role)
+SELECT id, user_id, 'owner' FROM created
+ WHERE user_id IS NOT NULL;
 
 -- name: ListTrips :many
-SELECT * FROM trip;
+SELECT t.*,
+ (t.destination_id IS NULL OR d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE t.deleted_at IS NULL;
+
+-- name: ListMemberTrips :many
+-- trips the user is a member of
+SELECT t.*,
+ (t.destination_id IS NULL OR d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip t
+JOIN trip_member m ON m.trip_id = t.id
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE m.user_id = $1 AND t.deleted_at IS NULL;
 
 -- name: GetTrip :one
-SELECT name, start_date, end_date, destination_id FROM trip
- WHERE id = $1 LIMIT 1;
+SELECT t.name, t.start_date, t.end_date, t.destination_id, t.budget, t.currency,
+ (t.destination_id IS NULL OR d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE t.id = $1 AND t.deleted_at IS NULL LIMIT 1;
 
--- name: UpdateTrip :exec
+-- name: UpdateTrip :execrows
 UPDATE trip
  SET name = $2,
  start_date = $3,
  end_date = $4,
- destination_id = $5
-WHERE id = $1;
+ destination_id = $5,
+ updated_at = now(),
+ sequence = sequence + 1
+WHERE id = $1 AND deleted_at IS NULL;
 
--- name: DeleteTrip :exec
+-- name: DeleteTrip :execrows
+-- moves it to the trash
+UPDATE trip
+ SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListDeletedTrips :many
+SELECT id, name, start_date, end_date, user_id, deleted_at FROM trip
+ WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: RestoreTrip :execrows
+UPDATE trip
+ SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: PurgeTrips :execrows
 DELETE FROM trip
+ WHERE deleted_at < $1;
+
+
+-- name: UpsertPlace :one
+INSERT INTO place (
+  id, parent_id, kind, name, country_code, geoname_id, latitude, longitude, admin1_code
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (geoname_id) DO UPDATE
+ SET parent_id = EXCLUDED.parent_id,
+ kind = EXCLUDED.kind,
+ name = EXCLUDED.name,
+ country_code = EXCLUDED.country_code,
+ latitude = EXCLUDED.latitude,
+ longitude = EXCLUDED.longitude,
+ admin1_code = EXCLUDED.admin1_code
+RETURNING id;
+
+-- name: FindCountryPlace :one
+SELECT id FROM place
+ WHERE kind = 'country' AND country_code = $1
+LIMIT 1;
+
+-- name: FindRegionPlace :one
+SELECT id FROM place
+ WHERE kind = 'region' AND country_code = $1 AND admin1_code = $2
+LIMIT 1;
+
+-- name: ListCountries :many
+SELECT * FROM place
+ WHERE parent_id IS NULL
+ ORDER BY name;
+
+-- name: GetPlace :one
+SELECT * FROM place
+ WHERE id = $1 LIMIT 1;
+
+-- name: ListChildPlaces :many
+SELECT * FROM place
+ WHERE parent_id = $1
+ ORDER BY kind, name;
+
+-- name: ListPlaceDestinations :many
+SELECT * FROM destination
+ WHERE place_id = $1 AND deleted_at IS NULL
+ ORDER BY name;
+
+-- name: ListPlaceAncestors :many
+-- the given places and all of their ancestors, used to build breadcrumbs
+WITH RECURSIVE ancestor AS (
+  SELECT id, parent_id, kind, name, country_code FROM place
+   WHERE id = ANY(@ids::uuid[])
+  UNION
+  SELECT p.id, p.parent_id, p.kind, p.name, p.country_code FROM place p
+   JOIN ancestor a ON p.id = a.parent_id
+)
+SELECT id, parent_id, kind, name, country_code FROM ancestor;
+
+
+-- name: CreateAttraction :exec
+INSERT INTO attraction (
+  id, destination_id, name, category, description, opening_hours,
+  visit_duration_minutes, price_level, latitude, longitude
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+);
+
+-- name: ListAttractions :many
+-- none for destinations in the trash
+SELECT a.* FROM attraction a
+JOIN destination d ON d.id = a.destination_id
+WHERE a.destination_id = $1 AND d.deleted_at IS NULL
+ORDER BY a.name;
+
+-- name: GetAttraction :one
+SELECT a.* FROM attraction a
+JOIN destination d ON d.id = a.destination_id
+WHERE a.id = $1 AND a.destination_id = $2 AND d.deleted_at IS NULL LIMIT 1;
+
+-- name: UpdateAttraction :execrows
+UPDATE attraction
+ SET name = $3,
+ category = $4,
+ description = $5,
+ opening_hours = $6,
+ visit_duration_minutes = $7,
+ price_level = $8,
+ latitude = $9,
+ longitude = $10
+WHERE id = $1 AND destination_id = $2;
+
+-- name: DeleteAttraction :execrows
+DELETE FROM attraction
+ WHERE id = $1 AND destination_id = $2;
+
+
+-- name: CreateMediaFile :exec
+-- appended to the end of the gallery
+INSERT INTO media_file (
+  id, destination_id, key, thumbnail_key, content_type, size, width, height, caption, position
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9,
+  (SELECT coalesce(max(position) + 1, 0) FROM media_file WHERE destination_id = $2)
+);
+
+-- name: ListMediaFiles :many
+-- none for destinations in the trash
+SELECT m.* FROM media_file m
+JOIN destination d ON d.id = m.destination_id
+WHERE m.destination_id = $1 AND d.deleted_at IS NULL
+ORDER BY m.position, m.created_at;
+
+-- name: GetMediaFile :one
+SELECT * FROM media_file
+ WHERE id = $1 AND destination_id = $2 LIMIT 1;
+
+-- name: UpdateMediaCaption :execrows
+UPDATE media_file
+ SET caption = $3
+WHERE id = $1 AND destination_id = $2;
+
+-- name: SetMediaCover :execrows
+-- a single statement so there is never more than one cover,
+-- nothing changes if the file isn't part of the destination
+UPDATE media_file
+ SET is_cover = (id = @id)
+WHERE destination_id = @destination_id
+ AND EXISTS (
+   SELECT 1 FROM media_file
+    WHERE id = @id AND destination_id = @destination_id
+ );
+
+-- name: ReorderMediaFiles :execrows
+-- positions follow the order of ids
+UPDATE media_file m
+ SET position = o.position
+FROM unnest(@ids::uuid[]) WITH ORDINALITY AS o(id, position)
+WHERE m.id = o.id AND m.destination_id = @destination_id;
+
+-- name: DeleteMediaFile :one
+DELETE FROM media_file
+ WHERE id = $1 AND destination_id = $2
+RETURNING key, thumbnail_key;
+
+
+-- name: CreateReview :exec
+INSERT INTO review (
+  id, destination_id, user_id, rating, title, body, visit_date
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: ListDestinationReviews :many
+SELECT r.id, r.user_id, u.name AS author, r.rating, r.title, r.body,
+ r.visit_date, r.status, r.created_at, r.updated_at
+FROM review r
+JOIN users u ON u.id = r.user_id
+JOIN destination d ON d.id = r.destination_id
+WHERE r.destination_id = $1 AND r.status <> 'hidden' AND d.deleted_at IS NULL
+ORDER BY r.created_at DESC;
+
+-- name: ListFlaggedReviews :many
+SELECT r.id, r.destination_id, r.user_id, u.name AS author, r.rating, r.title,
+ r.body, r.visit_date, r.status, r.created_at, r.updated_at
+FROM review r
+JOIN users u ON u.id = r.user_id
+WHERE r.status = 'flagged'
+ORDER BY r.updated_at;
+
+-- name: UpdateReview :execrows
+-- only the author can edit their review
+UPDATE review
+ SET rating = $4,
+ title = $5,
+ body = $6,
+ visit_date = $7,
+ updated_at = now()
+WHERE id = $1 AND destination_id = $2 AND user_id = $3;
+
+-- name: DeleteReview :execrows
+DELETE FROM review
+ WHERE id = $1 AND destination_id = $2 AND user_id = $3;
+
+-- name: SetReviewStatus :execrows
+UPDATE review
+ SET status = $3
+WHERE id = $1 AND destination_id = $2;
+
+-- name: ListDestinationRatings :many
+-- destinations without visible reviews are left out
+SELECT destination_id, avg(rating)::float8 AS average_rating, count(*) AS review_count
+FROM review
+WHERE status <> 'hidden'
+GROUP BY destination_id;
+
+-- name: GetDestinationRating :one
+SELECT coalesce(avg(rating), 0)::float8 AS average_rating, count(*) AS review_count
+FROM review
+WHERE destination_id = $1 AND status <> 'hidden';
+
+
+-- name: ListTripDestinations :many
+-- deleted destinations stay on the trip until they are purged
+SELECT td.destination_id, d.name, td.position,
+ (d.deleted_at IS NOT NULL)::bool AS destination_deleted
+FROM trip_destination td
+JOIN destination d ON d.id = td.destination_id
+WHERE td.trip_id = $1
+ORDER BY td.position;
+
+-- name: CopyWishlistToTrip :exec
+INSERT INTO trip_destination (trip_id, destination_id, position)
+SELECT @trip_id::uuid, i.destination_id, i.position FROM wishlist_item i
+JOIN destination d ON d.id = i.destination_id
+WHERE i.wishlist_id = @wishlist_id AND d.deleted_at IS NULL;
+
+
+-- name: EnsureDefaultWishlist :exec
+INSERT INTO wishlist (
+  id, user_id, name, is_default
+) VALUES (
+  $1, $2, 'Favorites', true
+)
+ON CONFLICT (user_id) WHERE is_default DO NOTHING;
+
+-- name: CreateWishlist :exec
+INSERT INTO wishlist (
+  id, user_id, name
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: ListWishlists :many
+SELECT w.id, w.name, w.is_default, w.share_token, w.created_at,
+ count(d.id) AS item_count
+FROM wishlist w
+LEFT JOIN wishlist_item i ON i.wishlist_id = w.id
+LEFT JOIN destination d ON d.id = i.destination_id AND d.deleted_at IS NULL
+WHERE w.user_id = $1
+GROUP BY w.id
+ORDER BY w.is_default DESC, w.name;
+
+-- name: GetWishlist :one
+SELECT * FROM wishlist
+ WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: GetDefaultWishlist :one
+SELECT * FROM wishlist
+ WHERE user_id = $1 AND is_default LIMIT 1;
+
+-- name: GetSharedWishlist :one
+SELECT * FROM wishlist
+ WHERE share_token = $1 LIMIT 1;
+
+-- name: RenameWishlist :execrows
+UPDATE wishlist
+ SET name = $3
+WHERE id = $1 AND user_id = $2 AND NOT is_default;
+
+-- name: SetWishlistShareToken :execrows
+-- a NULL token stops sharing
+UPDATE wishlist
+ SET share_token = $3
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteWishlist :execrows
+DELETE FROM wishlist
+ WHERE id = $1 AND user_id = $2 AND NOT is_default;
+
+-- name: AddWishlistItem :exec
+-- appended to the end of the list, adding it again only updates the note
+INSERT INTO wishlist_item (
+  wishlist_id, destination_id, note, position
+) VALUES (
+  $1, $2, $3,
+  (SELECT coalesce(max(position) + 1, 0) FROM wishlist_item WHERE wishlist_id = $1)
+)
+ON CONFLICT (wishlist_id, destination_id) DO UPDATE
+ SET note = EXCLUDED.note;
+
+-- name: ListWishlistItems :many
+SELECT i.destination_id, d.name, d.country, d.city, i.note, i.position, i.added_at
+FROM wishlist_item i
+JOIN destination d ON d.id = i.destination_id
+WHERE i.wishlist_id = $1 AND d.deleted_at IS NULL
+ORDER BY i.position;
+
+-- name: ReorderWishlistItems :execrows
+-- positions follow the order of destination_ids
+UPDATE wishlist_item i
+ SET position = o.position
+FROM unnest(@destination_ids::uuid[]) WITH ORDINALITY AS o(destination_id, position)
+WHERE i.destination_id = o.destination_id AND i.wishlist_id = @wishlist_id;
+
+-- name: RemoveWishlistItem :execrows
+DELETE FROM wishlist_item
+ WHERE wishlist_id = $1 AND destination_id = $2;
+
+-- name: CreateTag :exec
+INSERT INTO tag (
+  id, slug, name, category
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: ListTags :many
+SELECT t.id, t.slug, t.name, t.category,
+ count(d.id) AS destination_count
+FROM tag t
+LEFT JOIN destination_tag dt ON dt.tag_id = t.id
+LEFT JOIN destination d ON d.id = dt.destination_id AND d.deleted_at IS NULL
+GROUP BY t.id
+ORDER BY t.category, t.name;
+
+-- name: UpdateTag :execrows
+UPDATE tag
+ SET slug = $2, name = $3, category = $4
+WHERE id = $1;
+
+-- name: DeleteTag :execrows
+DELETE FROM tag
  WHERE id = $1;
+
+-- name: ListDestinationTags :many
+-- tags of each of the given destinations
+SELECT dt.destination_id, t.slug, t.name, t.category
+FROM destination_tag dt
+JOIN tag t ON t.id = dt.tag_id
+WHERE dt.destination_id = ANY(@destination_ids::uuid[])
+ORDER BY t.category, t.name;
+
+-- name: ListDestinationsByTags :many
+-- destinations having every one of the given tags, slugs must be distinct
+SELECT * FROM destination
+WHERE deleted_at IS NULL AND id IN (
+  SELECT dt.destination_id
+  FROM destination_tag dt
+  JOIN tag t ON t.id = dt.tag_id
+  WHERE t.slug = ANY(@slugs::text[])
+  GROUP BY dt.destination_id
+  HAVING count(*) = cardinality(@slugs::text[])
+);
+
+-- name: ClearDestinationTags :exec
+DELETE FROM destination_tag
+ WHERE destination_id = $1;
+
+-- name: AddDestinationTags :execrows
+-- unknown slugs are skipped, compare the rows with the number of slugs
+INSERT INTO destination_tag (destination_id, tag_id)
+SELECT @destination_id::uuid, id FROM tag
+ WHERE slug = ANY(@slugs::text[]);
+
+-- name: UpsertDestinationTranslation :exec
+INSERT INTO destination_translation (
+  destination_id, locale, name, description, attraction
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (destination_id, locale) DO UPDATE
+ SET name = EXCLUDED.name,
+     description = EXCLUDED.description,
+     attraction = EXCLUDED.attraction,
+     updated_at = now();
+
+-- name: ListDestinationTranslations :many
+SELECT * FROM destination_translation
+ WHERE destination_id = $1
+ORDER BY locale;
+
+-- name: DeleteDestinationTranslation :execrows
+DELETE FROM destination_translation
+ WHERE destination_id = $1 AND locale = $2;
+
+-- name: ListTranslations :many
+-- translations of the given destinations in the given locales
+SELECT * FROM destination_translation
+ WHERE destination_id = ANY(@destination_ids::uuid[])
+ AND locale = ANY(@locales::text[]);
+
+-- name: ListTranslatedLocales :many
+SELECT destination_id, locale FROM destination_translation
+ WHERE destination_id = ANY(@destination_ids::uuid[])
+ORDER BY locale;
+
+-- name: CreateDestinationRevision :exec
+-- snapshot of the destination as it is now, run in the same transaction
+-- as the change, the destination row lock keeps versions in order
+INSERT INTO destination_revision (
+  destination_id, version, action, snapshot, editor_id
+)
+SELECT d.id,
+ coalesce((SELECT max(version) FROM destination_revision WHERE destination_id = d.id), 0) + 1,
+ @action::text, to_jsonb(d) - 'id' - 'deleted_at', @editor_id::uuid
+FROM destination d
+WHERE d.id = @destination_id::uuid;
+
+-- name: ListDestinationRevisions :many
+SELECT r.version, r.action, r.snapshot, r.editor_id, u.name AS editor_name, r.created_at
+FROM destination_revision r
+LEFT JOIN users u ON u.id = r.editor_id
+WHERE r.destination_id = $1
+ORDER BY r.version;
+
+-- name: RollbackDestination :execrows
+-- places deleted since the revision are left unset
+UPDATE destination d
+ SET name = s.name,
+ description = s.description,
+ attraction = s.attraction,
+ latitude = s.latitude,
+ longitude = s.longitude,
+ country = s.country,
+ region = s.region,
+ city = s.city,
+ place_id = (SELECT p.id FROM place p WHERE p.id = s.place_id)
+FROM destination_revision r,
+ jsonb_populate_record(NULL::destination, r.snapshot) s
+WHERE d.id = @destination_id AND d.deleted_at IS NULL
+ AND r.destination_id = d.id AND r.version = @version;
+
+-- name: UpsertDestination :one
+-- matched on the natural key, name is case insensitive
+INSERT INTO destination (
+  id, name, description, attraction,
+  latitude, longitude, country, region, city, place_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (lower(name), coalesce(country, ''), coalesce(city, '')) WHERE deleted_at IS NULL
+DO UPDATE
+ SET name = EXCLUDED.name,
+ description = EXCLUDED.description,
+ attraction = EXCLUDED.attraction,
+ latitude = EXCLUDED.latitude,
+ longitude = EXCLUDED.longitude,
+ region = EXCLUDED.region,
+ place_id = EXCLUDED.place_id
+RETURNING id, (xmax = 0)::bool AS inserted;
+
+-- name: CreateImportJob :exec
+INSERT INTO import_job (
+  id, format, mode, dry_run, total_rows, editor_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+);
+
+-- name: UpdateImportJobProgress :exec
+UPDATE import_job
+ SET processed_rows = $2,
+ created_rows = $3,
+ updated_rows = $4,
+ failed_rows = $5
+WHERE id = $1;
+
+-- name: FinishImportJob :exec
+UPDATE import_job
+ SET status = $2,
+ processed_rows = $3,
+ created_rows = $4,
+ updated_rows = $5,
+ failed_rows = $6,
+ errors = $7,
+ finished_at = now()
+WHERE id = $1;
+
+-- name: FailInterruptedImportJobs :execrows
+-- jobs left running by a previous process, nothing can finish them
+UPDATE import_job
+ SET status = 'failed',
+ errors = errors || '[{"Row": 0, "Error": "import was interrupted by a restart"}]'::jsonb,
+ finished_at = now()
+WHERE status = 'running';
+
+-- name: GetImportJob :one
+SELECT * FROM import_job
+ WHERE id = $1 LIMIT 1;
+
+-- name: GetTripEvent :one
+SELECT t.id, t.name, t.start_date, t.end_date, t.updated_at, t.sequence,
+ d.name AS destination_name, d.description AS destination_description
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+WHERE t.id = $1 AND t.deleted_at IS NULL LIMIT 1;
+
+-- name: ListTripEvents :many
+SELECT t.id, t.name, t.start_date, t.end_date, t.updated_at, t.sequence,
+ d.name AS destination_name, d.description AS destination_description
+FROM trip t
+LEFT JOIN destination d ON d.id = t.destination_id
+JOIN trip_member m ON m.trip_id = t.id
+WHERE m.user_id = $1 AND t.deleted_at IS NULL
+ORDER BY t.start_date, t.name;
+
+-- name: SetCalendarToken :exec
+-- replaces the previous token, the old feed URL stops working
+INSERT INTO calendar_feed (user_id, token)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+ SET token = EXCLUDED.token,
+ created_at = now();
+
+-- name: DeleteCalendarToken :execrows
+DELETE FROM calendar_feed
+ WHERE user_id = $1;
+
+-- name: GetCalendarFeedUser :one
+SELECT user_id FROM calendar_feed
+ WHERE token = $1 LIMIT 1;
+
+-- name: FindDestinationsByName :many
+-- names are only unique within a city, two rows are enough to tell the
+-- name is ambiguous
+SELECT id FROM destination
+ WHERE lower(name) = lower($1) AND deleted_at IS NULL
+ORDER BY id
+LIMIT 2;
+
+-- name: GetTripRole :one
+SELECT m.role FROM trip_member m
+JOIN trip t ON t.id = m.trip_id
+WHERE m.trip_id = $1 AND m.user_id = $2 AND t.deleted_at IS NULL
+LIMIT 1;
+
+-- name: ListTripMembers :many
+SELECT m.user_id, u.name, m.role, m.created_at FROM trip_member m
+JOIN users u ON u.id = m.user_id
+WHERE m.trip_id = $1
+ORDER BY array_position(ARRAY['owner', 'editor', 'viewer'], m.role), u.name;
+
+-- name: AddTripMember :exec
+-- the role of existing members is replaced, except the owner's
+INSERT INTO trip_member (trip_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (trip_id, user_id) DO UPDATE
+ SET role = EXCLUDED.role
+WHERE trip_member.role <> 'owner';
+
+-- name: UpdateTripMemberRole :execrows
+UPDATE trip_member
+ SET role = $3
+WHERE trip_id = $1 AND user_id = $2 AND role <> 'owner';
+
+-- name: RemoveTripMember :execrows
+DELETE FROM trip_member
+ WHERE trip_id = $1 AND user_id = $2 AND role <> 'owner';
+
+-- name: CreateTripInvitation :exec
+INSERT INTO trip_invitation (
+  id, trip_id, email, role, token, invited_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+);
+
+-- name: ListTripInvitations :many
+-- pending ones, tokens are only sent to the invitee
+SELECT i.id, i.email, i.role, i.invited_by, u.name AS invited_by_name, i.created_at
+FROM trip_invitation i
+LEFT JOIN users u ON u.id = i.invited_by
+WHERE i.trip_id = $1 AND i.status = 'pending'
+ORDER BY i.created_at;
+
+-- name: DeleteTripInvitation :execrows
+DELETE FROM trip_invitation
+ WHERE id = $1 AND trip_id = $2 AND status = 'pending';
+
+-- name: ListUserInvitations :many
+-- pending invitations sent to the email of the user, tokens are only
+-- sent by email
+SELECT i.id, i.trip_id, t.name AS trip_name, i.role,
+ u.name AS invited_by_name, i.created_at
+FROM trip_invitation i
+JOIN trip t ON t.id = i.trip_id
+JOIN users me ON lower(me.email) = lower(i.email)
+LEFT JOIN users u ON u.id = i.invited_by
+WHERE me.id = $1 AND i.status = 'pending' AND t.deleted_at IS NULL
+ORDER BY i.created_at DESC;
+
+-- name: RespondTripInvitation :one
+-- only the user with the invited email can answer
+UPDATE trip_invitation i
+ SET status = @status,
+ responded_at = now()
+FROM users me, trip t
+WHERE i.token = @token AND i.status = 'pending'
+ AND me.id = @user_id AND lower(me.email) = lower(i.email)
+ AND t.id = i.trip_id AND t.deleted_at IS NULL
+RETURNING i.trip_id, i.role;
+
+-- name: CreateTripShare :exec
+INSERT INTO trip_share (
+  id, trip_id, token, password_hash, expires_at, created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+);
+
+-- name: ListTripShares :many
+SELECT id, token, (password_hash IS NOT NULL)::bool AS has_password,
+ expires_at, view_count, last_viewed_at, created_at
+FROM trip_share
+WHERE trip_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteTripShare :execrows
+DELETE FROM trip_share
+ WHERE id = $1 AND trip_id = $2;
+
+-- name: GetSharedTrip :one
+-- expired links and trips in the trash aren't shown
+SELECT s.id AS share_id, s.password_hash, t.id AS trip_id, t.name, t.start_date, t.end_date,
+ d.name AS destination_name, d.description AS destination_description,
+ d.attraction AS destination_attraction, d.latitude, d.longitude, d.country, d.region, d.city
+FROM trip_share s
+JOIN trip t ON t.id = s.trip_id
+LEFT JOIN destination d ON d.id = t.destination_id AND d.deleted_at IS NULL
+WHERE s.token = $1 AND (s.expires_at IS NULL OR s.expires_at > now())
+ AND t.deleted_at IS NULL
+LIMIT 1;
+
+-- name: RecordTripShareView :exec
+UPDATE trip_share
+ SET view_count = view_count + 1,
+ last_viewed_at = now()
+WHERE id = $1;
+
+-- name: ListSharedTripStops :many
+-- stops of trips made from a wishlist, deleted destinations left out
+SELECT td.position, d.name, d.description, d.attraction,
+ d.latitude, d.longitude, d.country, d.region, d.city
+FROM trip_destination td
+JOIN destination d ON d.id = td.destination_id
+WHERE td.trip_id = $1 AND d.deleted_at IS NULL
+ORDER BY td.position;
+
+-- name: GetTripBudget :one
+SELECT budget, currency FROM trip
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
+
+-- name: UpdateTripBudget :execrows
+UPDATE trip SET budget = $2, currency = $3
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListTripExpenses :many
+SELECT e.id, e.amount, e.currency, e.category, e.paid_by, u.name AS payer_name,
+ e.spent_on, e.note, e.created_at
+FROM expense e
+LEFT JOIN users u ON u.id = e.paid_by
+WHERE e.trip_id = $1
+ORDER BY e.spent_on, e.created_at;
+
+-- name: CreateExpense :exec
+INSERT INTO expense (id, trip_id, amount, currency, category, paid_by, spent_on, note, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: UpdateExpense :execrows
+UPDATE expense
+SET amount = $3, currency = $4, category = $5, paid_by = $6, spent_on = $7, note = $8
+WHERE id = $1 AND trip_id = $2;
+
+-- name: DeleteExpense :execrows
+DELETE FROM expense
+WHERE id = $1 AND trip_id = $2;
+
+-- name: ExpenseTotalsByCategory :many
+SELECT currency, category, sum(amount)::int8 AS total FROM expense
+WHERE trip_id = $1
+GROUP BY currency, category;
+
+-- name: ExpenseTotalsByDay :many
+SELECT currency, spent_on, sum(amount)::int8 AS total FROM expense
+WHERE trip_id = $1
+GROUP BY currency, spent_on
+ORDER BY spent_on;
+
+-- name: ListExchangeRates :many
+SELECT base, quote, rate::text AS rate, updated_at FROM exchange_rate
+ORDER BY base, quote;
+
+-- name: SetExchangeRate :exec
+INSERT INTO exchange_rate (base, quote, rate)
+VALUES (sqlc.arg(base), sqlc.arg(quote), sqlc.arg(rate)::text::numeric)
+ON CONFLICT (base, quote) DO UPDATE
+SET rate = excluded.rate, updated_at = now();
+
+-- name: DeleteExchangeRate :execrows
+DELETE FROM exchange_rate
+WHERE base = $1 AND quote = $2;
+
+-- name: ListLedgerParticipants :many
+SELECT id, name, user_id, created_at FROM ledger_participant
+WHERE trip_id = $1
+ORDER BY name, id;
+
+-- name: CreateLedgerParticipant :exec
+INSERT INTO ledger_participant (id, trip_id, name, user_id)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteLedgerParticipant :execrows
+DELETE FROM ledger_participant
+WHERE id = $1 AND trip_id = $2;
+
+-- name: CreateLedgerPayment :exec
+INSERT INTO ledger_payment (id, trip_id, paid_by, amount, currency, description, split, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: CreateLedgerSplits :exec
+-- the arrays are zipped, one row per participant
+INSERT INTO ledger_split (payment_id, participant_id, shares, amount)
+SELECT @payment_id::uuid, s.participant_id, s.shares, s.amount
+FROM unnest(@participant_ids::uuid[], @shares::int4[], @amounts::int8[]) AS s(participant_id, shares, amount);
+
+-- name: ListLedgerPayments :many
+SELECT p.id, p.paid_by, pp.name AS payer_name, p.amount, p.currency, p.description,
+ p.split, p.created_at
+FROM ledger_payment p
+JOIN ledger_participant pp ON pp.id = p.paid_by
+WHERE p.trip_id = $1
+ORDER BY p.created_at, p.id;
+
+-- name: ListLedgerSplits :many
+SELECT s.payment_id, s.participant_id, pp.name, s.shares, s.amount
+FROM ledger_split s
+JOIN ledger_payment p ON p.id = s.payment_id
+JOIN ledger_participant pp ON pp.id = s.participant_id
+WHERE p.trip_id = $1
+ORDER BY pp.name, pp.id;
+
+-- name: DeleteLedgerPayment :execrows
+DELETE FROM ledger_payment
+WHERE id = $1 AND trip_id = $2;
+
+-- name: LedgerBalances :many
+-- what each participant paid and owes per currency, participants with
+-- no payments have a single row without a currency. Trip expenses paid
+-- by a linked participant are split equally between every linked
+-- participant, the first ones by name get the leftover minor units
+SELECT pp.id AS participant_id, pp.name, e.currency,
+ coalesce(sum(e.paid), 0)::int8 AS paid,
+ coalesce(sum(e.owed), 0)::int8 AS owed
+FROM ledger_participant pp
+LEFT JOIN (
+  SELECT paid_by AS participant_id, currency, amount AS paid, 0::int8 AS owed
+  FROM ledger_payment
+  WHERE trip_id = $1
+  UNION ALL
+  SELECT s.participant_id, p.currency, 0::int8, s.amount
+  FROM ledger_split s
+  JOIN ledger_payment p ON p.id = s.payment_id
+  WHERE p.trip_id = $1
+  UNION ALL
+  SELECT l.id, x.currency, x.amount, 0::int8
+  FROM expense x
+  JOIN ledger_participant l ON l.trip_id = x.trip_id AND l.user_id = x.paid_by
+  WHERE x.trip_id = $1
+  UNION ALL
+  SELECT l.id, x.currency, 0::int8,
+   x.amount / l.n + CASE WHEN l.rank <= x.amount % l.n THEN 1 ELSE 0 END
+  FROM expense x
+  JOIN (
+    SELECT id, row_number() OVER (ORDER BY name, id) AS rank, count(*) OVER () AS n
+    FROM ledger_participant
+    WHERE trip_id = $1 AND user_id IS NOT NULL
+  ) l ON true
+  WHERE x.trip_id = $1 AND EXISTS (
+    SELECT 1 FROM ledger_participant payer
+    WHERE payer.trip_id = x.trip_id AND payer.user_id = x.paid_by
+  )
+) e ON e.participant_id = pp.id
+WHERE pp.trip_id = $1
+GROUP BY pp.id, pp.name, e.currency
+ORDER BY pp.name, pp.id;
+
+-- name: LockTripDates :one
+-- held until the end of the transaction so activities are checked for
+-- overlaps one at a time
+SELECT start_date, end_date FROM trip
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: GetAttractionDestination :one
+SELECT a.destination_id FROM attraction a
+JOIN destination d ON d.id = a.destination_id
+WHERE a.id = $1 AND d.deleted_at IS NULL LIMIT 1;
+
+-- name: OverlappingActivity :one
+-- the first activity of the day sharing some time with the given one,
+-- other than itself
+SELECT id, title FROM activity
+WHERE trip_id = @trip_id AND day = @day
+ AND start_time < @end_time AND end_time > @start_time
+ AND id <> @id
+ORDER BY start_time
+LIMIT 1;
+
+-- name: CreateActivity :exec
+INSERT INTO activity (
+  id, trip_id, title, category, day, start_time, end_time,
+  location, destination_id, attraction_id, notes
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+);
+
+-- name: UpdateActivity :execrows
+UPDATE activity
+ SET title = $3,
+ category = $4,
+ day = $5,
+ start_time = $6,
+ end_time = $7,
+ location = $8,
+ destination_id = $9,
+ attraction_id = $10,
+ notes = $11
+WHERE id = $1 AND trip_id = $2;
+
+-- name: DeleteActivity :execrows
+DELETE FROM activity
+WHERE id = $1 AND trip_id = $2;
+
+-- name: ListTripActivities :many
+SELECT a.id, a.title, a.category, a.day,
+ to_char(a.start_time, 'HH24:MI') AS start_time,
+ to_char(a.end_time, 'HH24:MI') AS end_time,
+ a.location, a.destination_id, d.name AS destination_name,
+ a.attraction_id, at.name AS attraction_name, a.notes
+FROM activity a
+LEFT JOIN destination d ON d.id = a.destination_id AND d.deleted_at IS NULL
+LEFT JOIN attraction at ON at.id = a.attraction_id
+WHERE a.trip_id = $1
+ORDER BY a.day, a.start_time, a.id;
+
+-- name: ListTripBookings :many
+SELECT * FROM booking
+WHERE trip_id = $1
+ORDER BY starts_at, id;
+
+-- name: CreateBooking :exec
+INSERT INTO booking (
+  id, trip_id, kind, title, provider, confirmation_code, starts_at, start_zone,
+  ends_at, end_zone, address, cost, currency, notes, created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+);
+
+-- name: UpdateBooking :execrows
+UPDATE booking
+ SET kind = $3,
+ title = $4,
+ provider = $5,
+ confirmation_code = $6,
+ starts_at = $7,
+ start_zone = $8,
+ ends_at = $9,
+ end_zone = $10,
+ address = $11,
+ cost = $12,
+ currency = $13,
+ notes = $14,
+ updated_at = now(),
+ sequence = sequence + 1
+WHERE id = $1 AND trip_id = $2;
+
+-- name: DeleteBookingAttachments :many
+-- attachment rows go first so their files can be removed from the store
+DELETE FROM booking_attachment a
+USING booking b
+WHERE a.booking_id = b.id AND b.id = $1 AND b.trip_id = $2
+RETURNING a.key;
+
+-- name: DeleteBooking :execrows
+DELETE FROM booking
+WHERE id = $1 AND trip_id = $2;
+
+-- name: ListTripBookingAttachments :many
+SELECT a.id, a.booking_id, a.key, a.filename, a.content_type, a.size, a.created_at
+FROM booking_attachment a
+JOIN booking b ON b.id = a.booking_id
+WHERE b.trip_id = $1
+ORDER BY a.created_at, a.id;
+
+-- name: CreateBookingAttachment :execrows
+-- nothing is inserted if the booking isn't one of the trip's
+INSERT INTO booking_attachment (id, booking_id, key, filename, content_type, size)
+SELECT @id::uuid, b.id, @key::text, @filename::text, @content_type::text, @size::bigint
+FROM booking b
+WHERE b.id = @booking_id AND b.trip_id = @trip_id;
+
+-- name: DeleteBookingAttachment :one
+DELETE FROM booking_attachment a
+USING booking b
+WHERE a.booking_id = b.id AND a.id = sqlc.arg(id) AND b.id = sqlc.arg(booking_id)
+ AND b.trip_id = sqlc.arg(trip_id)
+RETURNING a.key;
+
+-- name: DeletePurgedBookingAttachments :many
+-- attachments of the trips PurgeTrips is about to delete
+DELETE FROM booking_attachment a
+USING booking b, trip t
+WHERE a.booking_id = b.id AND b.trip_id = t.id AND t.deleted_at < $1
+RETURNING a.key;
+
+-- name: ListTripBookingEvents :many
+SELECT id, kind, title, provider, confirmation_code, starts_at, ends_at,
+ address, updated_at, sequence
+FROM booking
+WHERE trip_id = $1
+ORDER BY starts_at, id;
+
+-- name: ListBookingEvents :many
+-- bookings of every trip the user is a member of
+SELECT b.id, b.kind, b.title, b.provider, b.confirmation_code, b.starts_at, b.ends_at,
+ b.address, b.updated_at, b.sequence
+FROM booking b
+JOIN trip t ON t.id = b.trip_id
+JOIN trip_member m ON m.trip_id = t.id
+WHERE m.user_id = $1 AND t.deleted_at IS NULL
+ORDER BY b.starts_at, b.id;
+
+-- name: ListChecklistTemplates :many
+-- templates provided by admins and those of the user
+SELECT t.id, t.user_id, t.name, t.description, t.min_days, t.max_days, t.tag_id,
+ t.created_at, count(i.id) AS item_count
+FROM checklist_template t
+LEFT JOIN checklist_template_item i ON i.template_id = t.id
+WHERE t.user_id IS NULL OR t.user_id = $1
+GROUP BY t.id
+ORDER BY t.user_id NULLS FIRST, t.name, t.id;
+
+-- name: SuggestChecklistTemplates :many
+-- templates whose length and tag fit the trip, the most specific first,
+-- days is NULL if the trip's dates can't be read
+SELECT t.id, t.user_id, t.name, t.description, t.min_days, t.max_days, t.tag_id,
+ t.created_at, count(i.id) AS item_count
+FROM checklist_template t
+LEFT JOIN checklist_template_item i ON i.template_id = t.id
+WHERE (t.user_id IS NULL OR t.user_id = @user_id)
+ AND (t.min_days IS NULL OR t.min_days <= sqlc.narg(days)::integer)
+ AND (t.max_days IS NULL OR t.max_days >= sqlc.narg(days)::integer)
+ AND (t.tag_id IS NULL OR t.tag_id IN (
+   SELECT dt.tag_id FROM destination_tag dt
+   WHERE dt.destination_id IN (
+     SELECT tr.destination_id FROM trip tr WHERE tr.id = @trip_id
+     UNION
+     SELECT td.destination_id FROM trip_destination td WHERE td.trip_id = @trip_id
+   )
+ ))
+GROUP BY t.id
+ORDER BY (t.tag_id IS NOT NULL) DESC,
+ (t.min_days IS NOT NULL OR t.max_days IS NOT NULL) DESC, t.name, t.id;
+
+-- name: GetChecklistTemplate :one
+SELECT * FROM checklist_template
+WHERE id = $1 AND (user_id IS NULL OR user_id = $2);
+
+-- name: ListChecklistTemplateItems :many
+SELECT * FROM checklist_template_item
+WHERE template_id = $1
+ORDER BY position;
+
+-- name: CreateChecklistTemplate :exec
+INSERT INTO checklist_template (
+  id, user_id, name, description, min_days, max_days, tag_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: UpdateChecklistTemplate :execrows
+-- users change their own templates, admins the provided ones
+UPDATE checklist_template
+ SET name = @name,
+ description = @description,
+ min_days = @min_days,
+ max_days = @max_days,
+ tag_id = @tag_id
+WHERE id = @id AND (user_id = @user_id OR (@admin::boolean AND user_id IS NULL));
+
+-- name: DeleteChecklistTemplate :execrows
+DELETE FROM checklist_template
+WHERE id = @id AND (user_id = @user_id OR (@admin::boolean AND user_id IS NULL));
+
+-- name: DeleteChecklistTemplateItems :exec
+DELETE FROM checklist_template_item
+WHERE template_id = $1;
+
+-- name: CreateChecklistTemplateItems :exec
+-- the arrays are zipped, positions follow their order
+INSERT INTO checklist_template_item (id, template_id, title, position)
+SELECT o.id, @template_id::uuid, o.title, (o.position - 1)::integer
+FROM unnest(@ids::uuid[], @titles::text[]) WITH ORDINALITY AS o(id, title, position);
+
+-- name: ListTripChecklists :many
+SELECT * FROM checklist
+WHERE trip_id = $1
+ORDER BY created_at, id;
+
+-- name: ListTripChecklistItems :many
+SELECT i.id, i.checklist_id, i.title, i.assignee_id, u.name AS assignee_name,
+ i.done, i.done_at, i.position, i.created_at
+FROM checklist_item i
+JOIN checklist c ON c.id = i.checklist_id
+LEFT JOIN users u ON u.id = i.assignee_id
+WHERE c.trip_id = $1
+ORDER BY i.position, i.id;
+
+-- name: CreateChecklist :exec
+INSERT INTO checklist (
+  id, trip_id, name, template_id
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: CreateChecklistItems :exec
+-- the arrays are zipped, positions follow their order
+INSERT INTO checklist_item (id, checklist_id, title, position)
+SELECT o.id, @checklist_id::uuid, o.title, (o.position - 1)::integer
+FROM unnest(@ids::uuid[], @titles::text[]) WITH ORDINALITY AS o(id, title, position);
+
+-- name: RenameChecklist :execrows
+UPDATE checklist
+ SET name = $3
+WHERE id = $1 AND trip_id = $2;
+
+-- name: DeleteChecklist :execrows
+DELETE FROM checklist
+WHERE id = $1 AND trip_id = $2;
+
+-- name: CreateChecklistItem :execrows
+-- appended to the end, nothing is inserted if the checklist isn't one of
+-- the trip's
+INSERT INTO checklist_item (id, checklist_id, title, assignee_id, position)
+SELECT @id::uuid, c.id, @title::text, @assignee_id::uuid,
+ coalesce((SELECT max(i.position) + 1 FROM checklist_item i WHERE i.checklist_id = c.id), 0)
+FROM checklist c
+WHERE c.id = @checklist_id AND c.trip_id = @trip_id;
+
+-- name: UpdateChecklistItem :execrows
+-- done_at is kept while the item stays done
+UPDATE checklist_item i
+ SET title = @title,
+ assignee_id = @assignee_id,
+ done = @done,
+ done_at = CASE WHEN @done::boolean THEN coalesce(i.done_at, now()) END
+FROM checklist c
+WHERE i.checklist_id = c.id AND i.id = @id AND c.id = @checklist_id AND c.trip_id = @trip_id;
+
+-- name: DeleteChecklistItem :execrows
+DELETE FROM checklist_item i
+USING checklist c
+WHERE i.checklist_id = c.id AND i.id = $1 AND c.id = $2 AND c.trip_id = $3;
@@ -0,0 +1,414 @@
+// Package reservation extracts bookings from the schema.org JSON-LD
+// markup airlines, hotels and other providers embed in confirmation emails
+package reservation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoMarkup = errors.New("reservation: no JSON-LD found")
+	ErrNoStart  = errors.New("reservation: no start time")
+)
+
+// JSON-LD blocks of an HTML document
+var scriptPattern = regexp.MustCompile(`(?is)<script[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// Time is a time as written in a reservation, Wall holds the date and
+// clock time in UTC. Zone is "UTC", an offset such as "-08:00", or empty
+// if the reservation didn't say
+type Time struct {
+	Wall time.Time
+	Zone string
+}
+
+// IsZero reports whether the time is missing
+func (t Time) IsZero() bool {
+	return t.Wall.IsZero()
+}
+
+// Booking is a reservation found in the markup, Kind is one of flight,
+// lodging, car, train, bus, restaurant, event or other
+type Booking struct {
+	Kind             string
+	Title            string
+	Provider         string
+	ConfirmationCode string
+	Start            Time
+	End              Time
+	Address          string
+	// decimal as written, e.g. "119.00", empty if not given
+	Cost     string
+	Currency string
+}
+
+// kind of booking of each reservation type
+var kinds = map[string]string{
+	"FlightReservation":            "flight",
+	"LodgingReservation":           "lodging",
+	"RentalCarReservation":         "car",
+	"TrainReservation":             "train",
+	"BusReservation":               "bus",
+	"FoodEstablishmentReservation": "restaurant",
+	"EventReservation":             "event",
+	"Reservation":                  "other",
+}
+
+// Parse returns the reservations in a pasted email, either its HTML with
+// JSON-LD script tags or the JSON-LD itself. Reservations without a start
+// time are skipped, an error is returned only if none could be read
+func Parse(input string) ([]Booking, error) {
+	var blocks []string
+	for _, match := range scriptPattern.FindAllStringSubmatch(input, -1) {
+		blocks = append(blocks, match[1])
+	}
+
+	if trimmed := strings.TrimSpace(input); len(blocks) == 0 &&
+		(strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+		blocks = append(blocks, trimmed)
+	}
+
+	if len(blocks) == 0 {
+		return nil, ErrNoMarkup
+	}
+
+	var bookings []Booking
+	var lastErr error
+	for _, block := range blocks {
+		var doc any
+		if err := json.Unmarshal([]byte(block), &doc); err != nil {
+			lastErr = fmt.Errorf("reservation: invalid JSON-LD: %w", err)
+			continue
+		}
+
+		for _, node := range nodes(doc) {
+			kind, ok := kinds[typeOf(node)]
+			if !ok {
+				continue
+			}
+
+			booking, err := parseReservation(kind, node)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			bookings = append(bookings, booking)
+		}
+	}
+
+	if len(bookings) == 0 {
+		if lastErr == nil {
+			lastErr = ErrNoMarkup
+		}
+		return nil, lastErr
+	}
+
+	return bookings, nil
+}
+
+// nodes flattens arrays and @graph lists into their objects
+func nodes(v any) []map[string]any {
+	switch v := v.(type) {
+	case []any:
+		var out []map[string]any
+		for _, item := range v {
+			out = append(out, nodes(item)...)
+		}
+		return out
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			return nodes(graph)
+		}
+		return []map[string]any{v}
+	}
+	return nil
+}
+
+// typeOf returns the schema.org type of a node without its URL prefix
+func typeOf(node map[string]any) string {
+	t := node["@type"]
+	if types, ok := t.([]any); ok && len(types) > 0 {
+		t = types[0]
+	}
+
+	s, _ := t.(string)
+	return s[strings.LastIndex(s, "/")+1:]
+}
+
+// object returns a nested node, the first one of a list
+func object(node map[string]any, key string) map[string]any {
+	switch v := node[key].(type) {
+	case map[string]any:
+		return v
+	case []any:
+		if len(v) > 0 {
+			m, _ := v[0].(map[string]any)
+			return m
+		}
+	}
+	return nil
+}
+
+// text returns a property as text, numbers included, or the name of a
+// nested node
+func text(node map[string]any, key string) string {
+	switch v := node[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		return text(v, "name")
+	case []any:
+		if len(v) > 0 {
+			return text(map[string]any{key: v[0]}, key)
+		}
+	}
+	return ""
+}
+
+// first returns the first non empty text of the keys
+func first(node map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s := text(node, key); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// address formats a PostalAddress or returns a plain text address
+func address(node map[string]any) string {
+	if node == nil {
+		return ""
+	}
+
+	addr := object(node, "address")
+	if addr == nil {
+		return text(node, "address")
+	}
+
+	var parts []string
+	for _, key := range []string{"streetAddress", "addressLocality", "addressRegion", "postalCode", "addressCountry"} {
+		if s := text(addr, key); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dateTime layouts of ISO 8601 values, with and without an offset
+var (
+	zonedLayouts    = []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00"}
+	floatingLayouts = []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+)
+
+// parseTime reads an ISO 8601 date or date and time, ok is false if the
+// value is none of them
+func parseTime(value string) (t Time, dateOnly bool, ok bool) {
+	for _, layout := range zonedLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			zone := parsed.Format("-07:00")
+			if strings.HasSuffix(value, "Z") || strings.HasSuffix(value, "z") {
+				zone = "UTC"
+			}
+
+			wall := time.Date(parsed.Year(), parsed.Month(), parsed.Day(),
+				parsed.Hour(), parsed.Minute(), parsed.Second(), 0, time.UTC)
+			return Time{wall, zone}, false, true
+		}
+	}
+
+	for _, layout := range floatingLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return Time{Wall: parsed.Truncate(time.Second)}, false, true
+		}
+	}
+
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return Time{Wall: parsed}, true, true
+	}
+
+	return t, false, false
+}
+
+// moment reads a time from the first key holding one, a date alone is
+// completed by the clock time of timeKey if set, such as the checkinTime
+// of a hotel
+func moment(node map[string]any, timeKey string, keys ...string) Time {
+	for _, key := range keys {
+		t, dateOnly, ok := parseTime(text(node, key))
+		if !ok {
+			continue
+		}
+
+		if !dateOnly || timeKey == "" {
+			return t
+		}
+
+		// a full date and time or only a clock time
+		value := text(node, timeKey)
+		if clock, _, ok := parseTime(value); ok {
+			if clock.Wall.Format(time.DateOnly) == t.Wall.Format(time.DateOnly) {
+				return clock
+			}
+		}
+
+		for _, layout := range []string{"15:04:05Z07:00", "15:04Z07:00", "15:04:05", "15:04"} {
+			clock, err := time.Parse(layout, value)
+			if err != nil {
+				continue
+			}
+
+			t.Wall = t.Wall.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute +
+				time.Duration(clock.Second())*time.Second)
+			if strings.Contains(layout, "Z07") {
+				t.Zone = clock.Format("-07:00")
+				if strings.HasSuffix(value, "Z") {
+					t.Zone = "UTC"
+				}
+			}
+			break
+		}
+
+		return t
+	}
+
+	return Time{}
+}
+
+// price reads the total price and currency of a reservation
+func price(node map[string]any) (cost, currency string) {
+	currency = text(node, "priceCurrency")
+
+	if spec := object(node, "totalPrice"); spec != nil {
+		cost = text(spec, "price")
+		if c := text(spec, "priceCurrency"); c != "" {
+			currency = c
+		}
+		return cost, currency
+	}
+
+	return first(node, "totalPrice", "price"), currency
+}
+
+// parseReservation reads a reservation of the given kind
+func parseReservation(kind string, node map[string]any) (Booking, error) {
+	booking := Booking{
+		Kind:             kind,
+		ConfirmationCode: first(node, "reservationNumber", "reservationId"),
+		Provider:         first(node, "provider", "broker"),
+	}
+	booking.Cost, booking.Currency = price(node)
+
+	item := object(node, "reservationFor")
+	if item == nil {
+		item = map[string]any{}
+	}
+
+	switch kind {
+	case "flight":
+		airline := object(item, "airline")
+		if booking.Provider == "" {
+			booking.Provider = first(item, "airline", "provider")
+		}
+
+		number := text(item, "flightNumber")
+		if airline != nil && text(airline, "iataCode") != "" && !strings.HasPrefix(number, text(airline, "iataCode")) {
+			number = text(airline, "iataCode") + " " + number
+		}
+
+		from, to := object(item, "departureAirport"), object(item, "arrivalAirport")
+		booking.Title = strings.TrimSpace(fmt.Sprintf("%s %s → %s", number, place(from), place(to)))
+		booking.Start = moment(item, "", "departureTime")
+		booking.End = moment(item, "", "arrivalTime")
+		booking.Address = place(from)
+	case "train", "bus":
+		stop := "Station"
+		if kind == "bus" {
+			stop = "BusStop"
+		}
+		if booking.Provider == "" {
+			booking.Provider = text(item, "provider")
+		}
+
+		from, to := object(item, "departure"+stop), object(item, "arrival"+stop)
+		name := first(item, kind+"Name", kind+"Number")
+		booking.Title = strings.TrimSpace(fmt.Sprintf("%s %s → %s", name, text(from, "name"), text(to, "name")))
+		booking.Start = moment(item, "", "departureTime")
+		booking.End = moment(item, "", "arrivalTime")
+		booking.Address = firstNonEmpty(address(from), text(from, "name"))
+	case "lodging":
+		booking.Title = text(item, "name")
+		if booking.Provider == "" {
+			booking.Provider = booking.Title
+		}
+		booking.Start = moment(node, "checkinTime", "checkinDate", "checkinTime")
+		booking.End = moment(node, "checkoutTime", "checkoutDate", "checkoutTime")
+		booking.Address = address(item)
+	case "car":
+		if booking.Provider == "" {
+			booking.Provider = first(item, "rentalCompany", "brand")
+		}
+		pickup := object(node, "pickupLocation")
+		booking.Title = strings.TrimSpace(first(item, "name", "model") + " " + text(pickup, "name"))
+		booking.Start = moment(node, "", "pickupTime")
+		booking.End = moment(node, "", "dropoffTime")
+		booking.Address = firstNonEmpty(address(pickup), text(pickup, "name"))
+	case "restaurant":
+		booking.Title = text(item, "name")
+		if booking.Provider == "" {
+			booking.Provider = booking.Title
+		}
+		booking.Start = moment(node, "", "startTime")
+		booking.End = moment(node, "", "endTime")
+		booking.Address = address(item)
+	default:
+		booking.Title = text(item, "name")
+		location := object(item, "location")
+		booking.Start = moment(item, "", "startDate", "startTime")
+		if booking.Start.IsZero() {
+			booking.Start = moment(node, "", "startTime", "startDate")
+		}
+		booking.End = moment(item, "", "endDate", "endTime")
+		if booking.End.IsZero() {
+			booking.End = moment(node, "", "endTime", "endDate")
+		}
+		booking.Address = firstNonEmpty(address(location), text(location, "name"))
+	}
+
+	if booking.Start.IsZero() {
+		return booking, ErrNoStart
+	}
+
+	// ends before the start are typos, dropped rather than trusted
+	if !booking.End.IsZero() && booking.End.Zone == booking.Start.Zone && booking.End.Wall.Before(booking.Start.Wall) {
+		booking.End = Time{}
+	}
+
+	return booking, nil
+}
+
+// place names an airport by its IATA code if it has one
+func place(node map[string]any) string {
+	if node == nil {
+		return ""
+	}
+	return first(node, "iataCode", "name")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,319 @@
+package reservation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a Time whose wall clock is the given date and time
+func at(zone string, year int, month time.Month, day, hour, min int) Time {
+	return Time{time.Date(year, month, day, hour, min, 0, 0, time.UTC), zone}
+}
+
+// parseOne parses input and expects a single booking
+func parseOne(t *testing.T, input string) Booking {
+	t.Helper()
+
+	bookings, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookings) != 1 {
+		t.Fatalf("got %d bookings, want 1: %+v", len(bookings), bookings)
+	}
+	return bookings[0]
+}
+
+// flight is the markup of a Gmail flight confirmation
+const flight = `<html><body>
+<p>Your flight is confirmed.</p>
+<script type="application/ld+json">
+{
+  "@context": "http://schema.org",
+  "@type": "FlightReservation",
+  "reservationNumber": "RXJ34P",
+  "reservationStatus": "http://schema.org/ReservationConfirmed",
+  "underName": {
+    "@type": "Person",
+    "name": "Eva Green"
+  },
+  "reservationFor": {
+    "@type": "Flight",
+    "flightNumber": "110",
+    "airline": {
+      "@type": "Airline",
+      "name": "United",
+      "iataCode": "UA"
+    },
+    "departureAirport": {
+      "@type": "Airport",
+      "name": "San Francisco Airport",
+      "iataCode": "SFO"
+    },
+    "departureTime": "2027-03-04T20:15:00-08:00",
+    "arrivalAirport": {
+      "@type": "Airport",
+      "name": "John F. Kennedy International Airport",
+      "iataCode": "JFK"
+    },
+    "arrivalTime": "2027-03-05T06:30:00-05:00"
+  },
+  "totalPrice": {
+    "@type": "PriceSpecification",
+    "price": "327.40",
+    "priceCurrency": "USD"
+  }
+}
+</script>
+</body></html>`
+
+func TestParseFlight(t *testing.T) {
+	got := parseOne(t, flight)
+
+	want := Booking{
+		Kind:             "flight",
+		Title:            "UA 110 SFO → JFK",
+		Provider:         "United",
+		ConfirmationCode: "RXJ34P",
+		// ends "before" it starts in wall time, but in another zone
+		Start:    at("-08:00", 2027, 3, 4, 20, 15),
+		End:      at("-05:00", 2027, 3, 5, 6, 30),
+		Address:  "SFO",
+		Cost:     "327.40",
+		Currency: "USD",
+	}
+	if got != want {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+// lodging is the markup of a hotel confirmation, %s are the check in and
+// check out properties
+const lodging = `{
+  "@context": "https://schema.org",
+  "@type": "LodgingReservation",
+  "reservationId": "abc456",
+  "reservationFor": {
+    "@type": "LodgingBusiness",
+    "name": "Hilton San Francisco Union Square",
+    "address": {
+      "@type": "PostalAddress",
+      "streetAddress": "333 O'Farrell St",
+      "addressLocality": "San Francisco",
+      "addressRegion": "CA",
+      "postalCode": "94102",
+      "addressCountry": "US"
+    },
+    "telephone": "415-771-1400"
+  },
+  "totalPrice": 239,
+  "priceCurrency": "USD",
+  %s
+}`
+
+func TestParseLodging(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties string
+		start, end Time
+	}{
+		{
+			"dates and times",
+			`"checkinDate": "2027-04-11T16:00:00-08:00", "checkoutDate": "2027-04-13T11:00:00-08:00"`,
+			at("-08:00", 2027, 4, 11, 16, 0), at("-08:00", 2027, 4, 13, 11, 0),
+		},
+		{
+			"dates with clock checkinTime",
+			`"checkinDate": "2027-04-11", "checkinTime": "15:00:00-08:00",
+			 "checkoutDate": "2027-04-13", "checkoutTime": "11:00"`,
+			at("-08:00", 2027, 4, 11, 15, 0), at("", 2027, 4, 13, 11, 0),
+		},
+		{
+			"dates with full checkinTime",
+			`"checkinDate": "2027-04-11", "checkinTime": "2027-04-11T14:00:00Z",
+			 "checkoutDate": "2027-04-13", "checkoutTime": "2027-04-13T10:00:00Z"`,
+			at("UTC", 2027, 4, 11, 14, 0), at("UTC", 2027, 4, 13, 10, 0),
+		},
+		{
+			"checkinTime of another day ignored",
+			`"checkinDate": "2027-04-11", "checkinTime": "2027-04-12T14:00:00Z", "checkoutDate": "2027-04-13"`,
+			at("", 2027, 4, 11, 0, 0), at("", 2027, 4, 13, 0, 0),
+		},
+		{
+			"checkinTime only",
+			`"checkinTime": "2027-04-11T15:00:00+02:00", "checkoutTime": "2027-04-13T11:00:00+02:00"`,
+			at("+02:00", 2027, 4, 11, 15, 0), at("+02:00", 2027, 4, 13, 11, 0),
+		},
+		{
+			"dates only",
+			`"checkinDate": "2027-04-11", "checkoutDate": "2027-04-13"`,
+			at("", 2027, 4, 11, 0, 0), at("", 2027, 4, 13, 0, 0),
+		},
+		{
+			"checkout before checkin dropped",
+			`"checkinDate": "2027-04-11", "checkoutDate": "2027-04-01"`,
+			at("", 2027, 4, 11, 0, 0), Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		got := parseOne(t, strings.Replace(lodging, "%s", tt.properties, 1))
+
+		want := Booking{
+			Kind:             "lodging",
+			Title:            "Hilton San Francisco Union Square",
+			Provider:         "Hilton San Francisco Union Square",
+			ConfirmationCode: "abc456",
+			Start:            tt.start,
+			End:              tt.end,
+			Address:          "333 O'Farrell St, San Francisco, CA, 94102, US",
+			Cost:             "239",
+			Currency:         "USD",
+		}
+		if got != want {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", tt.name, got, want)
+		}
+	}
+}
+
+func TestParseGraph(t *testing.T) {
+	bookings, err := Parse(`{
+  "@context": "https://schema.org",
+  "@graph": [
+    {
+      "@type": "Organization",
+      "name": "Eurostar"
+    },
+    {
+      "@type": "https://schema.org/TrainReservation",
+      "reservationNumber": "QWERTY",
+      "reservationFor": {
+        "@type": "TrainTrip",
+        "provider": {"@type": "Organization", "name": "Eurostar"},
+        "trainNumber": "9014",
+        "departureStation": {"@type": "TrainStation", "name": "London St Pancras"},
+        "departureTime": "2027-06-01T08:01:00+01:00",
+        "arrivalStation": {"@type": "TrainStation", "name": "Paris Nord"},
+        "arrivalTime": "2027-06-01T11:20:00+02:00"
+      }
+    },
+    {
+      "@type": ["FoodEstablishmentReservation"],
+      "reservationNumber": "T-42",
+      "reservationFor": {
+        "@type": "FoodEstablishment",
+        "name": "Le Train Bleu",
+        "address": "Place Louis-Armand, 75012 Paris"
+      },
+      "startTime": "2027-06-01T13:00:00+02:00"
+    }
+  ]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Booking{
+		{
+			Kind:             "train",
+			Title:            "9014 London St Pancras → Paris Nord",
+			Provider:         "Eurostar",
+			ConfirmationCode: "QWERTY",
+			Start:            at("+01:00", 2027, 6, 1, 8, 1),
+			End:              at("+02:00", 2027, 6, 1, 11, 20),
+			Address:          "London St Pancras",
+		},
+		{
+			Kind:             "restaurant",
+			Title:            "Le Train Bleu",
+			Provider:         "Le Train Bleu",
+			ConfirmationCode: "T-42",
+			Start:            at("+02:00", 2027, 6, 1, 13, 0),
+			Address:          "Place Louis-Armand, 75012 Paris",
+		},
+	}
+	if len(bookings) != len(want) {
+		t.Fatalf("got %d bookings, want %d: %+v", len(bookings), len(want), bookings)
+	}
+	for i := range want {
+		if bookings[i] != want[i] {
+			t.Errorf("booking %d:\ngot  %+v\nwant %+v", i, bookings[i], want[i])
+		}
+	}
+}
+
+func TestParseFloating(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Time
+	}{
+		{"2027-05-01T09:30:00", at("", 2027, 5, 1, 9, 30)},
+		{"2027-05-01T09:30:00.250", at("", 2027, 5, 1, 9, 30)},
+		{"2027-05-01T09:30", at("", 2027, 5, 1, 9, 30)},
+		{"2027-05-01 09:30:00", at("", 2027, 5, 1, 9, 30)},
+		{"2027-05-01 09:30", at("", 2027, 5, 1, 9, 30)},
+		{"2027-05-01", at("", 2027, 5, 1, 0, 0)},
+		{"2027-05-01T09:30Z", at("UTC", 2027, 5, 1, 9, 30)},
+		{"2027-05-01T09:30:00+05:30", at("+05:30", 2027, 5, 1, 9, 30)},
+	}
+
+	for _, tt := range tests {
+		got := parseOne(t, `{
+  "@context": "https://schema.org",
+  "@type": "RentalCarReservation",
+  "reservationNumber": "C1",
+  "reservationFor": {"@type": "Car", "name": "Fiat 500", "rentalCompany": {"@type": "Organization", "name": "Hertz"}},
+  "pickupLocation": {"@type": "Place", "name": "Lisbon Airport"},
+  "pickupTime": "`+tt.value+`"
+}`)
+
+		if got.Start != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.value, got.Start, tt.want)
+		}
+		if got.Title != "Fiat 500 Lisbon Airport" || got.Provider != "Hertz" || got.Address != "Lisbon Airport" {
+			t.Errorf("%s: got %+v", tt.value, got)
+		}
+	}
+}
+
+func TestParseMultipleScripts(t *testing.T) {
+	bookings, err := Parse(flight + `
+<script type='application/ld+json'>[
+  {"@type": "EventReservation", "reservationFor": {"@type": "Event", "name": "Fado night", "startDate": "2027-03-06T21:00"}},
+  {"@type": "EventReservation", "reservationFor": {"@type": "Event", "name": "No date"}}
+]</script>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the event without a start is skipped
+	if len(bookings) != 2 || bookings[0].Kind != "flight" || bookings[1].Title != "Fado night" {
+		t.Errorf("got %+v", bookings)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"no markup", "<p>Thanks for booking</p>", ErrNoMarkup},
+		{"no reservations", `{"@type": "Organization", "name": "United"}`, ErrNoMarkup},
+		{"no start", `{"@type": "LodgingReservation", "reservationFor": {"name": "Hotel"}}`, ErrNoStart},
+		{"bad start", `{"@type": "FlightReservation", "reservationFor": {"departureTime": "soon"}}`, ErrNoStart},
+	}
+
+	for _, tt := range tests {
+		if _, err := Parse(tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := Parse(`<script type="application/ld+json">{"@type": </script>`); err == nil ||
+		!strings.Contains(err.Error(), "invalid JSON-LD") {
+		t.Errorf("got %v, want an invalid JSON-LD error", err)
+	}
+}
@@ -0,0 +1,440 @@
+package routes
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var (
+	fiberInvalidActivityID       = &fiber.Map{"error": "invalid activity id"}
+	fiberInvalidActivityCategory = &fiber.Map{"error": "category must be transport, meal, sight, lodging, activity or other"}
+	fiberInvalidActivityDay      = &fiber.Map{"error": "day must be a date in the format 2006-01-02"}
+	fiberInvalidActivityTime     = &fiber.Map{"error": "start_time and end_time must be times in the format 15:04, end_time after start_time on the same day"}
+	fiberActivityOutsideTrip     = &fiber.Map{"error": "day must be between the start and end dates of the trip"}
+	fiberAttractionElsewhere     = &fiber.Map{"error": "attraction isn't at the destination"}
+)
+
+const (
+	// layout of activity times
+	activityTimeLayout = "15:04"
+	// longest trip whose empty days are listed in the itinerary
+	maxItineraryDays = 366
+)
+
+var activityCategories = []string{"transport", "meal", "sight", "lodging", "activity", "other"}
+
+// itineraryDay is a day of a trip with its activities and the bookings
+// starting on it in order
+type itineraryDay struct {
+	Date       string
+	Activities []db.ListTripActivitiesRow
+	Bookings   []booking
+}
+
+// parseActivityTime reads a time of day
+func parseActivityTime(value string) (pgtype.Time, bool) {
+	t, err := time.Parse(activityTimeLayout, value)
+	if err != nil {
+		return pgtype.Time{}, false
+	}
+
+	minutes := int64(t.Hour()*60 + t.Minute())
+	return pgtype.Time{Microseconds: minutes * int64(time.Minute/time.Microsecond), Valid: true}, true
+}
+
+// parseActivity reads and validates the activity form values
+// returns the error to respond with if they are invalid
+func parseActivity(c *fiber.Ctx) (activity db.Activity, fiberErr *fiber.Map) {
+	activity.Title = c.FormValue("title")
+	activity.Category = c.FormValue("category")
+	day := c.FormValue("day")
+	startTime := c.FormValue("start_time")
+	endTime := c.FormValue("end_time")
+	activity.Location = c.FormValue("location")
+	activity.Notes = c.FormValue("notes")
+
+	// if any of the form data is missing return error
+	if activity.Title == "" || activity.Category == "" || day == "" || startTime == "" || endTime == "" {
+		return activity, fiberUndefinedParamError
+	}
+
+	if !slices.Contains(activityCategories, activity.Category) {
+		return activity, fiberInvalidActivityCategory
+	}
+
+	date, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return activity, fiberInvalidActivityDay
+	}
+	activity.Day = pgtype.Date{Time: date, Valid: true}
+
+	var startOk, endOk bool
+	activity.StartTime, startOk = parseActivityTime(startTime)
+	activity.EndTime, endOk = parseActivityTime(endTime)
+	if !startOk || !endOk || activity.EndTime.Microseconds <= activity.StartTime.Microseconds {
+		return activity, fiberInvalidActivityTime
+	}
+
+	// optional
+	if value := c.FormValue("destination_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return activity, fiberInvalidDestinationID
+		}
+		activity.DestinationID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	// optional, its destination is used if destination_id isn't set
+	if value := c.FormValue("attraction_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return activity, fiberInvalidAttractionID
+		}
+		activity.AttractionID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	return activity, nil
+}
+
+// activityPlace checks the destination and attraction of an activity,
+// filling in the destination from the attraction
+// writes the error response itself if ok is false
+func (r *Repo) activityPlace(c *fiber.Ctx, activity *db.Activity) (ok bool, err error) {
+	if activity.DestinationID.Valid {
+		if ok, err := r.destinationExists(c, activity.DestinationID); !ok {
+			return false, err
+		}
+	}
+
+	if !activity.AttractionID.Valid {
+		return true, nil
+	}
+
+	destination, err := r.Queries.GetAttractionDestination(r.Ctx, activity.AttractionID)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAttractionID)
+		}
+
+		logging.FromCtx(c).Error("error in getting attraction in GetAttractionDestination db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if activity.DestinationID.Valid && activity.DestinationID != destination {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiberAttractionElsewhere)
+	}
+
+	activity.DestinationID = destination
+	return true, nil
+}
+
+// checkActivity checks that an activity falls within the dates of its trip
+// and doesn't overlap another one, the trip stays locked until the
+// transaction of qtx ends
+// writes the error response itself if ok is false
+func (r *Repo) checkActivity(c *fiber.Ctx, qtx *db.Queries, activity db.Activity) (ok bool, err error) {
+	trip, err := qtx.LockTripDates(r.Ctx, activity.TripID)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in locking trip in LockTripDates db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// trips saved before dates were validated can't hold activities
+	start, startErr := time.Parse(time.DateOnly, trip.StartDate)
+	end, endErr := time.Parse(time.DateOnly, trip.EndDate)
+	if startErr != nil || endErr != nil || activity.Day.Time.Before(start) || activity.Day.Time.After(end) {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiberActivityOutsideTrip)
+	}
+
+	overlap, err := qtx.OverlappingActivity(r.Ctx, db.OverlappingActivityParams{
+		TripID:    activity.TripID,
+		Day:       activity.Day,
+		EndTime:   activity.EndTime,
+		StartTime: activity.StartTime,
+		ID:        activity.ID,
+	})
+
+	if err == nil {
+		return false, c.Status(fiber.StatusConflict).JSON(&fiber.Map{
+			"error":       "activity overlaps " + overlap.Title,
+			"activity_id": overlap.ID,
+		})
+	}
+
+	if err.Error() != "no rows in result set" {
+		logging.FromCtx(c).Error("error in checking overlaps in OverlappingActivity db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return true, nil
+}
+
+// itineraryIndex returns the index of a day in the itinerary, adding it
+// if missing
+func itineraryIndex(days *[]itineraryDay, date string) int {
+	i, found := slices.BinarySearchFunc(*days, date, func(day itineraryDay, date string) int {
+		return cmp.Compare(day.Date, date)
+	})
+	if !found {
+		*days = slices.Insert(*days, i, itineraryDay{date, []db.ListTripActivitiesRow{}, []booking{}})
+	}
+	return i
+}
+
+// getItinerary retrieves the activities of a trip grouped by day along
+// with its bookings on the local day they start, every day of the trip is
+// listed even without activities, members only
+func (r *Repo) getItinerary(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	trip, err := r.Queries.GetTrip(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip in GetTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	activities, err := r.Queries.ListTripActivities(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting activities in ListTripActivities db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	bookings, err := r.tripBookings(c, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	days := []itineraryDay{}
+
+	start, startErr := time.Parse(time.DateOnly, trip.StartDate)
+	end, endErr := time.Parse(time.DateOnly, trip.EndDate)
+	if startErr == nil && endErr == nil && !end.After(start.AddDate(0, 0, maxItineraryDays)) {
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			days = append(days, itineraryDay{day.Format(time.DateOnly), []db.ListTripActivitiesRow{}, []booking{}})
+		}
+	}
+
+	// activities come by day, those left outside after the trip's dates
+	// changed get days of their own
+	for _, activity := range activities {
+		i := itineraryIndex(&days, activity.Day.Time.Format(time.DateOnly))
+		days[i].Activities = append(days[i].Activities, activity)
+	}
+
+	for _, booking := range bookings {
+		i := itineraryIndex(&days, booking.StartLocal[:len(time.DateOnly)])
+		days[i].Bookings = append(days[i].Bookings, booking)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Name":      trip.Name,
+			"StartDate": trip.StartDate,
+			"EndDate":   trip.EndDate,
+			"Days":      days,
+		},
+	})
+}
+
+// createActivity adds an activity to a day of a trip, editors only
+func (r *Repo) createActivity(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	activity, fiberErr := parseActivity(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	if ok, err := r.activityPlace(c, &activity); !ok {
+		return err
+	}
+
+	activity.ID = pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+	activity.TripID = id
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	if ok, err := r.checkActivity(c, qtx, activity); !ok {
+		return err
+	}
+
+	err = qtx.CreateActivity(r.Ctx, db.CreateActivityParams{
+		ID:            activity.ID,
+		TripID:        activity.TripID,
+		Title:         activity.Title,
+		Category:      activity.Category,
+		Day:           activity.Day,
+		StartTime:     activity.StartTime,
+		EndTime:       activity.EndTime,
+		Location:      activity.Location,
+		DestinationID: activity.DestinationID,
+		AttractionID:  activity.AttractionID,
+		Notes:         activity.Notes,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating activity in CreateActivity db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "activity has been added",
+		"id":      activity.ID,
+	})
+}
+
+// updateActivity replaces an activity of a trip, editors only
+func (r *Repo) updateActivity(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	activityID, ok, err := paramUUID(c, "activity", fiberInvalidActivityID)
+	if !ok {
+		return err
+	}
+
+	activity, fiberErr := parseActivity(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	if ok, err := r.activityPlace(c, &activity); !ok {
+		return err
+	}
+
+	activity.ID = activityID
+	activity.TripID = id
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	if ok, err := r.checkActivity(c, qtx, activity); !ok {
+		return err
+	}
+
+	rows, err := qtx.UpdateActivity(r.Ctx, db.UpdateActivityParams{
+		ID:            activity.ID,
+		TripID:        activity.TripID,
+		Title:         activity.Title,
+		Category:      activity.Category,
+		Day:           activity.Day,
+		StartTime:     activity.StartTime,
+		EndTime:       activity.EndTime,
+		Location:      activity.Location,
+		DestinationID: activity.DestinationID,
+		AttractionID:  activity.AttractionID,
+		Notes:         activity.Notes,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating activity in UpdateActivity db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidActivityID)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "activity has been updated"})
+}
+
+// deleteActivity removes an activity from a trip, editors only
+func (r *Repo) deleteActivity(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	activityID, ok, err := paramUUID(c, "activity", fiberInvalidActivityID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteActivity(r.Ctx, db.DeleteActivityParams{
+		ID:     activityID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting activity in DeleteActivity db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidActivityID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "activity has been deleted"})
+}
@@ -1,10 +1,10 @@
 package routes
 
 import (
-	"log"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Trisamudrisvara/goTrip/logging"
 )
 
 // checks whether user is admin or owner
@@ -57,7 +57,7 @@ func (r *Repo) promoteAdmin(c *fiber.Ctx) error {
 
 	// handle any errors from the database operation
 	if err != nil {
-		log.Println("Error in changing admin status in PromoteAdmin or DemoteAdmin db function:", err)
+		logging.FromCtx(c).Error("error in changing admin status in PromoteAdmin or DemoteAdmin db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
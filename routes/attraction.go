@@ -0,0 +1,296 @@
+package routes
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var (
+	fiberInvalidAttractionID = &fiber.Map{"error": "invalid attraction id"}
+	fiberInvalidCategory     = &fiber.Map{"error": "category must be one of landmark, museum, park, religious, viewpoint, entertainment, shopping, food, other"}
+	fiberInvalidDuration     = &fiber.Map{"error": "visit_duration_minutes must be a positive number"}
+	fiberInvalidPriceLevel   = &fiber.Map{"error": "price_level must be between 0 (free) and 4"}
+)
+
+// error returned by postgres when the destination doesn't exist
+const attractionDestinationFkeyError = "ERROR: insert or update on table \"attraction\" violates foreign key constraint \"attraction_destination_id_fkey\" (SQLSTATE 23503)"
+
+var attractionCategories = map[string]bool{
+	"landmark":      true,
+	"museum":        true,
+	"park":          true,
+	"religious":     true,
+	"viewpoint":     true,
+	"entertainment": true,
+	"shopping":      true,
+	"food":          true,
+	"other":         true,
+}
+
+// paramUUID parses a uuid path param
+// writes the error response itself if ok is false
+func paramUUID(c *fiber.Ctx, key string, invalid *fiber.Map) (id pgtype.UUID, ok bool, err error) {
+	uuid, err := uuid.Parse(c.Params(key))
+
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid UUID") {
+			return id, false, c.Status(fiber.StatusBadRequest).JSON(invalid)
+		}
+
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
+		return id, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return pgtype.UUID{Bytes: uuid, Valid: true}, true, nil
+}
+
+// formUUID is paramUUID for form values, missing ones are invalid
+func formUUID(c *fiber.Ctx, key string, invalid *fiber.Map) (id pgtype.UUID, ok bool, err error) {
+	uuid, err := uuid.Parse(c.FormValue(key))
+
+	if err != nil {
+		return id, false, c.Status(fiber.StatusBadRequest).JSON(invalid)
+	}
+
+	return pgtype.UUID{Bytes: uuid, Valid: true}, true, nil
+}
+
+// optionalInt parses an optional integer form value within lo and hi
+func optionalInt(s string, lo, hi int32) (pgtype.Int4, bool) {
+	if s == "" {
+		return pgtype.Int4{}, true
+	}
+
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || int32(v) < lo || int32(v) > hi {
+		return pgtype.Int4{}, false
+	}
+
+	return pgtype.Int4{Int32: int32(v), Valid: true}, true
+}
+
+// parseAttraction reads and validates the attraction form values
+// returns the error to respond with if they are invalid
+func parseAttraction(c *fiber.Ctx) (db.Attraction, *fiber.Map) {
+	attraction := db.Attraction{
+		Name:         c.FormValue("name"),
+		Category:     c.FormValue("category"),
+		Description:  c.FormValue("description"),
+		OpeningHours: optionalText(c.FormValue("opening_hours")),
+	}
+
+	// if any of the form data is missing return error
+	if attraction.Name == "" || attraction.Category == "" || attraction.Description == "" {
+		return attraction, fiberUndefinedParamError
+	}
+
+	if !attractionCategories[attraction.Category] {
+		return attraction, fiberInvalidCategory
+	}
+
+	var ok bool
+	attraction.VisitDurationMinutes, ok = optionalInt(c.FormValue("visit_duration_minutes"), 1, 1<<31-1)
+	if !ok {
+		return attraction, fiberInvalidDuration
+	}
+
+	attraction.PriceLevel, ok = optionalInt(c.FormValue("price_level"), 0, 4)
+	if !ok {
+		return attraction, fiberInvalidPriceLevel
+	}
+
+	attraction.Latitude, attraction.Longitude, ok = parseCoordinates(c)
+	if !ok {
+		return attraction, fiberInvalidCoordinates
+	}
+
+	return attraction, nil
+}
+
+// listAttractions retrieves all attractions of a destination
+func (r *Repo) listAttractions(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	attractions, err := r.Queries.ListAttractions(r.Ctx, destinationID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting attractions in ListAttractions db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(attractions) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no attractions found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &attractions,
+	})
+}
+
+// getAttraction retrieves a single attraction of a destination
+func (r *Repo) getAttraction(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "attraction", fiberInvalidAttractionID)
+	if !ok {
+		return err
+	}
+
+	attraction, err := r.Queries.GetAttraction(r.Ctx, db.GetAttractionParams{
+		ID:            id,
+		DestinationID: destinationID,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAttractionID)
+		}
+
+		logging.FromCtx(c).Error("error in getting attraction in GetAttraction db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &attraction,
+	})
+}
+
+// createAttraction adds a new attraction to a destination
+func (r *Repo) createAttraction(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	attraction, fiberErr := parseAttraction(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	id := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	err = r.Queries.CreateAttraction(r.Ctx, db.CreateAttractionParams{
+		ID:                   id,
+		DestinationID:        destinationID,
+		Name:                 attraction.Name,
+		Category:             attraction.Category,
+		Description:          attraction.Description,
+		OpeningHours:         attraction.OpeningHours,
+		VisitDurationMinutes: attraction.VisitDurationMinutes,
+		PriceLevel:           attraction.PriceLevel,
+		Latitude:             attraction.Latitude,
+		Longitude:            attraction.Longitude,
+	})
+
+	if err != nil {
+		if err.Error() == attractionDestinationFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		logging.FromCtx(c).Error("error in creating attraction in CreateAttraction db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "attraction has been added",
+		"id":      id,
+	})
+}
+
+// updateAttraction modifies an existing attraction of a destination
+func (r *Repo) updateAttraction(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "attraction", fiberInvalidAttractionID)
+	if !ok {
+		return err
+	}
+
+	attraction, fiberErr := parseAttraction(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.UpdateAttraction(r.Ctx, db.UpdateAttractionParams{
+		ID:                   id,
+		DestinationID:        destinationID,
+		Name:                 attraction.Name,
+		Category:             attraction.Category,
+		Description:          attraction.Description,
+		OpeningHours:         attraction.OpeningHours,
+		VisitDurationMinutes: attraction.VisitDurationMinutes,
+		PriceLevel:           attraction.PriceLevel,
+		Latitude:             attraction.Latitude,
+		Longitude:            attraction.Longitude,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating attraction in UpdateAttraction db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAttractionID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "attraction has been updated"})
+}
+
+// deleteAttraction removes an attraction from a destination
+func (r *Repo) deleteAttraction(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "attraction", fiberInvalidAttractionID)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteAttraction(r.Ctx, db.DeleteAttractionParams{
+		ID:            id,
+		DestinationID: destinationID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting attraction in DeleteAttraction db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAttractionID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "attraction has been deleted"})
+}
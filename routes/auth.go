@@ -2,7 +2,6 @@ package routes
 
 import (
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,6 +11,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
 )
 
 // getCsrf retrieves the CSRF token from the context and returns it
@@ -45,7 +45,7 @@ func (r *Repo) login(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiberInvalidEmailPass)
 		}
 
-		log.Println("error in getting user info from db in GetPass function:", err)
+		logging.FromCtx(c).Error("error in getting user info from db in GetPass function", "err", err)
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
@@ -61,7 +61,7 @@ func (r *Repo) login(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiberInvalidEmailPass)
 		}
 
-		log.Println("error in comparing hash password:", err)
+		logging.FromCtx(c).Error("error in comparing hash password", "err", err)
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
@@ -71,11 +71,12 @@ func (r *Repo) login(c *fiber.Ctx) error {
 
 	// Create JWT claims
 	claims := jwt.MapClaims{
+		"id":    usrUuid,
 		"email": email,
 		"name":  GetPass.Name,
 		"admin": GetPass.Admin,
 		"owner": isOwner,
-		"exp":   time.Now().Add(time.Hour * 72).Unix(),
+		"exp":   time.Now().Add(r.Config.JWTExpiry).Unix(),
 	}
 
 	// Create and sign JWT token
@@ -85,7 +86,7 @@ func (r *Repo) login(c *fiber.Ctx) error {
 	jwtToken, err := token.SignedString(secret)
 
 	if err != nil {
-		log.Println("error in signing JWT key:", err)
+		logging.FromCtx(c).Error("error in signing JWT key", "err", err)
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
@@ -111,7 +112,7 @@ func (r *Repo) register(c *fiber.Ctx) error {
 	password, err := bcrypt.GenerateFromPassword([]byte(pass), 12)
 
 	if err != nil {
-		log.Println("Error hashing password:", err)
+		logging.FromCtx(c).Error("error hashing password", "err", err)
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
@@ -131,7 +132,7 @@ func (r *Repo) register(c *fiber.Ctx) error {
 	err = r.Queries.CreateUser(r.Ctx, usr)
 
 	if err != nil {
-		log.Println("Error in creating new user in CreateUser db function:", err)
+		logging.FromCtx(c).Error("error in creating new user in CreateUser db function", "err", err)
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
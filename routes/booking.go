@@ -0,0 +1,710 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+	"github.com/Trisamudrisvara/goTrip/media"
+	"github.com/Trisamudrisvara/goTrip/money"
+	"github.com/Trisamudrisvara/goTrip/reservation"
+)
+
+var (
+	fiberInvalidBookingID      = &fiber.Map{"error": "invalid booking id"}
+	fiberInvalidBookingKind    = &fiber.Map{"error": "kind must be flight, lodging, car, train, bus, restaurant, event or other"}
+	fiberInvalidBookingTime    = &fiber.Map{"error": "starts_at and ends_at must be local times in the format 2006-01-02T15:04, ends_at not before starts_at"}
+	fiberInvalidZone           = &fiber.Map{"error": "zones must be IANA time zones such as Europe/Paris or offsets such as -08:00"}
+	fiberInvalidCost           = &fiber.Map{"error": "cost must be an amount in currency, not negative"}
+	fiberInvalidAttachmentID   = &fiber.Map{"error": "invalid attachment id"}
+	fiberUnsupportedAttachment = &fiber.Map{"error": "file must be a pdf document or a jpeg, png, gif or webp image"}
+	fiberNoReservations        = &fiber.Map{"error": "no reservations found in content"}
+)
+
+// error returned by postgres when the trip of a booking doesn't exist
+const bookingTripFkeyError = "ERROR: insert or update on table \"booking\" violates foreign key constraint \"booking_trip_id_fkey\" (SQLSTATE 23503)"
+
+const (
+	// layout of the local times of bookings
+	bookingTimeLayout = "2006-01-02T15:04"
+	// domain of the UIDs of booking events
+	bookingUIDDomain = "@booking.gotrip"
+)
+
+var bookingKinds = []string{"flight", "lodging", "car", "train", "bus", "restaurant", "event", "other"}
+
+// zone offsets such as -08:00
+var offsetPattern = regexp.MustCompile(`^[+-]\d{2}:\d{2}$`)
+
+// booking is a booking along with its local times and attachments
+type booking struct {
+	db.Booking
+	// wall clock times in the zones of the booking
+	StartLocal  string
+	EndLocal    string
+	Attachments []bookingAttachment
+}
+
+// bookingAttachment is an attachment along with the URL it can be
+// fetched from
+type bookingAttachment struct {
+	db.ListTripBookingAttachmentsRow
+	URL string
+}
+
+// loadZone reads an IANA time zone or an offset
+func loadZone(name string) (*time.Location, bool) {
+	if offsetPattern.MatchString(name) {
+		t, err := time.Parse("-07:00", name)
+		if err != nil {
+			return nil, false
+		}
+		_, offset := t.Zone()
+		return time.FixedZone(name, offset), true
+	}
+
+	// Local is the zone of the server, not of the booking
+	if name == "" || name == "Local" {
+		return nil, false
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// localTime formats an instant in a zone of a booking
+func localTime(t pgtype.Timestamptz, zone pgtype.Text) string {
+	if !t.Valid {
+		return ""
+	}
+
+	loc, ok := loadZone(zone.String)
+	if !ok {
+		loc = time.UTC
+	}
+	return t.Time.In(loc).Format(bookingTimeLayout)
+}
+
+// parseBookingTime reads a local time in a zone
+func parseBookingTime(value, zone string) (pgtype.Timestamptz, *fiber.Map) {
+	loc, ok := loadZone(zone)
+	if !ok {
+		return pgtype.Timestamptz{}, fiberInvalidZone
+	}
+
+	t, err := time.ParseInLocation(bookingTimeLayout, value, loc)
+	if err != nil {
+		return pgtype.Timestamptz{}, fiberInvalidBookingTime
+	}
+
+	return pgtype.Timestamptz{Time: t, Valid: true}, nil
+}
+
+// parseBooking reads and validates the booking form values
+// returns the error to respond with if they are invalid
+func parseBooking(c *fiber.Ctx) (booking db.Booking, fiberErr *fiber.Map) {
+	booking.Kind = c.FormValue("kind")
+	booking.Title = c.FormValue("title")
+	booking.Provider = c.FormValue("provider")
+	booking.ConfirmationCode = c.FormValue("confirmation_code")
+	startsAt := c.FormValue("starts_at")
+	booking.StartZone = c.FormValue("start_zone")
+	endsAt := c.FormValue("ends_at")
+	booking.Address = c.FormValue("address")
+	cost := c.FormValue("cost")
+	booking.Notes = c.FormValue("notes")
+
+	// if any of the form data is missing return error
+	if booking.Kind == "" || booking.Title == "" || startsAt == "" || booking.StartZone == "" {
+		return booking, fiberUndefinedParamError
+	}
+
+	if !slices.Contains(bookingKinds, booking.Kind) {
+		return booking, fiberInvalidBookingKind
+	}
+
+	if booking.StartsAt, fiberErr = parseBookingTime(startsAt, booking.StartZone); fiberErr != nil {
+		return booking, fiberErr
+	}
+
+	// optional, in the start zone unless end_zone is set
+	if endsAt != "" {
+		booking.EndZone = pgtype.Text{String: c.FormValue("end_zone", booking.StartZone), Valid: true}
+
+		if booking.EndsAt, fiberErr = parseBookingTime(endsAt, booking.EndZone.String); fiberErr != nil {
+			return booking, fiberErr
+		}
+
+		if booking.EndsAt.Time.Before(booking.StartsAt.Time) {
+			return booking, fiberInvalidBookingTime
+		}
+	}
+
+	// optional
+	if cost != "" {
+		currency, ok := money.Currency(c.FormValue("currency"))
+		if !ok {
+			return booking, fiberInvalidCurrency
+		}
+
+		minor, err := money.Parse(cost, currency)
+		if err != nil || minor < 0 {
+			return booking, fiberInvalidCost
+		}
+
+		booking.Cost = pgtype.Int8{Int64: minor, Valid: true}
+		booking.Currency = pgtype.Text{String: currency, Valid: true}
+	}
+
+	return booking, nil
+}
+
+// withLocalTimes adds the local times to a booking
+func withLocalTimes(b db.Booking) booking {
+	return booking{
+		Booking:     b,
+		StartLocal:  localTime(b.StartsAt, pgtype.Text{String: b.StartZone, Valid: true}),
+		EndLocal:    localTime(b.EndsAt, b.EndZone),
+		Attachments: []bookingAttachment{},
+	}
+}
+
+// tripBookings retrieves the bookings of a trip in order with their
+// attachments
+func (r *Repo) tripBookings(c *fiber.Ctx, id pgtype.UUID) ([]booking, error) {
+	rows, err := r.Queries.ListTripBookings(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting bookings in ListTripBookings db function", "err", err)
+		return nil, err
+	}
+
+	attachments, err := r.Queries.ListTripBookingAttachments(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting attachments in ListTripBookingAttachments db function", "err", err)
+		return nil, err
+	}
+
+	bookings := make([]booking, len(rows))
+	index := make(map[pgtype.UUID]int, len(rows))
+	for i, row := range rows {
+		bookings[i] = withLocalTimes(row)
+		index[row.ID] = i
+	}
+
+	for _, attachment := range attachments {
+		// added to a booking since it was listed
+		i, ok := index[attachment.BookingID]
+		if !ok {
+			continue
+		}
+
+		url, err := r.Media.URL(attachment.Key, r.Config.Media.URLExpiry)
+		if err != nil {
+			logging.FromCtx(c).Error("error in signing attachment urls", "err", err)
+			return nil, err
+		}
+
+		bookings[i].Attachments = append(bookings[i].Attachments, bookingAttachment{attachment, url})
+	}
+
+	return bookings, nil
+}
+
+// listBookings retrieves the bookings of a trip in order, members only
+func (r *Repo) listBookings(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	bookings, err := r.tripBookings(c, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(bookings) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no bookings found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &bookings,
+	})
+}
+
+// createBooking adds a booking to a trip, editors only
+func (r *Repo) createBooking(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	booking, fiberErr := parseBooking(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	user, _ := userID(c)
+	bookingID := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	err = r.Queries.CreateBooking(r.Ctx, db.CreateBookingParams{
+		ID:               bookingID,
+		TripID:           id,
+		Kind:             booking.Kind,
+		Title:            booking.Title,
+		Provider:         booking.Provider,
+		ConfirmationCode: booking.ConfirmationCode,
+		StartsAt:         booking.StartsAt,
+		StartZone:        booking.StartZone,
+		EndsAt:           booking.EndsAt,
+		EndZone:          booking.EndZone,
+		Address:          booking.Address,
+		Cost:             booking.Cost,
+		Currency:         booking.Currency,
+		Notes:            booking.Notes,
+		CreatedBy:        user,
+	})
+
+	if err != nil {
+		if err.Error() == bookingTripFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in creating booking in CreateBooking db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "booking has been added",
+		"id":      bookingID,
+	})
+}
+
+// updateBooking replaces a booking of a trip, editors only
+func (r *Repo) updateBooking(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	bookingID, ok, err := paramUUID(c, "booking", fiberInvalidBookingID)
+	if !ok {
+		return err
+	}
+
+	booking, fiberErr := parseBooking(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.UpdateBooking(r.Ctx, db.UpdateBookingParams{
+		ID:               bookingID,
+		TripID:           id,
+		Kind:             booking.Kind,
+		Title:            booking.Title,
+		Provider:         booking.Provider,
+		ConfirmationCode: booking.ConfirmationCode,
+		StartsAt:         booking.StartsAt,
+		StartZone:        booking.StartZone,
+		EndsAt:           booking.EndsAt,
+		EndZone:          booking.EndZone,
+		Address:          booking.Address,
+		Cost:             booking.Cost,
+		Currency:         booking.Currency,
+		Notes:            booking.Notes,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating booking in UpdateBooking db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidBookingID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "booking has been updated"})
+}
+
+// deleteBooking removes a booking and its attachments from a trip,
+// editors only
+func (r *Repo) deleteBooking(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	bookingID, ok, err := paramUUID(c, "booking", fiberInvalidBookingID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	keys, err := qtx.DeleteBookingAttachments(r.Ctx, db.DeleteBookingAttachmentsParams{
+		ID:     bookingID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting attachments in DeleteBookingAttachments db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rows, err := qtx.DeleteBooking(r.Ctx, db.DeleteBookingParams{
+		ID:     bookingID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting booking in DeleteBooking db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidBookingID)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	r.deleteStoredMedia(c, keys...)
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "booking has been deleted"})
+}
+
+// importedBooking converts a reservation to a booking, floating times
+// are taken to be in zone
+func importedBooking(res reservation.Booking, zone string) (booking db.Booking, ok bool) {
+	at := func(t reservation.Time) (pgtype.Timestamptz, string, bool) {
+		name := t.Zone
+		if name == "" {
+			name = zone
+		}
+
+		loc, ok := loadZone(name)
+		if !ok {
+			return pgtype.Timestamptz{}, "", false
+		}
+
+		w := t.Wall
+		local := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), 0, loc)
+		return pgtype.Timestamptz{Time: local, Valid: true}, name, true
+	}
+
+	booking = db.Booking{
+		Kind:             res.Kind,
+		Title:            res.Title,
+		Provider:         res.Provider,
+		ConfirmationCode: res.ConfirmationCode,
+		Address:          res.Address,
+	}
+
+	if booking.StartsAt, booking.StartZone, ok = at(res.Start); !ok {
+		return booking, false
+	}
+
+	if booking.Title == "" {
+		booking.Title = booking.Provider
+	}
+	if booking.Title == "" {
+		booking.Title = booking.Kind
+	}
+
+	// ends before the start are dropped rather than trusted
+	if !res.End.IsZero() {
+		if endsAt, endZone, ok := at(res.End); ok && !endsAt.Time.Before(booking.StartsAt.Time) {
+			booking.EndsAt = endsAt
+			booking.EndZone = pgtype.Text{String: endZone, Valid: true}
+		}
+	}
+
+	// prices that can't be read are left out
+	if currency, ok := money.Currency(res.Currency); ok && res.Cost != "" {
+		if minor, err := money.Parse(res.Cost, currency); err == nil && minor >= 0 {
+			booking.Cost = pgtype.Int8{Int64: minor, Valid: true}
+			booking.Currency = pgtype.Text{String: currency, Valid: true}
+		}
+	}
+
+	return booking, true
+}
+
+// importBookings adds the reservations found in a pasted confirmation
+// email to a trip, editors only
+//
+// content is the HTML of the email or its schema.org JSON-LD. Times the
+// email gives without a zone are taken to be in zone, UTC by default
+func (r *Repo) importBookings(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	content := c.FormValue("content")
+	if content == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	zone := c.FormValue("zone", "UTC")
+	if _, ok := loadZone(zone); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidZone)
+	}
+
+	reservations, err := reservation.Parse(content)
+	if err != nil {
+		if errors.Is(err, reservation.ErrNoMarkup) || errors.Is(err, reservation.ErrNoStart) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberNoReservations)
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{"error": err.Error()})
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	user, _ := userID(c)
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	bookings := []booking{}
+	for _, res := range reservations {
+		b, ok := importedBooking(res, zone)
+		if !ok {
+			continue
+		}
+
+		b.ID = pgtype.UUID{Bytes: uuid.New(), Valid: true}
+		b.TripID = id
+
+		err = qtx.CreateBooking(r.Ctx, db.CreateBookingParams{
+			ID:               b.ID,
+			TripID:           b.TripID,
+			Kind:             b.Kind,
+			Title:            b.Title,
+			Provider:         b.Provider,
+			ConfirmationCode: b.ConfirmationCode,
+			StartsAt:         b.StartsAt,
+			StartZone:        b.StartZone,
+			EndsAt:           b.EndsAt,
+			EndZone:          b.EndZone,
+			Address:          b.Address,
+			Cost:             b.Cost,
+			Currency:         b.Currency,
+			Notes:            b.Notes,
+			CreatedBy:        user,
+		})
+
+		if err != nil {
+			if err.Error() == bookingTripFkeyError {
+				return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+			}
+
+			logging.FromCtx(c).Error("error in creating booking in CreateBooking db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		bookings = append(bookings, withLocalTimes(b))
+	}
+
+	if len(bookings) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberNoReservations)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": fmt.Sprintf("%d bookings have been imported", len(bookings)),
+		"data":    &bookings,
+	})
+}
+
+// uploadBookingAttachment adds a ticket or confirmation to a booking,
+// editors only
+//
+// The content type is sniffed from the file itself, PDF documents and
+// images are accepted
+func (r *Repo) uploadBookingAttachment(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	bookingID, ok, err := paramUUID(c, "booking", fiberInvalidBookingID)
+	if !ok {
+		return err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	maxSize := r.Config.Media.MaxSize
+	fiberTooLarge := &fiber.Map{"error": fmt.Sprintf("file must be at most %d bytes", maxSize)}
+
+	if header.Size > int64(maxSize) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiberTooLarge)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logging.FromCtx(c).Error("error in opening uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer file.Close()
+
+	// don't trust the size in the header either
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxSize)+1))
+	if err != nil {
+		logging.FromCtx(c).Error("error in reading uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(data) > maxSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiberTooLarge)
+	}
+
+	contentType, ext, err := media.SniffAttachment(data)
+	if err != nil {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiberUnsupportedAttachment)
+	}
+
+	attachmentID := uuid.New()
+	attachment := db.CreateBookingAttachmentParams{
+		ID:          pgtype.UUID{Bytes: attachmentID, Valid: true},
+		Key:         fmt.Sprintf("bookings/%s/%s%s", uuid.UUID(bookingID.Bytes), attachmentID, ext),
+		Filename:    header.Filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		BookingID:   bookingID,
+		TripID:      id,
+	}
+
+	err = r.Media.Put(r.Ctx, attachment.Key, bytes.NewReader(data), attachment.Size, contentType)
+
+	if err != nil {
+		r.deleteStoredMedia(c, attachment.Key)
+		logging.FromCtx(c).Error("error in storing attachment", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rows, err := r.Queries.CreateBookingAttachment(r.Ctx, attachment)
+
+	if err != nil || rows == 0 {
+		r.deleteStoredMedia(c, attachment.Key)
+
+		if err == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidBookingID)
+		}
+
+		logging.FromCtx(c).Error("error in creating attachment in CreateBookingAttachment db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "attachment has been added",
+		"id":      attachment.ID,
+	})
+}
+
+// deleteBookingAttachment removes an attachment from a booking, editors only
+func (r *Repo) deleteBookingAttachment(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	bookingID, ok, err := paramUUID(c, "booking", fiberInvalidBookingID)
+	if !ok {
+		return err
+	}
+
+	attachmentID, ok, err := paramUUID(c, "attachment", fiberInvalidAttachmentID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	key, err := r.Queries.DeleteBookingAttachment(r.Ctx, db.DeleteBookingAttachmentParams{
+		ID:        attachmentID,
+		BookingID: bookingID,
+		TripID:    id,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAttachmentID)
+		}
+
+		logging.FromCtx(c).Error("error in deleting attachment in DeleteBookingAttachment db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	r.deleteStoredMedia(c, key)
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "attachment has been deleted"})
+}
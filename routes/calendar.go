@@ -0,0 +1,412 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/ical"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+const (
+	// path of calendar feeds, followed by the feed token and .ics
+	calendarFeedPath = "/calendar/"
+	// domain of the UIDs of trip events
+	tripUIDDomain = "@trip.gotrip"
+)
+
+var (
+	fiberInvalidFeedToken = &fiber.Map{"error": "invalid calendar feed token"}
+	fiberInvalidCalendar  = &fiber.Map{"error": "file isn't a valid iCalendar file"}
+)
+
+// tripEvent is the all day event of a trip, at its destination
+type tripEvent struct {
+	ID                     pgtype.UUID
+	Name                   string
+	StartDate              string
+	EndDate                string
+	UpdatedAt              pgtype.Timestamptz
+	Sequence               int32
+	DestinationName        pgtype.Text
+	DestinationDescription pgtype.Text
+}
+
+// event converts a trip to an event, ok is false if its dates were saved
+// before they were validated and can't be read
+func (t tripEvent) event() (event ical.Event, ok bool) {
+	start, err := time.Parse(time.DateOnly, t.StartDate)
+	if err != nil {
+		return event, false
+	}
+
+	end, err := time.Parse(time.DateOnly, t.EndDate)
+	if err != nil || end.Before(start) {
+		return event, false
+	}
+
+	return ical.Event{
+		UID:         uuid.UUID(t.ID.Bytes).String() + tripUIDDomain,
+		Summary:     t.Name,
+		Description: t.DestinationDescription.String,
+		Location:    t.DestinationName.String,
+		Start:       start,
+		End:         end,
+		Sequence:    int(t.Sequence),
+		Modified:    t.UpdatedAt.Time,
+	}, true
+}
+
+// bookingEvent is the timed event of a booking of a trip
+type bookingEvent struct {
+	ID               pgtype.UUID
+	Kind             string
+	Title            string
+	Provider         string
+	ConfirmationCode string
+	StartsAt         pgtype.Timestamptz
+	EndsAt           pgtype.Timestamptz
+	Address          string
+	UpdatedAt        pgtype.Timestamptz
+	Sequence         int32
+}
+
+// event converts a booking to an event, bookings without an end last
+// no time
+func (b bookingEvent) event() ical.Event {
+	var description []string
+	if b.Provider != "" {
+		description = append(description, b.Provider)
+	}
+	if b.ConfirmationCode != "" {
+		description = append(description, "Confirmation: "+b.ConfirmationCode)
+	}
+
+	end := b.StartsAt.Time
+	if b.EndsAt.Valid {
+		end = b.EndsAt.Time
+	}
+
+	return ical.Event{
+		UID:         uuid.UUID(b.ID.Bytes).String() + bookingUIDDomain,
+		Summary:     b.Title,
+		Description: strings.Join(description, "\n"),
+		Location:    b.Address,
+		Start:       b.StartsAt.Time,
+		End:         end,
+		Timed:       true,
+		Sequence:    int(b.Sequence),
+		Modified:    b.UpdatedAt.Time,
+	}
+}
+
+// sendCalendar responds with the trips and bookings as an iCalendar file
+func sendCalendar(c *fiber.Ctx, name string, trips []tripEvent, bookings []bookingEvent) error {
+	cal := ical.Calendar{Name: name}
+	for _, trip := range trips {
+		if event, ok := trip.event(); ok {
+			cal.Events = append(cal.Events, event)
+		}
+	}
+
+	for _, booking := range bookings {
+		cal.Events = append(cal.Events, booking.event())
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		logging.FromCtx(c).Error("error in encoding calendar", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+// feedURL returns the URL of a calendar feed
+func feedURL(c *fiber.Ctx, token string) string {
+	return c.BaseURL() + calendarFeedPath + token + ".ics"
+}
+
+// tripCalendar retrieves a single trip and its bookings as an iCalendar
+// file, members and admins only
+func (r *Repo) tripCalendar(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidID)
+	if !ok {
+		return err
+	}
+
+	// members only
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidID); !ok {
+		return err
+	}
+
+	trip, err := r.Queries.GetTripEvent(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip in GetTripEvent db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rows, err := r.Queries.ListTripBookingEvents(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting bookings in ListTripBookingEvents db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	bookings := make([]bookingEvent, len(rows))
+	for i, row := range rows {
+		bookings[i] = bookingEvent(row)
+	}
+
+	c.Attachment("trip.ics")
+	return sendCalendar(c, trip.Name, []tripEvent{tripEvent(trip)}, bookings)
+}
+
+// calendarFeed retrieves every trip the owner of the feed token is a member
+// of and their bookings as an iCalendar file calendar apps can subscribe
+// to, no JWT needed
+func (r *Repo) calendarFeed(c *fiber.Ctx) error {
+	user, err := r.Queries.GetCalendarFeedUser(r.Ctx, c.Params("token"))
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusNotFound).JSON(fiberInvalidFeedToken)
+		}
+
+		logging.FromCtx(c).Error("error in getting calendar feed in GetCalendarFeedUser db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rows, err := r.Queries.ListTripEvents(r.Ctx, user)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting trips in ListTripEvents db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// an empty calendar is still a valid feed
+	trips := make([]tripEvent, len(rows))
+	for i, row := range rows {
+		trips[i] = tripEvent(row)
+	}
+
+	bookingRows, err := r.Queries.ListBookingEvents(r.Ctx, user)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting bookings in ListBookingEvents db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	bookings := make([]bookingEvent, len(bookingRows))
+	for i, row := range bookingRows {
+		bookings[i] = bookingEvent(row)
+	}
+
+	return sendCalendar(c, "Trips", trips, bookings)
+}
+
+// createCalendarFeed gives the logged in user a secret calendar feed URL,
+// replacing the previous one
+func (r *Repo) createCalendarFeed(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberUnauthorizedError)
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		logging.FromCtx(c).Error("error in generating feed token", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	err := r.Queries.SetCalendarToken(r.Ctx, db.SetCalendarTokenParams{
+		UserID: user,
+		Token:  token,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in saving feed token in SetCalendarToken db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "calendar feed has been created",
+		"FeedURL": feedURL(c, token),
+	})
+}
+
+// deleteCalendarFeed stops the logged in user's calendar feed
+func (r *Repo) deleteCalendarFeed(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberUnauthorizedError)
+	}
+
+	rows, err := r.Queries.DeleteCalendarToken(r.Ctx, user)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting feed token in DeleteCalendarToken db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no calendar feed found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "calendar feed has been deleted"})
+}
+
+// skippedEvent is an event of an imported calendar that didn't become a trip
+type skippedEvent struct {
+	UID     string
+	Summary string
+	Error   string
+}
+
+// importTrips creates a trip for the logged in user from each event of an
+// uploaded .ics file. Events go to the destination_id form value if set,
+// otherwise to the only destination named like their location, events
+// whose location names several destinations are skipped
+func (r *Repo) importTrips(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberUnauthorizedError)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	// optional, every event goes there
+	var destinationID pgtype.UUID
+	if value := c.FormValue("destination_id"); value != "" {
+		uuid, err := uuid.Parse(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		destinationID = pgtype.UUID{Bytes: uuid, Valid: true}
+
+		if ok, err := r.destinationExists(c, destinationID); !ok {
+			return err
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logging.FromCtx(c).Error("error in opening uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer file.Close()
+
+	events, err := ical.Decode(file)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidCalendar)
+	}
+
+	if len(events) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no events found"})
+	}
+
+	// either every matched event becomes a trip or none
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	created := []pgtype.UUID{}
+	skipped := []skippedEvent{}
+	for _, event := range events {
+		skip := func(reason string) {
+			skipped = append(skipped, skippedEvent{event.UID, event.Summary, reason})
+		}
+
+		if event.Summary == "" {
+			skip("event has no summary")
+			continue
+		}
+
+		destination := destinationID
+		if !destination.Valid {
+			if event.Location == "" {
+				skip("event has no location")
+				continue
+			}
+
+			destinations, err := qtx.FindDestinationsByName(r.Ctx, event.Location)
+
+			if err != nil {
+				logging.FromCtx(c).Error("error in finding destination in FindDestinationsByName db function", "err", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+			}
+
+			switch len(destinations) {
+			case 0:
+				skip("no destination named " + event.Location)
+				continue
+			case 1:
+				destination = destinations[0]
+			default:
+				// e.g. Paris in France and in Texas, don't guess
+				skip("more than one destination is named " + event.Location + ", set destination_id")
+				continue
+			}
+		}
+
+		trip := db.CreateTripParams{
+			ID: pgtype.UUID{
+				Bytes: uuid.New(),
+				Valid: true,
+			},
+			Name:          event.Summary,
+			StartDate:     event.Start.Format(time.DateOnly),
+			EndDate:       event.End.Format(time.DateOnly),
+			DestinationID: destination,
+			UserID:        user,
+		}
+
+		if err := qtx.CreateTrip(r.Ctx, trip); err != nil {
+			logging.FromCtx(c).Error("error in creating trip in CreateTrip db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		created = append(created, trip.ID)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "trips have been imported",
+		"data": &fiber.Map{
+			"Created": created,
+			"Skipped": skipped,
+		},
+	})
+}
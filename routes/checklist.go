@@ -0,0 +1,814 @@
+package routes
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var (
+	fiberInvalidTemplateID      = &fiber.Map{"error": "invalid checklist template id"}
+	fiberInvalidTemplateDays    = &fiber.Map{"error": "min_days and max_days must be positive numbers, max_days not below min_days"}
+	fiberInvalidChecklistID     = &fiber.Map{"error": "invalid checklist id"}
+	fiberInvalidChecklistItemID = &fiber.Map{"error": "invalid checklist item id"}
+	fiberInvalidAssignee        = &fiber.Map{"error": "assignee_id must be a member of the trip"}
+	fiberInvalidDone            = &fiber.Map{"error": "done must be true or false"}
+)
+
+// error returned by postgres when the tag doesn't exist
+const checklistTemplateTagFkeyError = "ERROR: insert or update on table \"checklist_template\" violates foreign key constraint \"checklist_template_tag_id_fkey\" (SQLSTATE 23503)"
+
+// checklistTemplate is a template along with its items in order
+type checklistTemplate struct {
+	db.ChecklistTemplate
+	Items []db.ChecklistTemplateItem
+}
+
+// tripChecklist is a checklist of a trip along with its items in order
+type tripChecklist struct {
+	db.Checklist
+	Items []db.ListTripChecklistItemsRow
+	// items not done yet
+	Remaining int
+}
+
+// parseItems reads a list of items, one per line, blank lines are skipped
+func parseItems(value string) (ids []pgtype.UUID, titles []string) {
+	for _, line := range strings.Split(value, "\n") {
+		if title := strings.TrimSpace(line); title != "" {
+			ids = append(ids, pgtype.UUID{Bytes: uuid.New(), Valid: true})
+			titles = append(titles, title)
+		}
+	}
+	return ids, titles
+}
+
+// parseChecklistTemplate reads and validates the template form values
+// returns the error to respond with if they are invalid
+func parseChecklistTemplate(c *fiber.Ctx) (template db.ChecklistTemplate, fiberErr *fiber.Map) {
+	template.Name = c.FormValue("name")
+	template.Description = c.FormValue("description")
+
+	// if any of the form data is missing return error
+	if template.Name == "" {
+		return template, fiberUndefinedParamError
+	}
+
+	// optional, the lengths of trips it's suggested for
+	var minOk, maxOk bool
+	template.MinDays, minOk = optionalInt(c.FormValue("min_days"), 1, 1<<31-1)
+	template.MaxDays, maxOk = optionalInt(c.FormValue("max_days"), 1, 1<<31-1)
+	if !minOk || !maxOk || (template.MinDays.Valid && template.MaxDays.Valid && template.MaxDays.Int32 < template.MinDays.Int32) {
+		return template, fiberInvalidTemplateDays
+	}
+
+	// optional, the tag of destinations it's suggested for
+	if value := c.FormValue("tag_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return template, fiberInvalidTagID
+		}
+		template.TagID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	return template, nil
+}
+
+// listChecklistTemplates retrieves the templates provided by admins
+// followed by those of the logged in user
+func (r *Repo) listChecklistTemplates(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	templates, err := r.Queries.ListChecklistTemplates(r.Ctx, user)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting checklist templates in ListChecklistTemplates db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(templates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no checklist templates found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &templates,
+	})
+}
+
+// checklistTemplateByID retrieves a template the logged in user can see
+// along with its items
+// writes the error response itself if ok is false
+func (r *Repo) checklistTemplateByID(c *fiber.Ctx, id pgtype.UUID) (template checklistTemplate, ok bool, err error) {
+	user, ok := userID(c)
+	if !ok {
+		return template, false, c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	template.ChecklistTemplate, err = r.Queries.GetChecklistTemplate(r.Ctx, db.GetChecklistTemplateParams{
+		ID:     id,
+		UserID: user,
+	})
+
+	if err != nil {
+		// other users' templates don't exist for this user
+		if err.Error() == "no rows in result set" {
+			return template, false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTemplateID)
+		}
+
+		logging.FromCtx(c).Error("error in getting checklist template in GetChecklistTemplate db function", "err", err)
+		return template, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	template.Items, err = r.Queries.ListChecklistTemplateItems(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting template items in ListChecklistTemplateItems db function", "err", err)
+		return template, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if template.Items == nil {
+		template.Items = []db.ChecklistTemplateItem{}
+	}
+
+	return template, true, nil
+}
+
+// getChecklistTemplate retrieves a template with its items
+func (r *Repo) getChecklistTemplate(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTemplateID)
+	if !ok {
+		return err
+	}
+
+	template, ok, err := r.checklistTemplateByID(c, id)
+	if !ok {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &template,
+	})
+}
+
+// createChecklistTemplate adds a template for the logged in user, those
+// made by admins are provided to everyone
+//
+// items lists the items of the template, one per line
+func (r *Repo) createChecklistTemplate(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	template, fiberErr := parseChecklistTemplate(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	// NULL for templates provided by admins
+	if isAdmin(c) {
+		user = pgtype.UUID{}
+	}
+
+	id := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+	ids, titles := parseItems(c.FormValue("items"))
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	err = qtx.CreateChecklistTemplate(r.Ctx, db.CreateChecklistTemplateParams{
+		ID:          id,
+		UserID:      user,
+		Name:        template.Name,
+		Description: template.Description,
+		MinDays:     template.MinDays,
+		MaxDays:     template.MaxDays,
+		TagID:       template.TagID,
+	})
+
+	if err != nil {
+		if err.Error() == checklistTemplateTagFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTagID)
+		}
+
+		logging.FromCtx(c).Error("error in creating checklist template in CreateChecklistTemplate db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	err = qtx.CreateChecklistTemplateItems(r.Ctx, db.CreateChecklistTemplateItemsParams{
+		TemplateID: id,
+		Ids:        ids,
+		Titles:     titles,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating template items in CreateChecklistTemplateItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "checklist template has been added",
+		"id":      id,
+	})
+}
+
+// updateChecklistTemplate replaces a template and its items, users change
+// their own templates and admins the provided ones
+func (r *Repo) updateChecklistTemplate(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTemplateID)
+	if !ok {
+		return err
+	}
+
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	template, fiberErr := parseChecklistTemplate(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	ids, titles := parseItems(c.FormValue("items"))
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	rows, err := qtx.UpdateChecklistTemplate(r.Ctx, db.UpdateChecklistTemplateParams{
+		Name:        template.Name,
+		Description: template.Description,
+		MinDays:     template.MinDays,
+		MaxDays:     template.MaxDays,
+		TagID:       template.TagID,
+		ID:          id,
+		UserID:      user,
+		Admin:       isAdmin(c),
+	})
+
+	if err != nil {
+		if err.Error() == checklistTemplateTagFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTagID)
+		}
+
+		logging.FromCtx(c).Error("error in updating checklist template in UpdateChecklistTemplate db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTemplateID)
+	}
+
+	if err := qtx.DeleteChecklistTemplateItems(r.Ctx, id); err != nil {
+		logging.FromCtx(c).Error("error in deleting template items in DeleteChecklistTemplateItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	err = qtx.CreateChecklistTemplateItems(r.Ctx, db.CreateChecklistTemplateItemsParams{
+		TemplateID: id,
+		Ids:        ids,
+		Titles:     titles,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating template items in CreateChecklistTemplateItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "checklist template has been updated"})
+}
+
+// deleteChecklistTemplate removes a template, checklists made from it are
+// kept
+func (r *Repo) deleteChecklistTemplate(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTemplateID)
+	if !ok {
+		return err
+	}
+
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	rows, err := r.Queries.DeleteChecklistTemplate(r.Ctx, db.DeleteChecklistTemplateParams{
+		ID:     id,
+		UserID: user,
+		Admin:  isAdmin(c),
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting checklist template in DeleteChecklistTemplate db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTemplateID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "checklist template has been deleted"})
+}
+
+// suggestChecklistTemplates retrieves the templates fitting the length of
+// a trip and the tags of its destinations, the most specific first,
+// members only
+func (r *Repo) suggestChecklistTemplates(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	trip, err := r.Queries.GetTrip(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip in GetTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// trips saved before dates were validated only get templates for
+	// any length
+	var days pgtype.Int4
+	start, startErr := time.Parse(time.DateOnly, trip.StartDate)
+	end, endErr := time.Parse(time.DateOnly, trip.EndDate)
+	if startErr == nil && endErr == nil && !end.Before(start) {
+		days = pgtype.Int4{Int32: int32(end.Sub(start).Hours()/24) + 1, Valid: true}
+	}
+
+	user, _ := userID(c)
+	templates, err := r.Queries.SuggestChecklistTemplates(r.Ctx, db.SuggestChecklistTemplatesParams{
+		UserID: user,
+		Days:   days,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting checklist templates in SuggestChecklistTemplates db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(templates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no checklist templates found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &templates,
+	})
+}
+
+// listChecklists retrieves the checklists of a trip with their items,
+// members only
+func (r *Repo) listChecklists(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	checklists, err := r.Queries.ListTripChecklists(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting checklists in ListTripChecklists db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(checklists) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no checklists found"})
+	}
+
+	items, err := r.Queries.ListTripChecklistItems(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting checklist items in ListTripChecklistItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	data := make([]tripChecklist, len(checklists))
+	index := make(map[pgtype.UUID]int, len(checklists))
+	for i, checklist := range checklists {
+		data[i] = tripChecklist{checklist, []db.ListTripChecklistItemsRow{}, 0}
+		index[checklist.ID] = i
+	}
+
+	for _, item := range items {
+		// added to a checklist since it was listed
+		i, ok := index[item.ChecklistID]
+		if !ok {
+			continue
+		}
+
+		data[i].Items = append(data[i].Items, item)
+		if !item.Done {
+			data[i].Remaining++
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &data,
+	})
+}
+
+// checklistAssignee reads the optional assignee_id form value, who has to
+// be a member of the trip
+// writes the error response itself if ok is false
+func (r *Repo) checklistAssignee(c *fiber.Ctx, trip pgtype.UUID) (assignee pgtype.UUID, ok bool, err error) {
+	value := c.FormValue("assignee_id")
+	if value == "" {
+		return assignee, true, nil
+	}
+
+	assignee, ok, err = formUUID(c, "assignee_id", fiberInvalidAssignee)
+	if !ok {
+		return assignee, false, err
+	}
+
+	_, err = r.Queries.GetTripRole(r.Ctx, db.GetTripRoleParams{
+		TripID: trip,
+		UserID: assignee,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return assignee, false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAssignee)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip role in GetTripRole db function", "err", err)
+		return assignee, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return assignee, true, nil
+}
+
+// createChecklist adds a checklist to a trip, editors only
+//
+// Set template_id to copy the items of a template, the checklist is named
+// after it by default. items lists more items, one per line
+func (r *Repo) createChecklist(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	name := c.FormValue("name")
+	var templateID pgtype.UUID
+	var ids []pgtype.UUID
+	var titles []string
+
+	// optional
+	if c.FormValue("template_id") != "" {
+		templateID, ok, err = formUUID(c, "template_id", fiberInvalidTemplateID)
+		if !ok {
+			return err
+		}
+
+		template, ok, err := r.checklistTemplateByID(c, templateID)
+		if !ok {
+			return err
+		}
+
+		if name == "" {
+			name = template.Name
+		}
+
+		for _, item := range template.Items {
+			ids = append(ids, pgtype.UUID{Bytes: uuid.New(), Valid: true})
+			titles = append(titles, item.Title)
+		}
+	}
+
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	moreIDs, moreTitles := parseItems(c.FormValue("items"))
+	ids = append(ids, moreIDs...)
+	titles = append(titles, moreTitles...)
+
+	checklistID := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	err = qtx.CreateChecklist(r.Ctx, db.CreateChecklistParams{
+		ID:         checklistID,
+		TripID:     id,
+		Name:       name,
+		TemplateID: templateID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating checklist in CreateChecklist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	err = qtx.CreateChecklistItems(r.Ctx, db.CreateChecklistItemsParams{
+		ChecklistID: checklistID,
+		Ids:         ids,
+		Titles:      titles,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating checklist items in CreateChecklistItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "checklist has been added",
+		"id":      checklistID,
+	})
+}
+
+// renameChecklist changes the name of a checklist of a trip, editors only
+func (r *Repo) renameChecklist(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	checklistID, ok, err := paramUUID(c, "checklist", fiberInvalidChecklistID)
+	if !ok {
+		return err
+	}
+
+	name := c.FormValue("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.RenameChecklist(r.Ctx, db.RenameChecklistParams{
+		ID:     checklistID,
+		TripID: id,
+		Name:   name,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in renaming checklist in RenameChecklist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidChecklistID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "checklist has been renamed"})
+}
+
+// deleteChecklist removes a checklist and its items from a trip, editors
+// only
+func (r *Repo) deleteChecklist(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	checklistID, ok, err := paramUUID(c, "checklist", fiberInvalidChecklistID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteChecklist(r.Ctx, db.DeleteChecklistParams{
+		ID:     checklistID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting checklist in DeleteChecklist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidChecklistID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "checklist has been deleted"})
+}
+
+// createChecklistItem adds an item to the end of a checklist, editors only
+func (r *Repo) createChecklistItem(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	checklistID, ok, err := paramUUID(c, "checklist", fiberInvalidChecklistID)
+	if !ok {
+		return err
+	}
+
+	title := c.FormValue("title")
+	if title == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	assignee, ok, err := r.checklistAssignee(c, id)
+	if !ok {
+		return err
+	}
+
+	itemID := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	rows, err := r.Queries.CreateChecklistItem(r.Ctx, db.CreateChecklistItemParams{
+		ID:          itemID,
+		Title:       title,
+		AssigneeID:  assignee,
+		ChecklistID: checklistID,
+		TripID:      id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating checklist item in CreateChecklistItem db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidChecklistID)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "checklist item has been added",
+		"id":      itemID,
+	})
+}
+
+// updateChecklistItem replaces the title, assignee and done state of an
+// item, editors only
+func (r *Repo) updateChecklistItem(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	checklistID, ok, err := paramUUID(c, "checklist", fiberInvalidChecklistID)
+	if !ok {
+		return err
+	}
+
+	itemID, ok, err := paramUUID(c, "item", fiberInvalidChecklistItemID)
+	if !ok {
+		return err
+	}
+
+	title := c.FormValue("title")
+	if title == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	done := c.FormValue("done", "false")
+	if done != "true" && done != "false" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDone)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	assignee, ok, err := r.checklistAssignee(c, id)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.UpdateChecklistItem(r.Ctx, db.UpdateChecklistItemParams{
+		Title:       title,
+		AssigneeID:  assignee,
+		Done:        done == "true",
+		ID:          itemID,
+		ChecklistID: checklistID,
+		TripID:      id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating checklist item in UpdateChecklistItem db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidChecklistItemID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "checklist item has been updated"})
+}
+
+// deleteChecklistItem removes an item from a checklist, editors only
+func (r *Repo) deleteChecklistItem(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	checklistID, ok, err := paramUUID(c, "checklist", fiberInvalidChecklistID)
+	if !ok {
+		return err
+	}
+
+	itemID, ok, err := paramUUID(c, "item", fiberInvalidChecklistItemID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteChecklistItem(r.Ctx, db.DeleteChecklistItemParams{
+		ID:          itemID,
+		ChecklistID: checklistID,
+		TripID:      id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting checklist item in DeleteChecklistItem db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidChecklistItemID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "checklist item has been deleted"})
+}
@@ -1,23 +1,125 @@
 package routes
 
 import (
-	"log"
+	"sort"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
 )
 
+var fiberDestinationExists = &fiber.Map{"error": "a destination with this name already exists in this city"}
+
+// error returned by postgres when another destination has the same
+// name, country and city
+const destinationUniqueError = "ERROR: duplicate key value violates unique constraint \"destination_natural_key_idx\" (SQLSTATE 23505)"
+
+// destinationSummary is a destination along with its place breadcrumb,
+// its tags, the aggregate of its reviews and the locale it's in
+type destinationSummary struct {
+	db.Destination
+	Path []placeCrumb
+	Tags []tagRef
+	rating
+	localized
+}
+
+// summarize translates each destination following the locale chain
+// and adds its breadcrumb path, tags and rating
+func (r *Repo) summarize(destinations []db.Destination, chain []string) ([]destinationSummary, error) {
+	ids := make([]pgtype.UUID, len(destinations))
+	placeIDs := make([]pgtype.UUID, len(destinations))
+	for i, destination := range destinations {
+		ids[i] = destination.ID
+		placeIDs[i] = destination.PlaceID
+	}
+
+	paths, err := r.placePaths(placeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := r.destinationTags(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings, err := r.Queries.ListDestinationRatings(r.Ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	translations, localizations, err := r.localize(ids, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	byDestination := make(map[pgtype.UUID]rating, len(ratings))
+	for _, row := range ratings {
+		byDestination[row.DestinationID] = rating{
+			AverageRating: row.AverageRating,
+			ReviewCount:   row.ReviewCount,
+		}
+	}
+
+	out := make([]destinationSummary, len(destinations))
+	for i, destination := range destinations {
+		if translation, ok := translations[destination.ID]; ok {
+			destination.Name = translation.Name
+			destination.Description = translation.Description
+			destination.Attraction = translation.Attraction
+		}
+
+		out[i] = destinationSummary{
+			Destination: destination,
+			Path:        paths[destination.PlaceID],
+			Tags:        tags[destination.ID],
+			rating:      byDestination[destination.ID],
+			localized:   localizations[destination.ID],
+		}
+	}
+
+	return out, nil
+}
+
 // getDestinations retrieves all destinations from the database
+// sort=rating orders them by average rating, then number of reviews
+// tag=beach&tag=family keeps those having every tag, facets counts
+// the tags of the destinations listed
+// content is translated following the Accept-Language header
 func (r *Repo) ListDestinations(c *fiber.Ctx) error {
+	sortBy := c.Query("sort")
+	if sortBy != "" && sortBy != "rating" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "sort must be rating"})
+	}
+
+	var tagValues []string
+	for _, value := range c.Context().QueryArgs().PeekMulti("tag") {
+		tagValues = append(tagValues, string(value))
+	}
+
+	slugs, ok := parseSlugs(strings.Join(tagValues, ","))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUnknownTag)
+	}
+
 	// get destinations from db
-	destinations, err := r.Queries.ListDestinations(r.Ctx)
+	var destinations []db.Destination
+	var err error
+	if len(slugs) == 0 {
+		destinations, err = r.Queries.ListDestinations(r.Ctx)
+	} else {
+		destinations, err = r.Queries.ListDestinationsByTags(r.Ctx, slugs)
+	}
 
 	if err != nil {
-		log.Println("Error in getting destinations in GetDestinations db function:", err)
+		logging.FromCtx(c).Error("error in getting destinations in ListDestinations db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -26,12 +128,32 @@ func (r *Repo) ListDestinations(c *fiber.Ctx) error {
 			&fiber.Map{"error": "no destinations found"})
 	}
 
+	// translate, add breadcrumb paths, tags and ratings
+	c.Vary(fiber.HeaderAcceptLanguage)
+	data, err := r.summarize(destinations, locales.chain(c.Get(fiber.HeaderAcceptLanguage)))
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in summarizing destinations", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if sortBy == "rating" {
+		sort.SliceStable(data, func(i, j int) bool {
+			if data[i].AverageRating != data[j].AverageRating {
+				return data[i].AverageRating > data[j].AverageRating
+			}
+			return data[i].ReviewCount > data[j].ReviewCount
+		})
+	}
+
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
-		"data": &destinations,
+		"data":   &data,
+		"facets": facets(data),
 	})
 }
 
 // getDestination retrieves a single destination by ID
+// content is translated following the Accept-Language header
 func (r *Repo) getDestination(c *fiber.Ctx) error {
 	uuid, err := uuid.Parse(c.Params("id"))
 
@@ -40,7 +162,7 @@ func (r *Repo) getDestination(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -58,12 +180,82 @@ func (r *Repo) getDestination(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
 		}
 
-		log.Println("Error in getting destination in GetDestination db function:", err)
+		logging.FromCtx(c).Error("error in getting destination in GetDestination db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// add breadcrumb path
+	paths, err := r.placePaths([]pgtype.UUID{destination.PlaceID})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting destination path in ListPlaceAncestors db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
+	// embed the attractions
+	attractions, err := r.Queries.ListAttractions(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting attractions in ListAttractions db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// embed the gallery, the cover is marked with IsCover
+	files, err := r.Queries.ListMediaFiles(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting media in ListMediaFiles db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	gallery, err := r.withURLs(files)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in signing media urls", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	tags, err := r.destinationTags([]pgtype.UUID{id})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting destination tags in ListDestinationTags db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// average rating of the visible reviews
+	destinationRating, err := r.Queries.GetDestinationRating(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting rating in GetDestinationRating db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	translations, localizations, err := r.localize([]pgtype.UUID{id}, locales.chain(c.Get(fiber.HeaderAcceptLanguage)))
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting translations in ListTranslations db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if translation, ok := translations[id]; ok {
+		destination.Name = translation.Name
+		destination.Description = translation.Description
+		destination.Attraction = translation.Attraction
+	}
+
+	c.Vary(fiber.HeaderAcceptLanguage)
+	c.Set(fiber.HeaderContentLanguage, localizations[id].Locale)
+
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
-		"data": &destination,
+		"data": &struct {
+			db.GetDestinationRow
+			Path        []placeCrumb
+			Tags        []tagRef
+			Attractions []db.Attraction
+			Media       []mediaFile
+			rating
+			localized
+		}{destination, paths[destination.PlaceID], tags[id], attractions, gallery, rating(destinationRating), localizations[id]},
 	})
 }
 
@@ -79,6 +271,18 @@ func (r *Repo) createDestination(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
 	}
 
+	// optional coordinates
+	lat, lng, ok := parseCoordinates(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidCoordinates)
+	}
+
+	// optional place in the country/region/city hierarchy
+	placeID, ok := parsePlaceID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidPlaceID)
+	}
+
 	destination := db.CreateDestinationParams{
 		ID: pgtype.UUID{
 			Bytes: uuid.New(),
@@ -87,12 +291,28 @@ func (r *Repo) createDestination(c *fiber.Ctx) error {
 		Name:        name,
 		Description: description,
 		Attraction:  attraction,
+		Latitude:    lat,
+		Longitude:   lng,
+		Country:     optionalText(c.FormValue("country")),
+		Region:      optionalText(c.FormValue("region")),
+		City:        optionalText(c.FormValue("city")),
+		PlaceID:     placeID,
 	}
 
-	err := r.Queries.CreateDestination(r.Ctx, destination)
+	// recorded as the first revision
+	err := r.revise(c, destination.ID, "create", func(q *db.Queries) error {
+		return q.CreateDestination(r.Ctx, destination)
+	})
 
 	if err != nil {
-		log.Println("Error in creating destination in Createdestination db function:", err)
+		switch err.Error() {
+		case destinationPlaceFkeyError:
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidPlaceID)
+		case destinationUniqueError:
+			return c.Status(fiber.StatusConflict).JSON(fiberDestinationExists)
+		}
+
+		logging.FromCtx(c).Error("error in creating destination in CreateDestination db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -121,10 +341,22 @@ func (r *Repo) updateDestination(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
+	// optional coordinates
+	lat, lng, ok := parseCoordinates(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidCoordinates)
+	}
+
+	// optional place in the country/region/city hierarchy
+	placeID, ok := parsePlaceID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidPlaceID)
+	}
+
 	destination := db.UpdateDestinationParams{
 		ID: pgtype.UUID{
 			Bytes: uuid,
@@ -133,12 +365,35 @@ func (r *Repo) updateDestination(c *fiber.Ctx) error {
 		Name:        name,
 		Description: description,
 		Attraction:  attraction,
+		Latitude:    lat,
+		Longitude:   lng,
+		Country:     optionalText(c.FormValue("country")),
+		Region:      optionalText(c.FormValue("region")),
+		City:        optionalText(c.FormValue("city")),
+		PlaceID:     placeID,
 	}
 
-	err = r.Queries.UpdateDestination(r.Ctx, destination)
+	// the previous state stays in the revision history
+	err = r.revise(c, destination.ID, "update", func(q *db.Queries) error {
+		rows, err := q.UpdateDestination(r.Ctx, destination)
+		if err == nil && rows == 0 {
+			return pgx.ErrNoRows
+		}
+		return err
+	})
 
 	if err != nil {
-		log.Println("Error in updatin destination in UpdateDestination db function:", err)
+		switch err.Error() {
+		case destinationPlaceFkeyError:
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidPlaceID)
+		case destinationUniqueError:
+			return c.Status(fiber.StatusConflict).JSON(fiberDestinationExists)
+		// either doesn't exist or is in the trash
+		case "no rows in result set":
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		logging.FromCtx(c).Error("error in updating destination in UpdateDestination db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -146,7 +401,8 @@ func (r *Repo) updateDestination(c *fiber.Ctx) error {
 		"message": "destination has been updated"})
 }
 
-// deleteDestination removes a destination from the database by ID
+// deleteDestination moves a destination to the trash by ID
+// trips keep it until it's purged
 func (r *Repo) deleteDestination(c *fiber.Ctx) error {
 	// get id
 	uuid, err := uuid.Parse(c.Params("id"))
@@ -156,7 +412,7 @@ func (r *Repo) deleteDestination(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -165,14 +421,20 @@ func (r *Repo) deleteDestination(c *fiber.Ctx) error {
 		Valid: true,
 	}
 
-	err = r.Queries.DeleteDestination(r.Ctx, id)
+	err = r.revise(c, id, "delete", func(q *db.Queries) error {
+		rows, err := q.DeleteDestination(r.Ctx, id)
+		if err == nil && rows == 0 {
+			return pgx.ErrNoRows
+		}
+		return err
+	})
 
 	if err != nil {
 		if err.Error() == "no rows in result set" {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
 		}
 
-		log.Println("Error in deleting destination in DeleteDestination db function:", err)
+		logging.FromCtx(c).Error("error in deleting destination in DeleteDestination db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -0,0 +1,131 @@
+package routes
+
+import (
+	"math/big"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+	"github.com/Trisamudrisvara/goTrip/money"
+)
+
+var (
+	fiberInvalidRate         = &fiber.Map{"error": "rate must be a positive number, at least 0.000000000001"}
+	fiberInvalidCurrencyPair = &fiber.Map{"error": "base and quote must be different supported ISO 4217 codes, e.g. USD"}
+
+	// smallest rate numeric(24, 12) can hold
+	minRate = big.NewRat(1, 1e12)
+)
+
+// exchangeRates loads every exchange rate, rates the money package can't
+// read are left out
+// writes the error response itself if ok is false
+func (r *Repo) exchangeRates(c *fiber.Ctx) (rates money.Rates, ok bool, err error) {
+	rows, err := r.Queries.ListExchangeRates(r.Ctx)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting exchange rates in ListExchangeRates db function", "err", err)
+		return nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rates = make(money.Rates, len(rows))
+	for _, row := range rows {
+		rate, err := money.ParseRate(row.Rate)
+		if err != nil {
+			logging.FromCtx(c).Warn("error in reading exchange rate", "base", row.Base, "quote", row.Quote, "err", err)
+			continue
+		}
+
+		rates[[2]string{row.Base, row.Quote}] = rate
+	}
+
+	return rates, true, nil
+}
+
+// currencyPair reads the base and quote currencies from the route
+func currencyPair(c *fiber.Ctx) (base, quote string, ok bool) {
+	base, baseOk := money.Currency(c.Params("base"))
+	quote, quoteOk := money.Currency(c.Params("quote"))
+	return base, quote, baseOk && quoteOk && base != quote
+}
+
+// listExchangeRates retrieves the exchange rates used to total expenses
+func (r *Repo) listExchangeRates(c *fiber.Ctx) error {
+	rates, err := r.Queries.ListExchangeRates(r.Ctx)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting exchange rates in ListExchangeRates db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(rates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no exchange rates found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &rates,
+	})
+}
+
+// putExchangeRate sets how many units of quote one unit of base is worth,
+// admin only
+func (r *Repo) putExchangeRate(c *fiber.Ctx) error {
+	base, quote, ok := currencyPair(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidCurrencyPair)
+	}
+
+	value := c.FormValue("rate")
+	if value == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	rate, err := money.ParseRate(value)
+	// smaller rates would be saved as 0 with 12 decimals
+	if err != nil || rate.Cmp(minRate) < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidRate)
+	}
+
+	err = r.Queries.SetExchangeRate(r.Ctx, db.SetExchangeRateParams{
+		Base:  base,
+		Quote: quote,
+		// numeric(24, 12) keeps 12 decimals
+		Rate: rate.FloatString(12),
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in saving exchange rate in SetExchangeRate db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "exchange rate has been saved"})
+}
+
+// deleteExchangeRate removes an exchange rate, admin only
+func (r *Repo) deleteExchangeRate(c *fiber.Ctx) error {
+	base, quote, ok := currencyPair(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidCurrencyPair)
+	}
+
+	rows, err := r.Queries.DeleteExchangeRate(r.Ctx, db.DeleteExchangeRateParams{
+		Base:  base,
+		Quote: quote,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting exchange rate in DeleteExchangeRate db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no exchange rate found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "exchange rate has been deleted"})
+}
@@ -0,0 +1,452 @@
+package routes
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+	"github.com/Trisamudrisvara/goTrip/money"
+)
+
+var (
+	fiberInvalidExpenseID       = &fiber.Map{"error": "invalid expense id"}
+	fiberInvalidAmount          = &fiber.Map{"error": "amount must be a positive number with no more decimals than its currency has"}
+	fiberInvalidBudget          = &fiber.Map{"error": "budget must be a number with no more decimals than its currency has and not negative"}
+	fiberInvalidCurrency        = &fiber.Map{"error": "currency must be a supported ISO 4217 code, e.g. USD"}
+	fiberInvalidExpenseCategory = &fiber.Map{"error": "category must be lodging, transport, food, activities, shopping, fees or other"}
+	fiberInvalidSpentOn         = &fiber.Map{"error": "spent_on must be a date in the format 2006-01-02"}
+	fiberInvalidPayer           = &fiber.Map{"error": "paid_by must be a member of the trip"}
+)
+
+// error returned by postgres when the trip of an expense doesn't exist
+const expenseTripFkeyError = "ERROR: insert or update on table \"expense\" violates foreign key constraint \"expense_trip_id_fkey\" (SQLSTATE 23503)"
+
+var expenseCategories = []string{"lodging", "transport", "food", "activities", "shopping", "fees", "other"}
+
+// parseExpense reads and validates the expense form values, the payer
+// is the logged in user unless paid_by is set
+// returns the error to respond with if they are invalid
+func parseExpense(c *fiber.Ctx) (expense db.Expense, fiberErr *fiber.Map) {
+	amount := c.FormValue("amount")
+	currency := c.FormValue("currency")
+	expense.Category = c.FormValue("category")
+	spentOn := c.FormValue("spent_on")
+	expense.Note = c.FormValue("note")
+
+	// if any of the form data is missing return error
+	if amount == "" || currency == "" || expense.Category == "" || spentOn == "" {
+		return expense, fiberUndefinedParamError
+	}
+
+	var ok bool
+	if expense.Currency, ok = money.Currency(currency); !ok {
+		return expense, fiberInvalidCurrency
+	}
+
+	minor, err := money.Parse(amount, expense.Currency)
+	if err != nil || minor <= 0 {
+		return expense, fiberInvalidAmount
+	}
+	expense.Amount = minor
+
+	if !slices.Contains(expenseCategories, expense.Category) {
+		return expense, fiberInvalidExpenseCategory
+	}
+
+	date, err := time.Parse(time.DateOnly, spentOn)
+	if err != nil {
+		return expense, fiberInvalidSpentOn
+	}
+	expense.SpentOn = pgtype.Date{Time: date, Valid: true}
+
+	if paidBy := c.FormValue("paid_by"); paidBy != "" {
+		payer, err := uuid.Parse(paidBy)
+		if err != nil {
+			return expense, fiberInvalidPayer
+		}
+		expense.PaidBy = pgtype.UUID{Bytes: payer, Valid: true}
+	} else {
+		expense.PaidBy, _ = userID(c)
+	}
+
+	return expense, nil
+}
+
+// tripPayer checks that the payer of an expense is a member of the trip
+// writes the error response itself if ok is false
+func (r *Repo) tripPayer(c *fiber.Ctx, trip, payer pgtype.UUID) (ok bool, err error) {
+	if !payer.Valid {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidPayer)
+	}
+
+	_, err = r.Queries.GetTripRole(r.Ctx, db.GetTripRoleParams{
+		TripID: trip,
+		UserID: payer,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidPayer)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip role in GetTripRole db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return true, nil
+}
+
+// listExpenses retrieves the expenses of a trip by date, members only
+func (r *Repo) listExpenses(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	expenses, err := r.Queries.ListTripExpenses(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting expenses in ListTripExpenses db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(expenses) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no expenses found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &expenses,
+	})
+}
+
+// createExpense adds an expense to a trip, editors only
+func (r *Repo) createExpense(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	expense, fiberErr := parseExpense(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	if ok, err := r.tripPayer(c, id, expense.PaidBy); !ok {
+		return err
+	}
+
+	creator, _ := userID(c)
+
+	expense.ID = pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	err = r.Queries.CreateExpense(r.Ctx, db.CreateExpenseParams{
+		ID:        expense.ID,
+		TripID:    id,
+		Amount:    expense.Amount,
+		Currency:  expense.Currency,
+		Category:  expense.Category,
+		PaidBy:    expense.PaidBy,
+		SpentOn:   expense.SpentOn,
+		Note:      expense.Note,
+		CreatedBy: creator,
+	})
+
+	if err != nil {
+		if err.Error() == expenseTripFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in creating expense in CreateExpense db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "expense has been added",
+		"id":      expense.ID,
+	})
+}
+
+// updateExpense replaces an expense of a trip, editors only
+func (r *Repo) updateExpense(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	expenseID, ok, err := paramUUID(c, "expense", fiberInvalidExpenseID)
+	if !ok {
+		return err
+	}
+
+	expense, fiberErr := parseExpense(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	if ok, err := r.tripPayer(c, id, expense.PaidBy); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.UpdateExpense(r.Ctx, db.UpdateExpenseParams{
+		ID:       expenseID,
+		TripID:   id,
+		Amount:   expense.Amount,
+		Currency: expense.Currency,
+		Category: expense.Category,
+		PaidBy:   expense.PaidBy,
+		SpentOn:  expense.SpentOn,
+		Note:     expense.Note,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating expense in UpdateExpense db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidExpenseID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "expense has been updated"})
+}
+
+// deleteExpense removes an expense from a trip, editors only
+func (r *Repo) deleteExpense(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	expenseID, ok, err := paramUUID(c, "expense", fiberInvalidExpenseID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteExpense(r.Ctx, db.DeleteExpenseParams{
+		ID:     expenseID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting expense in DeleteExpense db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidExpenseID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "expense has been deleted"})
+}
+
+// updateBudget sets the home currency of a trip and its budget in it, an
+// empty budget removes it, editors only
+func (r *Repo) updateBudget(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	currency, ok := money.Currency(c.FormValue("currency"))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidCurrency)
+	}
+
+	// optional, no budget if empty
+	var budget pgtype.Int8
+	if value := c.FormValue("budget"); value != "" {
+		minor, err := money.Parse(value, currency)
+		if err != nil || minor < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidBudget)
+		}
+		budget = pgtype.Int8{Int64: minor, Valid: true}
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.UpdateTripBudget(r.Ctx, db.UpdateTripBudgetParams{
+		ID:       id,
+		Budget:   budget,
+		Currency: currency,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating budget in UpdateTripBudget db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "budget has been updated"})
+}
+
+// categoryTotal is the spending of a trip in a category
+type categoryTotal struct {
+	Category string
+	Total    int64
+}
+
+// dayTotal is the spending of a trip on a day
+type dayTotal struct {
+	Date  string
+	Total int64
+}
+
+// currencyTotal is spending that has no exchange rate to the home currency
+type currencyTotal struct {
+	Currency string
+	Total    int64
+}
+
+// expenseSummary retrieves the totals of a trip by category and by day,
+// converted to its home currency, along with the budget left. Spending in
+// currencies without an exchange rate is listed apart, members only
+func (r *Repo) expenseSummary(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	trip, err := r.Queries.GetTripBudget(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in getting budget in GetTripBudget db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rates, ok, err := r.exchangeRates(c)
+	if !ok {
+		return err
+	}
+
+	byCategory, err := r.Queries.ExpenseTotalsByCategory(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting totals in ExpenseTotalsByCategory db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	byDay, err := r.Queries.ExpenseTotalsByDay(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting totals in ExpenseTotalsByDay db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// convert returns the total in the home currency, ok is false if
+	// there's no rate for its currency
+	convert := func(total int64, currency string) (int64, bool) {
+		rate, ok := rates.Rate(currency, trip.Currency)
+		if !ok {
+			return 0, false
+		}
+
+		converted, err := money.Convert(total, currency, trip.Currency, rate)
+		return converted, err == nil
+	}
+
+	var spent int64
+	categories := map[string]int64{}
+	unconverted := map[string]int64{}
+	for _, row := range byCategory {
+		total, ok := convert(row.Total, row.Currency)
+		if !ok {
+			unconverted[row.Currency] += row.Total
+			continue
+		}
+
+		categories[row.Category] += total
+		spent += total
+	}
+
+	categoryTotals := []categoryTotal{}
+	for _, category := range expenseCategories {
+		if total, ok := categories[category]; ok {
+			categoryTotals = append(categoryTotals, categoryTotal{category, total})
+		}
+	}
+
+	// rows come by date, several currencies may share one
+	dayTotals := []dayTotal{}
+	for _, row := range byDay {
+		total, ok := convert(row.Total, row.Currency)
+		if !ok {
+			continue
+		}
+
+		date := row.SpentOn.Time.Format(time.DateOnly)
+		if n := len(dayTotals); n > 0 && dayTotals[n-1].Date == date {
+			dayTotals[n-1].Total += total
+			continue
+		}
+
+		dayTotals = append(dayTotals, dayTotal{date, total})
+	}
+
+	currencyTotals := []currencyTotal{}
+	for currency, total := range unconverted {
+		currencyTotals = append(currencyTotals, currencyTotal{currency, total})
+	}
+	slices.SortFunc(currencyTotals, func(a, b currencyTotal) int {
+		return cmp.Compare(a.Currency, b.Currency)
+	})
+
+	var remaining pgtype.Int8
+	if trip.Budget.Valid {
+		remaining = pgtype.Int8{Int64: trip.Budget.Int64 - spent, Valid: true}
+	}
+
+	// amounts are in minor units of their currency
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Currency":    trip.Currency,
+			"Budget":      trip.Budget,
+			"Spent":       spent,
+			"Remaining":   remaining,
+			"ByCategory":  categoryTotals,
+			"ByDay":       dayTotals,
+			"Unconverted": currencyTotals,
+		},
+	})
+}
@@ -0,0 +1,232 @@
+package routes
+
+import (
+	"bufio"
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var fiberInvalidExportFormat = &fiber.Map{"error": "format must be csv, json or ndjson"}
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"json":   fiber.MIMEApplicationJSONCharsetUTF8,
+	"ndjson": "application/x-ndjson",
+}
+
+var destinationExportColumns = []string{
+	"id", "name", "description", "attraction", "latitude", "longitude",
+	"country", "region", "city", "place_id", "average_rating", "review_count", "tags",
+}
+
+var tripExportColumns = []string{
+	"id", "name", "start_date", "end_date", "destination_id",
+	"destination_name", "destination_deleted", "user_id",
+}
+
+// exportRows is a query whose rows are streamed, see db/export.go
+type exportRows[T any] interface {
+	Next() bool
+	Row() (T, error)
+	Err() error
+	Close()
+}
+
+// exportFormat reads the format query, json by default
+func exportFormat(c *fiber.Ctx) (string, bool) {
+	format := c.Query("format", "json")
+	_, ok := exportContentTypes[format]
+	return format, ok
+}
+
+// streamExport writes the rows as they are read from the database, csv
+// records are made by record. The status is sent before the first row,
+// so an error halfway through can only cut the body short, the json
+// array is then left unterminated
+func streamExport[T any](c *fiber.Ctx, name, format string, rows exportRows[T], columns []string, record func(T) []string) error {
+	// the request context can't be used once the handler returns
+	logger := logging.FromCtx(c)
+	encode := c.App().Config().JSONEncoder
+
+	c.Attachment(name + "." + format)
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	c.Status(fiber.StatusOK)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer rows.Close()
+
+		csvWriter := csv.NewWriter(w)
+
+		var err error
+		switch format {
+		case "csv":
+			err = csvWriter.Write(columns)
+		case "json":
+			_, err = w.WriteString(`{"data":[`)
+		}
+
+		for first := true; err == nil && rows.Next(); first = false {
+			var row T
+			row, err = rows.Row()
+			if err != nil {
+				break
+			}
+
+			if format == "csv" {
+				err = csvWriter.Write(record(row))
+				continue
+			}
+
+			var data []byte
+			data, err = encode(row)
+			if err != nil {
+				break
+			}
+
+			if format == "json" && !first {
+				data = append([]byte{','}, data...)
+			}
+			if format == "ndjson" {
+				data = append(data, '\n')
+			}
+
+			// stops once the client is gone
+			_, err = w.Write(data)
+		}
+
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			logger.Error("error in exporting "+name, "err", err)
+			return
+		}
+
+		switch format {
+		case "csv":
+			csvWriter.Flush()
+			err = csvWriter.Error()
+		case "json":
+			_, err = w.WriteString("]}")
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			logger.Warn("error in writing "+name+" export", "err", err)
+		}
+	})
+
+	return nil
+}
+
+// uuidField formats a nullable uuid for csv
+func uuidField(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return uuid.UUID(id.Bytes).String()
+}
+
+// floatField formats a nullable float for csv
+func floatField(f pgtype.Float8) string {
+	if !f.Valid {
+		return ""
+	}
+	return strconv.FormatFloat(f.Float64, 'f', -1, 64)
+}
+
+// exportDestinations streams every destination in csv, json or ndjson
+// along with its tags and rating, untranslated. Takes the same tag and
+// sort filters as ListDestinations
+func (r *Repo) exportDestinations(c *fiber.Ctx) error {
+	format, ok := exportFormat(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidExportFormat)
+	}
+
+	sortBy := c.Query("sort")
+	if sortBy != "" && sortBy != "rating" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "sort must be rating"})
+	}
+
+	var tagValues []string
+	for _, value := range c.Context().QueryArgs().PeekMulti("tag") {
+		tagValues = append(tagValues, string(value))
+	}
+
+	slugs, ok := parseSlugs(strings.Join(tagValues, ","))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUnknownTag)
+	}
+
+	rows, err := r.Queries.ExportDestinations(r.Ctx, db.ExportDestinationsParams{
+		// not nil, NULL would match nothing
+		Slugs:    append([]string{}, slugs...),
+		ByRating: sortBy == "rating",
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in exporting destinations in ExportDestinations db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return streamExport(c, "destinations", format, rows, destinationExportColumns,
+		func(d db.ExportDestinationsRow) []string {
+			return []string{
+				uuidField(d.ID), d.Name, d.Description, d.Attraction,
+				floatField(d.Latitude), floatField(d.Longitude),
+				d.Country.String, d.Region.String, d.City.String, uuidField(d.PlaceID),
+				strconv.FormatFloat(d.AverageRating, 'f', -1, 64),
+				strconv.FormatInt(d.ReviewCount, 10),
+				strings.Join(d.Tags, ","),
+			}
+		})
+}
+
+// exportTrips streams the trips the logged in user is a member of in csv, json or
+// ndjson, all=true exports every user's trips, admin only
+func (r *Repo) exportTrips(c *fiber.Ctx) error {
+	format, ok := exportFormat(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidExportFormat)
+	}
+
+	all := c.QueryBool("all")
+	if all && !isAdmin(c) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberUnauthorizedError)
+	}
+
+	user, ok := userID(c)
+	if !ok && !all {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberUnauthorizedError)
+	}
+
+	rows, err := r.Queries.ExportTrips(r.Ctx, db.ExportTripsParams{
+		All:    all,
+		UserID: user,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in exporting trips in ExportTrips db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return streamExport(c, "trips", format, rows, tripExportColumns,
+		func(t db.ExportTripsRow) []string {
+			return []string{
+				uuidField(t.ID), t.Name, t.StartDate, t.EndDate,
+				uuidField(t.DestinationID), t.DestinationName.String,
+				strconv.FormatBool(t.DestinationDeleted), uuidField(t.UserID),
+			}
+		})
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+const (
+	// mean earth radius used by the haversine formula
+	earthRadiusKm = 6371.0
+	// half of the earth's circumference
+	maxRadiusKm = math.Pi * earthRadiusKm
+
+	defaultRadiusKm      = 50
+	defaultNearbyResults = 50
+	maxNearbyResults     = 200
+)
+
+var fiberInvalidCoordinates = &fiber.Map{
+	"error": "latitude must be between -90 and 90 and longitude between -180 and 180, both or neither must be set"}
+
+// parseCoordinates reads the optional latitude and longitude form values
+// returns ok false if they are invalid or only one of them is set
+func parseCoordinates(c *fiber.Ctx) (lat, lng pgtype.Float8, ok bool) {
+	return coordinates(c.FormValue("latitude"), c.FormValue("longitude"))
+}
+
+// coordinates validates an optional pair of coordinates
+func coordinates(latitude, longitude string) (lat, lng pgtype.Float8, ok bool) {
+	// coordinates are optional
+	if latitude == "" && longitude == "" {
+		return lat, lng, true
+	}
+
+	latValue, err := strconv.ParseFloat(latitude, 64)
+	if err != nil || !validLatitude(latValue) {
+		return lat, lng, false
+	}
+
+	lngValue, err := strconv.ParseFloat(longitude, 64)
+	if err != nil || !validLongitude(lngValue) {
+		return lat, lng, false
+	}
+
+	return pgtype.Float8{Float64: latValue, Valid: true},
+		pgtype.Float8{Float64: lngValue, Valid: true}, true
+}
+
+// NaN fails every comparison, so validLatitude and validLongitude reject it
+func validLatitude(lat float64) bool {
+	return lat >= -90 && lat <= 90
+}
+
+func validLongitude(lng float64) bool {
+	return lng >= -180 && lng <= 180
+}
+
+// optionalText converts an empty form value to NULL
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// boundingBox returns the lat/lng box containing every point within
+// radiusKm of lat, lng, the longitude range is widened to the whole
+// world near the poles or across the antimeridian
+func boundingBox(lat, lng, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	delta := radiusKm / earthRadiusKm * 180 / math.Pi
+
+	minLat = math.Max(lat-delta, -90)
+	maxLat = math.Min(lat+delta, 90)
+	minLng, maxLng = -180, 180
+
+	// box touches a pole, every longitude is within range
+	if minLat == -90 || maxLat == 90 {
+		return
+	}
+
+	lngDelta := delta / math.Cos(lat*math.Pi/180)
+	if lng-lngDelta >= -180 && lng+lngDelta <= 180 {
+		minLng, maxLng = lng-lngDelta, lng+lngDelta
+	}
+
+	return
+}
+
+// nearbyDestinations returns destinations within radius_km of lat, lng
+// ordered by great-circle distance
+func (r *Repo) nearbyDestinations(c *fiber.Ctx) error {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || !validLatitude(lat) {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "lat must be between -90 and 90"})
+	}
+
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil || !validLongitude(lng) {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "lng must be between -180 and 180"})
+	}
+
+	radius := float64(defaultRadiusKm)
+	if q := c.Query("radius_km"); q != "" {
+		radius, err = strconv.ParseFloat(q, 64)
+		if err != nil || math.IsNaN(radius) || radius <= 0 || radius > maxRadiusKm {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				&fiber.Map{"error": "radius_km must be a positive number of at most 20015"})
+		}
+	}
+
+	limit := c.QueryInt("limit", defaultNearbyResults)
+	if limit < 1 || limit > maxNearbyResults {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "limit must be between 1 and 200"})
+	}
+
+	minLat, maxLat, minLng, maxLng := boundingBox(lat, lng, radius)
+
+	destinations, err := r.Queries.ListNearbyDestinations(r.Ctx, db.ListNearbyDestinationsParams{
+		Lat:        lat,
+		Lng:        lng,
+		MinLat:     minLat,
+		MaxLat:     maxLat,
+		MinLng:     minLng,
+		MaxLng:     maxLng,
+		RadiusKm:   radius,
+		MaxResults: int32(limit),
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting nearby destinations in ListNearbyDestinations db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(destinations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no destinations found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &destinations,
+	})
+}
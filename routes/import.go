@@ -0,0 +1,506 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+const (
+	// files with more rows are imported in the background
+	importSyncRows = 500
+	// how often the progress of background imports is saved
+	importProgressRows = 100
+)
+
+var (
+	fiberInvalidImportID     = &fiber.Map{"error": "invalid import id"}
+	fiberInvalidImportFormat = &fiber.Map{"error": "format must be csv, json or jsonl"}
+	fiberInvalidImportMode   = &fiber.Map{"error": "mode must be atomic or best_effort"}
+	fiberEmptyImport         = &fiber.Map{"error": "file has no rows"}
+)
+
+// columns an import row can have, name, description and attraction are required
+var importColumns = []string{
+	"name", "description", "attraction", "latitude", "longitude",
+	"country", "region", "city", "place_id",
+}
+
+// importRow is a parsed row of an import file
+type importRow struct {
+	// line number in the file
+	Line        int
+	Destination db.UpsertDestinationParams
+	// set if the row didn't validate
+	Err string
+}
+
+// importError is why a row of an import wasn't imported
+type importError struct {
+	Row   int
+	Error string
+}
+
+// importJob is an import along with the errors of its failed rows
+type importJob struct {
+	db.ImportJob
+	Errors json.RawMessage
+}
+
+// validateImportRow checks the values of a row the same way the
+// destination form is checked
+func validateImportRow(line int, values map[string]string) importRow {
+	row := importRow{Line: line}
+
+	for column := range values {
+		if !slices.Contains(importColumns, column) {
+			row.Err = "unknown column " + column
+			return row
+		}
+	}
+
+	name := strings.TrimSpace(values["name"])
+	description := values["description"]
+	attraction := values["attraction"]
+
+	if name == "" || description == "" || attraction == "" {
+		row.Err = "name, description and attraction are required"
+		return row
+	}
+
+	lat, lng, ok := coordinates(values["latitude"], values["longitude"])
+	if !ok {
+		row.Err = "latitude must be between -90 and 90 and longitude between -180 and 180, both or neither must be set"
+		return row
+	}
+
+	placeID, ok := placeIDValue(values["place_id"])
+	if !ok {
+		row.Err = "invalid place_id"
+		return row
+	}
+
+	row.Destination = db.UpsertDestinationParams{
+		ID: pgtype.UUID{
+			Bytes: uuid.New(),
+			Valid: true,
+		},
+		Name:        name,
+		Description: description,
+		Attraction:  attraction,
+		Latitude:    lat,
+		Longitude:   lng,
+		Country:     optionalText(values["country"]),
+		Region:      optionalText(values["region"]),
+		City:        optionalText(values["city"]),
+		PlaceID:     placeID,
+	}
+
+	return row
+}
+
+// parseCSV reads a csv file whose first line names the columns
+func parseCSV(data []byte) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// a malformed line doesn't stop the rest from being read
+			rows = append(rows, importRow{Line: parseErr.Line, Err: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		values := make(map[string]string, len(header))
+		for i, column := range header {
+			if record[i] != "" {
+				values[column] = record[i]
+			}
+		}
+
+		rows = append(rows, validateImportRow(line, values))
+	}
+
+	return rows, nil
+}
+
+// parseJSONLines reads a file with a json object per line, blank lines are skipped
+func parseJSONLines(data []byte) []importRow {
+	var rows []importRow
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil {
+			rows = append(rows, importRow{Line: i + 1, Err: "invalid json object"})
+			continue
+		}
+
+		rows = append(rows, validateImportRow(i+1, jsonValues(object)))
+	}
+
+	return rows
+}
+
+// parseJSON reads a file with a json array of objects, rows are numbered
+// by their position in the array
+func parseJSON(data []byte) ([]importRow, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var objects []json.RawMessage
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, err
+	}
+
+	rows := make([]importRow, 0, len(objects))
+	for i, raw := range objects {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil || object == nil {
+			rows = append(rows, importRow{Line: i + 1, Err: "invalid json object"})
+			continue
+		}
+
+		rows = append(rows, validateImportRow(i+1, jsonValues(object)))
+	}
+
+	return rows, nil
+}
+
+// jsonValues converts the values of a json object to the strings a csv
+// row would have, nulls are left out
+func jsonValues(object map[string]any) map[string]string {
+	values := make(map[string]string, len(object))
+	for column, value := range object {
+		switch value := value.(type) {
+		case nil:
+		case string:
+			values[column] = value
+		case json.Number:
+			values[column] = value.String()
+		default:
+			values[column] = fmt.Sprint(value)
+		}
+	}
+	return values
+}
+
+// importFormat tells the format of an import from the format form value,
+// falling back to the extension of the file
+func importFormat(format, filename string) (string, bool) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			format = "csv"
+		case ".json":
+			format = "json"
+		case ".jsonl", ".ndjson":
+			format = "jsonl"
+		}
+	}
+
+	return format, format == "csv" || format == "json" || format == "jsonl"
+}
+
+// importDestinations creates or updates destinations from a csv, json or
+// json lines file, rows are matched to existing destinations by name, country
+// and city. Set dry_run=true to only validate the file. mode=atomic, the
+// default, imports nothing if any row fails, mode=best_effort skips them.
+// Large files are imported in the background, admin only
+func (r *Repo) importDestinations(c *fiber.Ctx) error {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	format, ok := importFormat(c.FormValue("format"), header.Filename)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidImportFormat)
+	}
+
+	mode := c.FormValue("mode", "atomic")
+	if mode != "atomic" && mode != "best_effort" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidImportMode)
+	}
+
+	dryRun := c.FormValue("dry_run") == "true"
+
+	file, err := header.Open()
+	if err != nil {
+		logging.FromCtx(c).Error("error in opening uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logging.FromCtx(c).Error("error in reading uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	var rows []importRow
+	switch format {
+	case "csv":
+		rows, err = parseCSV(data)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"error": "invalid csv: " + err.Error()})
+		}
+	case "json":
+		rows, err = parseJSON(data)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"error": "invalid json, expected an array of objects: " + err.Error()})
+		}
+	default:
+		rows = parseJSONLines(data)
+	}
+
+	if len(rows) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberEmptyImport)
+	}
+
+	// the editor is left unset if the token has no user id
+	editor, _ := userID(c)
+
+	job := db.CreateImportJobParams{
+		ID: pgtype.UUID{
+			Bytes: uuid.New(),
+			Valid: true,
+		},
+		Format:    format,
+		Mode:      mode,
+		DryRun:    dryRun,
+		TotalRows: int32(len(rows)),
+		EditorID:  editor,
+	}
+
+	err = r.Queries.CreateImportJob(r.Ctx, job)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating import job in CreateImportJob db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// the request context can't be used once the handler returns
+	logger := logging.FromCtx(c)
+	jobID := uuid.UUID(job.ID.Bytes).String()
+
+	if len(rows) > importSyncRows {
+		go r.runImport(logger, job, rows)
+
+		c.Location("/destination/import/" + jobID)
+		return c.Status(fiber.StatusAccepted).JSON(&fiber.Map{
+			"message": "import has been started",
+			"id":      job.ID,
+		})
+	}
+
+	r.runImport(logger, job, rows)
+
+	return r.sendImportJob(c, job.ID)
+}
+
+// FailInterruptedImports marks the imports a previous process left running
+// as failed, their goroutine is gone so they would never finish. Only one
+// process may run the API against a database
+func (r *Repo) FailInterruptedImports() error {
+	n, err := r.Queries.FailInterruptedImportJobs(r.Ctx)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		slog.Warn("marked interrupted imports as failed", "count", n)
+	}
+	return nil
+}
+
+// runImport upserts the rows of an import in a single transaction, each
+// row in its own savepoint so a failed row doesn't abort the others
+func (r *Repo) runImport(logger *slog.Logger, job db.CreateImportJobParams, rows []importRow) {
+	result := db.FinishImportJobParams{ID: job.ID, Status: "failed"}
+	errs := []importError{}
+	aborted := true
+
+	// marks the job as failed if anything goes wrong on the way
+	defer func() {
+		if aborted {
+			errs = append(errs, importError{Error: "import was aborted"})
+		}
+
+		result.Errors, _ = json.Marshal(errs)
+
+		if err := r.Queries.FinishImportJob(r.Ctx, result); err != nil {
+			logger.Error("error in finishing import job in FinishImportJob db function", "err", err)
+		}
+	}()
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logger.Error("error in starting transaction", "err", err)
+		return
+	}
+	defer tx.Rollback(r.Ctx)
+
+	for i, row := range rows {
+		inserted, err := r.importRow(tx, job.EditorID, row)
+
+		if err != nil {
+			result.FailedRows++
+			errs = append(errs, importError{row.Line, err.Error()})
+		} else if inserted {
+			result.CreatedRows++
+		} else {
+			result.UpdatedRows++
+		}
+		result.ProcessedRows++
+
+		if (i+1)%importProgressRows == 0 {
+			// outside the transaction so it can be seen while it runs
+			err := r.Queries.UpdateImportJobProgress(r.Ctx, db.UpdateImportJobProgressParams{
+				ID:            job.ID,
+				ProcessedRows: result.ProcessedRows,
+				CreatedRows:   result.CreatedRows,
+				UpdatedRows:   result.UpdatedRows,
+				FailedRows:    result.FailedRows,
+			})
+			if err != nil {
+				logger.Warn("error in saving import progress in UpdateImportJobProgress db function", "err", err)
+			}
+		}
+	}
+
+	if job.Mode == "atomic" && result.FailedRows > 0 {
+		aborted = false
+		return
+	}
+
+	if !job.DryRun {
+		if err := tx.Commit(r.Ctx); err != nil {
+			logger.Error("error in committing transaction", "err", err)
+			return
+		}
+	}
+
+	aborted = false
+	result.Status = "succeeded"
+}
+
+// importRow upserts a row and records it as a revision of its destination
+// returns whether the destination was created rather than updated
+func (r *Repo) importRow(tx pgx.Tx, editor pgtype.UUID, row importRow) (inserted bool, err error) {
+	if row.Err != "" {
+		return false, errors.New(row.Err)
+	}
+
+	savepoint, err := tx.Begin(r.Ctx)
+	if err != nil {
+		return false, err
+	}
+	defer savepoint.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(savepoint)
+
+	upserted, err := qtx.UpsertDestination(r.Ctx, row.Destination)
+	if err != nil {
+		if err.Error() == destinationPlaceFkeyError {
+			return false, errors.New("unknown place_id")
+		}
+		return false, err
+	}
+
+	action := "update"
+	if upserted.Inserted {
+		action = "create"
+	}
+
+	err = qtx.CreateDestinationRevision(r.Ctx, db.CreateDestinationRevisionParams{
+		Action:        action,
+		EditorID:      editor,
+		DestinationID: upserted.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return upserted.Inserted, savepoint.Commit(r.Ctx)
+}
+
+// sendImportJob responds with the status of an import
+func (r *Repo) sendImportJob(c *fiber.Ctx, id pgtype.UUID) error {
+	job, err := r.Queries.GetImportJob(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidImportID)
+		}
+
+		logging.FromCtx(c).Error("error in getting import job in GetImportJob db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &importJob{job, job.Errors},
+	})
+}
+
+// getImportJob retrieves the progress of an import along with the rows
+// that failed, admin only
+func (r *Repo) getImportJob(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidImportID)
+	if !ok {
+		return err
+	}
+
+	return r.sendImportJob(c, id)
+}
@@ -0,0 +1,793 @@
+package routes
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/ledger"
+	"github.com/Trisamudrisvara/goTrip/logging"
+	"github.com/Trisamudrisvara/goTrip/money"
+)
+
+var (
+	fiberInvalidParticipantID   = &fiber.Map{"error": "invalid participant id"}
+	fiberInvalidPaymentID       = &fiber.Map{"error": "invalid payment id"}
+	fiberInvalidSplit           = &fiber.Map{"error": "split must be equal, shares or exact"}
+	fiberInvalidSplitEntries    = &fiber.Map{"error": "participants must be a comma separated list of participant ids of the trip, each followed by :shares when split by shares or :amount when split by exact amounts"}
+	fiberInvalidExactSplit      = &fiber.Map{"error": "exact amounts must add up to the amount"}
+	fiberEmptySplit             = &fiber.Map{"error": "nobody is left to split the payment between"}
+	fiberInvalidSettlement      = &fiber.Map{"error": "from and to must be two different participants of the trip"}
+	fiberParticipantExists      = &fiber.Map{"error": "the trip already has a participant with this name"}
+	fiberInvalidParticipantUser = &fiber.Map{"error": "user_id must be a member of the trip"}
+	fiberParticipantUserExists  = &fiber.Map{"error": "the trip already has a participant for this user"}
+	fiberParticipantHasPayments = &fiber.Map{"error": "participant has payments, delete them first"}
+)
+
+const (
+	// errors returned by postgres
+	ledgerParticipantUniqueError     = "ERROR: duplicate key value violates unique constraint \"ledger_participant_name_idx\" (SQLSTATE 23505)"
+	ledgerParticipantTripError       = "ERROR: insert or update on table \"ledger_participant\" violates foreign key constraint \"ledger_participant_trip_id_fkey\" (SQLSTATE 23503)"
+	ledgerParticipantUserUniqueError = "ERROR: duplicate key value violates unique constraint \"ledger_participant_user_id_idx\" (SQLSTATE 23505)"
+	ledgerParticipantUserError       = "ERROR: insert or update on table \"ledger_participant\" violates foreign key constraint \"ledger_participant_user_id_fkey\" (SQLSTATE 23503)"
+	ledgerPaymentPayerError          = "ERROR: update or delete on table \"ledger_participant\" violates foreign key constraint \"ledger_payment_paid_by_fkey\" on table \"ledger_payment\" (SQLSTATE 23503)"
+	ledgerSplitParticipantError      = "ERROR: update or delete on table \"ledger_participant\" violates foreign key constraint \"ledger_split_participant_id_fkey\" on table \"ledger_split\" (SQLSTATE 23503)"
+	// most shares a participant can have of a payment
+	maxSplitShares = 1000
+)
+
+// splitPart is what a participant owes of a payment
+type splitPart struct {
+	ParticipantID pgtype.UUID
+	Name          string
+	Shares        int32
+	Amount        int64
+}
+
+// ledgerPayment is a payment along with how it's split
+type ledgerPayment struct {
+	db.ListLedgerPaymentsRow
+	Splits []splitPart
+}
+
+// participantBalance is what a participant paid and owes in the home
+// currency of the trip, a positive balance is money they are owed
+type participantBalance struct {
+	ParticipantID pgtype.UUID
+	Name          string
+	Paid          int64
+	Owed          int64
+	Balance       int64
+}
+
+// ledgerTransfer is a payment of the settle up plan
+type ledgerTransfer struct {
+	From     pgtype.UUID
+	FromName string
+	To       pgtype.UUID
+	ToName   string
+	Amount   int64
+}
+
+// ledgerParticipants retrieves the participants of a trip by id
+// writes the error response itself if ok is false
+func (r *Repo) ledgerParticipants(c *fiber.Ctx, id pgtype.UUID) (participants map[pgtype.UUID]string, ordered []pgtype.UUID, ok bool, err error) {
+	rows, err := r.Queries.ListLedgerParticipants(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting participants in ListLedgerParticipants db function", "err", err)
+		return nil, nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	participants = make(map[pgtype.UUID]string, len(rows))
+	for _, row := range rows {
+		participants[row.ID] = row.Name
+		ordered = append(ordered, row.ID)
+	}
+
+	return participants, ordered, true, nil
+}
+
+// tripCurrency reads the currency form value, the home currency of the
+// trip by default
+// writes the error response itself if ok is false
+func (r *Repo) tripCurrency(c *fiber.Ctx, id pgtype.UUID) (currency string, ok bool, err error) {
+	if value := c.FormValue("currency"); value != "" {
+		if currency, ok = money.Currency(value); !ok {
+			return "", false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidCurrency)
+		}
+		return currency, true, nil
+	}
+
+	trip, err := r.Queries.GetTripBudget(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return "", false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip currency in GetTripBudget db function", "err", err)
+		return "", false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return trip.Currency, true, nil
+}
+
+// parseSplit works out what each participant owes of a payment from the
+// split, participants and exclude form values. Equal splits are between
+// every participant of the trip unless participants is set, exclude
+// leaves people out of any split
+// returns the error to respond with if they are invalid
+func parseSplit(c *fiber.Ctx, amount int64, currency string, participants map[pgtype.UUID]string, ordered []pgtype.UUID) (split string, parts db.CreateLedgerSplitsParams, fiberErr *fiber.Map) {
+	split = c.FormValue("split", "equal")
+	if split != "equal" && split != "shares" && split != "exact" {
+		return split, parts, fiberInvalidSplit
+	}
+
+	excluded := map[pgtype.UUID]bool{}
+	if value := c.FormValue("exclude"); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(entry))
+			if err != nil {
+				return split, parts, fiberInvalidSplitEntries
+			}
+			excluded[pgtype.UUID{Bytes: id, Valid: true}] = true
+		}
+	}
+
+	var ids []pgtype.UUID
+	var values []string
+	if value := c.FormValue("participants"); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			idValue, weight, hasWeight := strings.Cut(strings.TrimSpace(entry), ":")
+			if hasWeight == (split == "equal") {
+				return split, parts, fiberInvalidSplitEntries
+			}
+
+			parsed, err := uuid.Parse(idValue)
+			if err != nil {
+				return split, parts, fiberInvalidSplitEntries
+			}
+
+			id := pgtype.UUID{Bytes: parsed, Valid: true}
+			if _, ok := participants[id]; !ok || slices.Contains(ids, id) {
+				return split, parts, fiberInvalidSplitEntries
+			}
+
+			ids = append(ids, id)
+			values = append(values, weight)
+		}
+	} else if split == "equal" {
+		ids = ordered
+		values = make([]string, len(ids))
+	} else {
+		return split, parts, fiberUndefinedParamError
+	}
+
+	weights := []int64{}
+	exact := []int64{}
+	var sum int64
+	for i, id := range ids {
+		if excluded[id] {
+			continue
+		}
+
+		switch split {
+		case "equal":
+			weights = append(weights, 1)
+		case "shares":
+			shares, err := strconv.Atoi(values[i])
+			if err != nil || shares < 1 || shares > maxSplitShares {
+				return split, parts, fiberInvalidSplitEntries
+			}
+			weights = append(weights, int64(shares))
+		case "exact":
+			minor, err := money.Parse(values[i], currency)
+			if err != nil || minor < 0 {
+				return split, parts, fiberInvalidSplitEntries
+			}
+			exact = append(exact, minor)
+			sum += minor
+		}
+
+		parts.ParticipantIds = append(parts.ParticipantIds, id)
+	}
+
+	if len(parts.ParticipantIds) == 0 {
+		return split, parts, fiberEmptySplit
+	}
+
+	if split == "exact" {
+		if sum != amount {
+			return split, parts, fiberInvalidExactSplit
+		}
+
+		parts.Amounts = exact
+		parts.Shares = make([]int32, len(exact))
+		for i := range parts.Shares {
+			parts.Shares[i] = 1
+		}
+		return split, parts, nil
+	}
+
+	amounts, err := ledger.Split(amount, weights)
+	if err != nil {
+		return split, parts, fiberEmptySplit
+	}
+
+	parts.Amounts = amounts
+	for _, w := range weights {
+		parts.Shares = append(parts.Shares, int32(w))
+	}
+
+	return split, parts, nil
+}
+
+// recordPayment saves a payment and its split in one transaction
+// writes the error response itself if ok is false
+func (r *Repo) recordPayment(c *fiber.Ctx, payment db.CreateLedgerPaymentParams, parts db.CreateLedgerSplitsParams) (ok bool, err error) {
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	if err := qtx.CreateLedgerPayment(r.Ctx, payment); err != nil {
+		logging.FromCtx(c).Error("error in creating payment in CreateLedgerPayment db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	parts.PaymentID = payment.ID
+	if err := qtx.CreateLedgerSplits(r.Ctx, parts); err != nil {
+		logging.FromCtx(c).Error("error in splitting payment in CreateLedgerSplits db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return true, nil
+}
+
+// listLedgerParticipants retrieves the participants of a trip's ledger,
+// members only
+func (r *Repo) listLedgerParticipants(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	participants, err := r.Queries.ListLedgerParticipants(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting participants in ListLedgerParticipants db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(participants) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no participants found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &participants,
+	})
+}
+
+// createLedgerParticipant adds a named participant to a trip's ledger,
+// editors only. Participants linked to a trip member with user_id share
+// the trip expenses
+func (r *Repo) createLedgerParticipant(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	// optional
+	var user pgtype.UUID
+	if c.FormValue("user_id") != "" {
+		if user, ok, err = formUUID(c, "user_id", fiberInvalidParticipantUser); !ok {
+			return err
+		}
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	if user.Valid {
+		_, err = r.Queries.GetTripRole(r.Ctx, db.GetTripRoleParams{
+			TripID: id,
+			UserID: user,
+		})
+
+		if err != nil {
+			if err.Error() == "no rows in result set" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidParticipantUser)
+			}
+
+			logging.FromCtx(c).Error("error in getting trip role in GetTripRole db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+	}
+
+	participant := db.CreateLedgerParticipantParams{
+		ID: pgtype.UUID{
+			Bytes: uuid.New(),
+			Valid: true,
+		},
+		TripID: id,
+		Name:   name,
+		UserID: user,
+	}
+
+	err = r.Queries.CreateLedgerParticipant(r.Ctx, participant)
+
+	if err != nil {
+		switch err.Error() {
+		case ledgerParticipantUniqueError:
+			return c.Status(fiber.StatusConflict).JSON(fiberParticipantExists)
+		case ledgerParticipantUserUniqueError:
+			return c.Status(fiber.StatusConflict).JSON(fiberParticipantUserExists)
+		case ledgerParticipantTripError:
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		case ledgerParticipantUserError:
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidParticipantUser)
+		}
+
+		logging.FromCtx(c).Error("error in creating participant in CreateLedgerParticipant db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "participant has been added",
+		"id":      participant.ID,
+	})
+}
+
+// deleteLedgerParticipant removes a participant without payments from a
+// trip's ledger, editors only
+func (r *Repo) deleteLedgerParticipant(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	participantID, ok, err := paramUUID(c, "participant", fiberInvalidParticipantID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteLedgerParticipant(r.Ctx, db.DeleteLedgerParticipantParams{
+		ID:     participantID,
+		TripID: id,
+	})
+
+	if err != nil {
+		if err.Error() == ledgerPaymentPayerError || err.Error() == ledgerSplitParticipantError {
+			return c.Status(fiber.StatusConflict).JSON(fiberParticipantHasPayments)
+		}
+
+		logging.FromCtx(c).Error("error in deleting participant in DeleteLedgerParticipant db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidParticipantID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "participant has been deleted"})
+}
+
+// listLedgerPayments retrieves the payments of a trip's ledger along with
+// how each is split, members only
+func (r *Repo) listLedgerPayments(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	payments, err := r.Queries.ListLedgerPayments(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting payments in ListLedgerPayments db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(payments) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no payments found"})
+	}
+
+	splits, err := r.Queries.ListLedgerSplits(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting splits in ListLedgerSplits db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	byPayment := map[pgtype.UUID][]splitPart{}
+	for _, split := range splits {
+		byPayment[split.PaymentID] = append(byPayment[split.PaymentID],
+			splitPart{split.ParticipantID, split.Name, split.Shares, split.Amount})
+	}
+
+	data := make([]ledgerPayment, len(payments))
+	for i, payment := range payments {
+		data[i] = ledgerPayment{payment, byPayment[payment.ID]}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &data,
+	})
+}
+
+// createLedgerPayment records a payment made by a participant and how it
+// is split, editors only
+func (r *Repo) createLedgerPayment(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	paidBy, ok, err := formUUID(c, "paid_by", fiberInvalidParticipantID)
+	if !ok {
+		return err
+	}
+
+	amount := c.FormValue("amount")
+	if amount == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	currency, ok, err := r.tripCurrency(c, id)
+	if !ok {
+		return err
+	}
+
+	minor, err := money.Parse(amount, currency)
+	if err != nil || minor <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAmount)
+	}
+
+	participants, ordered, ok, err := r.ledgerParticipants(c, id)
+	if !ok {
+		return err
+	}
+
+	if _, ok := participants[paidBy]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidParticipantID)
+	}
+
+	split, parts, fiberErr := parseSplit(c, minor, currency, participants, ordered)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	creator, _ := userID(c)
+
+	payment := db.CreateLedgerPaymentParams{
+		ID: pgtype.UUID{
+			Bytes: uuid.New(),
+			Valid: true,
+		},
+		TripID:      id,
+		PaidBy:      paidBy,
+		Amount:      minor,
+		Currency:    currency,
+		Description: c.FormValue("description"),
+		Split:       split,
+		CreatedBy:   creator,
+	}
+
+	if ok, err := r.recordPayment(c, payment, parts); !ok {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "payment has been recorded",
+		"id":      payment.ID,
+	})
+}
+
+// deleteLedgerPayment removes a payment or settlement from a trip's
+// ledger, editors only
+func (r *Repo) deleteLedgerPayment(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	paymentID, ok, err := paramUUID(c, "payment", fiberInvalidPaymentID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteLedgerPayment(r.Ctx, db.DeleteLedgerPaymentParams{
+		ID:     paymentID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting payment in DeleteLedgerPayment db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidPaymentID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "payment has been deleted"})
+}
+
+// balances works out what each participant of a trip paid and owes in
+// its home currency, ledger payments along with the trip expenses paid by
+// linked participants. Currencies without an exchange rate to it are left
+// out and returned apart
+// writes the error response itself if ok is false
+func (r *Repo) balances(c *fiber.Ctx, id pgtype.UUID) (balances []participantBalance, currency string, unconverted []string, ok bool, err error) {
+	trip, err := r.Queries.GetTripBudget(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return nil, "", nil, false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip currency in GetTripBudget db function", "err", err)
+		return nil, "", nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rates, ok, err := r.exchangeRates(c)
+	if !ok {
+		return nil, "", nil, false, err
+	}
+
+	rows, err := r.Queries.LedgerBalances(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting balances in LedgerBalances db function", "err", err)
+		return nil, "", nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	convert := func(amount int64, from string) (int64, bool) {
+		rate, ok := rates.Rate(from, trip.Currency)
+		if !ok {
+			return 0, false
+		}
+
+		converted, err := money.Convert(amount, from, trip.Currency, rate)
+		return converted, err == nil
+	}
+
+	unconverted = []string{}
+	balances = []participantBalance{}
+	for _, row := range rows {
+		// rows of a participant are next to each other
+		if n := len(balances); n == 0 || balances[n-1].ParticipantID != row.ParticipantID {
+			balances = append(balances, participantBalance{ParticipantID: row.ParticipantID, Name: row.Name})
+		}
+
+		if !row.Currency.Valid {
+			continue
+		}
+
+		paid, paidOk := convert(row.Paid, row.Currency.String)
+		owed, owedOk := convert(row.Owed, row.Currency.String)
+		if !paidOk || !owedOk {
+			if !slices.Contains(unconverted, row.Currency.String) {
+				unconverted = append(unconverted, row.Currency.String)
+			}
+			continue
+		}
+
+		balance := &balances[len(balances)-1]
+		balance.Paid += paid
+		balance.Owed += owed
+		balance.Balance = balance.Paid - balance.Owed
+	}
+
+	slices.Sort(unconverted)
+	return balances, trip.Currency, unconverted, true, nil
+}
+
+// ledgerBalances retrieves what each participant paid, owes and is owed in
+// the home currency of the trip, members only
+func (r *Repo) ledgerBalances(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	balances, currency, unconverted, ok, err := r.balances(c, id)
+	if !ok {
+		return err
+	}
+
+	if len(balances) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no participants found"})
+	}
+
+	// amounts are in minor units of currency
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Currency":    currency,
+			"Balances":    balances,
+			"Unconverted": unconverted,
+		},
+	})
+}
+
+// settleUp retrieves the fewest transfers found that make every balance of
+// a trip's ledger zero, members only
+func (r *Repo) settleUp(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	balances, currency, unconverted, ok, err := r.balances(c, id)
+	if !ok {
+		return err
+	}
+
+	// the ledger package needs ordered keys
+	byKey := make(map[string]int64, len(balances))
+	participants := make(map[string]participantBalance, len(balances))
+	for _, balance := range balances {
+		key := uuid.UUID(balance.ParticipantID.Bytes).String()
+		byKey[key] = balance.Balance
+		participants[key] = balance
+	}
+
+	transfers := []ledgerTransfer{}
+	for _, transfer := range ledger.Settle(byKey) {
+		from, to := participants[transfer.From], participants[transfer.To]
+		transfers = append(transfers, ledgerTransfer{
+			From:     from.ParticipantID,
+			FromName: from.Name,
+			To:       to.ParticipantID,
+			ToName:   to.Name,
+			Amount:   transfer.Amount,
+		})
+	}
+
+	// amounts are in minor units of currency
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Currency":    currency,
+			"Transfers":   transfers,
+			"Unconverted": unconverted,
+		},
+	})
+}
+
+// createSettlement records a transfer of the settle up plan, or any other
+// repayment, as a payment from one participant made whole to another,
+// editors only
+func (r *Repo) createSettlement(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	from, ok, err := formUUID(c, "from", fiberInvalidSettlement)
+	if !ok {
+		return err
+	}
+
+	to, ok, err := formUUID(c, "to", fiberInvalidSettlement)
+	if !ok {
+		return err
+	}
+
+	amount := c.FormValue("amount")
+	if amount == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if from == to {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidSettlement)
+	}
+
+	if ok, err := r.tripAccess(c, id, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	currency, ok, err := r.tripCurrency(c, id)
+	if !ok {
+		return err
+	}
+
+	minor, err := money.Parse(amount, currency)
+	if err != nil || minor <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidAmount)
+	}
+
+	participants, _, ok, err := r.ledgerParticipants(c, id)
+	if !ok {
+		return err
+	}
+
+	_, fromOk := participants[from]
+	_, toOk := participants[to]
+	if !fromOk || !toOk {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidSettlement)
+	}
+
+	creator, _ := userID(c)
+
+	payment := db.CreateLedgerPaymentParams{
+		ID: pgtype.UUID{
+			Bytes: uuid.New(),
+			Valid: true,
+		},
+		TripID:      id,
+		PaidBy:      from,
+		Amount:      minor,
+		Currency:    currency,
+		Description: c.FormValue("description", "settlement"),
+		Split:       "settlement",
+		CreatedBy:   creator,
+	}
+
+	parts := db.CreateLedgerSplitsParams{
+		ParticipantIds: []pgtype.UUID{to},
+		Shares:         []int32{1},
+		Amounts:        []int64{minor},
+	}
+
+	if ok, err := r.recordPayment(c, payment, parts); !ok {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "settlement has been recorded",
+		"id":      payment.ID,
+	})
+}
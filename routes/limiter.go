@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Trisamudrisvara/goTrip/config"
+)
+
+// rateLimit limits requests of a route group to the given policy
+// requests are counted per user when authenticated, per IP otherwise
+// and reported in RateLimit-* headers
+func (r *Repo) rateLimit(group string, policy config.Policy) fiber.Handler {
+	handler := limiter.New(limiter.Config{
+		Max:        policy.Max,
+		Expiration: policy.Expiration,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return group + ":" + limiterKey(c)
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(
+				&fiber.Map{"error": "too many requests"})
+		},
+		Storage: r.LimiterStorage,
+	})
+
+	limit := strconv.Itoa(policy.Max)
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Max, int(policy.Expiration.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		err := handler(c)
+
+		// fiber uses the X-RateLimit-* names, rename them to
+		// the RateLimit-* ones from the IETF draft
+		res := &c.Response().Header
+		remaining := string(res.Peek("X-RateLimit-Remaining"))
+		reset := string(res.Peek("X-RateLimit-Reset"))
+		res.Del("X-RateLimit-Limit")
+		res.Del("X-RateLimit-Remaining")
+		res.Del("X-RateLimit-Reset")
+
+		// when the limit is reached only Retry-After is set
+		if remaining == "" {
+			remaining = "0"
+			reset = string(res.Peek(fiber.HeaderRetryAfter))
+		}
+
+		c.Set("RateLimit-Limit", limit)
+		c.Set("RateLimit-Remaining", remaining)
+		c.Set("RateLimit-Reset", reset)
+		c.Set("RateLimit-Policy", policyHeader)
+
+		return err
+	}
+}
+
+// readWriteLimit applies the read policy to safe methods
+// and the write policy to everything else
+func (r *Repo) readWriteLimit() fiber.Handler {
+	read := r.rateLimit("read", r.Config.RateLimit.Read)
+	write := r.rateLimit("write", r.Config.RateLimit.Write)
+
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return read(c)
+		}
+		return write(c)
+	}
+}
+
+// limiterKey identifies the client, using the user id from a valid JWT
+// so that users aren't throttled by others sharing their IP
+func limiterKey(c *fiber.Ctx) string {
+	auth := c.Get(fiber.HeaderAuthorization)
+	if tokenString, ok := strings.CutPrefix(auth, "Bearer "); ok {
+		// invalid tokens fall back to the IP so they can't be used
+		// to get a fresh bucket on every request
+		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+			return secret, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+		if err == nil {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				if id, ok := claims["id"].(string); ok && id != "" {
+					return "user:" + id
+				}
+			}
+		}
+	}
+
+	return "ip:" + c.IP()
+}
@@ -0,0 +1,416 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+	"github.com/Trisamudrisvara/goTrip/media"
+)
+
+// longest side of generated thumbnails in pixels
+const thumbnailSize = 320
+
+var (
+	fiberInvalidMediaID   = &fiber.Map{"error": "invalid media id"}
+	fiberUnsupportedMedia = &fiber.Map{"error": "file must be a jpeg, png, gif or webp image"}
+	fiberInvalidImage     = &fiber.Map{"error": "file isn't a valid image or is too large to process"}
+	fiberInvalidMediaIDs  = &fiber.Map{"error": "ids must list every media file of the destination exactly once"}
+)
+
+// error returned by postgres when the destination doesn't exist
+const mediaDestinationFkeyError = "ERROR: insert or update on table \"media_file\" violates foreign key constraint \"media_file_destination_id_fkey\" (SQLSTATE 23503)"
+
+// mediaFile is a media file along with the URLs it can be fetched from
+type mediaFile struct {
+	db.MediaFile
+	URL          string
+	ThumbnailURL string
+}
+
+// withURLs adds signed URLs to each media file
+func (r *Repo) withURLs(files []db.MediaFile) ([]mediaFile, error) {
+	out := make([]mediaFile, len(files))
+
+	for i, file := range files {
+		url, err := r.Media.URL(file.Key, r.Config.Media.URLExpiry)
+		if err != nil {
+			return nil, err
+		}
+
+		thumbnailURL, err := r.Media.URL(file.ThumbnailKey, r.Config.Media.URLExpiry)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i] = mediaFile{MediaFile: file, URL: url, ThumbnailURL: thumbnailURL}
+	}
+
+	return out, nil
+}
+
+// listMedia retrieves the gallery of a destination in order
+func (r *Repo) listMedia(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	files, err := r.Queries.ListMediaFiles(r.Ctx, destinationID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting media in ListMediaFiles db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(files) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no media found"})
+	}
+
+	data, err := r.withURLs(files)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in signing media urls", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &data,
+	})
+}
+
+// uploadMedia adds an image to the end of a destination's gallery
+//
+// The content type is sniffed from the file itself and a JPEG thumbnail
+// is generated. Set cover=true to make it the destination's cover image
+func (r *Repo) uploadMedia(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	maxSize := r.Config.Media.MaxSize
+	fiberTooLarge := &fiber.Map{"error": fmt.Sprintf("file must be at most %d bytes", maxSize)}
+
+	if header.Size > int64(maxSize) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiberTooLarge)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logging.FromCtx(c).Error("error in opening uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer file.Close()
+
+	// don't trust the size in the header either
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxSize)+1))
+	if err != nil {
+		logging.FromCtx(c).Error("error in reading uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(data) > maxSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiberTooLarge)
+	}
+
+	contentType, ext, err := media.Sniff(data)
+	if err != nil {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiberUnsupportedMedia)
+	}
+
+	thumbnail, width, height, err := media.Thumbnail(data, thumbnailSize)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidImage)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	id := uuid.New()
+	prefix := fmt.Sprintf("destinations/%s/%s", uuid.UUID(destinationID.Bytes), id)
+
+	mediaFile := db.CreateMediaFileParams{
+		ID:            pgtype.UUID{Bytes: id, Valid: true},
+		DestinationID: destinationID,
+		Key:           prefix + ext,
+		ThumbnailKey:  prefix + "_thumb.jpg",
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		Width:         int32(width),
+		Height:        int32(height),
+		Caption:       c.FormValue("caption"),
+	}
+
+	err = r.Media.Put(r.Ctx, mediaFile.Key, bytes.NewReader(data), mediaFile.Size, contentType)
+	if err == nil {
+		err = r.Media.Put(r.Ctx, mediaFile.ThumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg")
+	}
+
+	if err != nil {
+		r.deleteStoredMedia(c, mediaFile.Key, mediaFile.ThumbnailKey)
+		logging.FromCtx(c).Error("error in storing media", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	err = r.Queries.CreateMediaFile(r.Ctx, mediaFile)
+
+	if err != nil {
+		r.deleteStoredMedia(c, mediaFile.Key, mediaFile.ThumbnailKey)
+
+		if err.Error() == mediaDestinationFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		logging.FromCtx(c).Error("error in creating media in CreateMediaFile db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if c.FormValue("cover") == "true" {
+		_, err = r.Queries.SetMediaCover(r.Ctx, db.SetMediaCoverParams{
+			ID:            mediaFile.ID,
+			DestinationID: destinationID,
+		})
+
+		if err != nil {
+			logging.FromCtx(c).Error("error in setting cover in SetMediaCover db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "media has been added",
+		"id":      mediaFile.ID,
+	})
+}
+
+// deleteStoredMedia removes files from the media store, failures are
+// only logged since the files are unreachable without their db row
+func (r *Repo) deleteStoredMedia(c *fiber.Ctx, keys ...string) {
+	for _, key := range keys {
+		if err := r.Media.Delete(r.Ctx, key); err != nil {
+			logging.FromCtx(c).Warn("error in deleting stored media", "key", key, "err", err)
+		}
+	}
+}
+
+// updateMedia changes the caption of a media file, if set, and makes
+// it the cover image with cover=true
+func (r *Repo) updateMedia(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "media", fiberInvalidMediaID)
+	if !ok {
+		return err
+	}
+
+	caption := c.FormValue("caption")
+	cover := c.FormValue("cover") == "true"
+
+	if caption == "" && !cover {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	if caption != "" {
+		rows, err := r.Queries.UpdateMediaCaption(r.Ctx, db.UpdateMediaCaptionParams{
+			ID:            id,
+			DestinationID: destinationID,
+			Caption:       caption,
+		})
+
+		if err != nil {
+			logging.FromCtx(c).Error("error in updating caption in UpdateMediaCaption db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		if rows == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMediaID)
+		}
+	}
+
+	if cover {
+		rows, err := r.Queries.SetMediaCover(r.Ctx, db.SetMediaCoverParams{
+			ID:            id,
+			DestinationID: destinationID,
+		})
+
+		if err != nil {
+			logging.FromCtx(c).Error("error in setting cover in SetMediaCover db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		if rows == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMediaID)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "media has been updated"})
+}
+
+// reorderMedia sets the gallery order from a comma separated list of
+// every media id of the destination
+func (r *Repo) reorderMedia(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	idsValue := c.FormValue("ids")
+	if idsValue == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	files, err := r.Queries.ListMediaFiles(r.Ctx, destinationID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting media in ListMediaFiles db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	existing := make([]pgtype.UUID, len(files))
+	for i, file := range files {
+		existing[i] = file.ID
+	}
+
+	ids, ok := orderedIDs(idsValue, existing)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMediaIDs)
+	}
+
+	_, err = r.Queries.ReorderMediaFiles(r.Ctx, db.ReorderMediaFilesParams{
+		Ids:           ids,
+		DestinationID: destinationID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in reordering media in ReorderMediaFiles db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "media has been reordered"})
+}
+
+// orderedIDs parses a comma separated list of uuids that must contain
+// every one of existing exactly once
+func orderedIDs(value string, existing []pgtype.UUID) ([]pgtype.UUID, bool) {
+	remaining := make(map[pgtype.UUID]bool, len(existing))
+	for _, id := range existing {
+		remaining[id] = true
+	}
+
+	var ids []pgtype.UUID
+	for _, part := range strings.Split(value, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, false
+		}
+
+		pgID := pgtype.UUID{Bytes: id, Valid: true}
+		if !remaining[pgID] {
+			return nil, false
+		}
+
+		delete(remaining, pgID)
+		ids = append(ids, pgID)
+	}
+
+	return ids, len(remaining) == 0
+}
+
+// deleteMedia removes a media file and its thumbnail
+func (r *Repo) deleteMedia(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "media", fiberInvalidMediaID)
+	if !ok {
+		return err
+	}
+
+	keys, err := r.Queries.DeleteMediaFile(r.Ctx, db.DeleteMediaFileParams{
+		ID:            id,
+		DestinationID: destinationID,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMediaID)
+		}
+
+		logging.FromCtx(c).Error("error in deleting media in DeleteMediaFile db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	r.deleteStoredMedia(c, keys.Key, keys.ThumbnailKey)
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "media has been deleted"})
+}
+
+// mediaRoute returns the route of the files of the local media store,
+// base is MEDIA_BASE_URL, a path or an absolute URL
+func mediaRoute(base string) string {
+	if u, err := url.Parse(base); err == nil {
+		base = u.Path
+	}
+	return strings.TrimSuffix(base, "/") + "/*"
+}
+
+// serveMedia serves files of the local media store through signed URLs
+// S3 URLs are presigned and point at the bucket directly
+func (r *Repo) serveMedia(c *fiber.Ctx) error {
+	local, ok := r.Media.(*media.Local)
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	key := c.Params("*")
+
+	ttl, err := local.Verify(key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(
+			&fiber.Map{"error": "invalid or expired signature"})
+	}
+
+	path, err := local.Path(key)
+	if errors.Is(err, media.ErrInvalidKey) {
+		return fiber.ErrNotFound
+	}
+
+	// keys are never reused, so files can be cached until the URL expires
+	c.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.Itoa(int(ttl.Seconds()))+", immutable")
+
+	return c.SendFile(path)
+}
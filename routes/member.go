@@ -0,0 +1,441 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var (
+	fiberInvalidMemberRole   = &fiber.Map{"error": "role must be editor or viewer"}
+	fiberInvalidEmail        = &fiber.Map{"error": "invalid email"}
+	fiberInvalidMemberID     = &fiber.Map{"error": "invalid member id"}
+	fiberInvalidInvitationID = &fiber.Map{"error": "invalid invitation id"}
+	fiberInvalidInvitation   = &fiber.Map{"error": "invitation doesn't exist, was already answered or is for another email"}
+	fiberInvitationExists    = &fiber.Map{"error": "this email already has a pending invitation to the trip"}
+	fiberInvitationNotSent   = &fiber.Map{"error": "invitation email couldn't be sent, try again later"}
+)
+
+// error returned by postgres when the email already has a pending invitation
+const tripInvitationUniqueError = "ERROR: duplicate key value violates unique constraint \"trip_invitation_pending_idx\" (SQLSTATE 23505)"
+
+// what each role can do includes everything the ones below it can
+var tripRoles = map[string]int{
+	"viewer": 1,
+	"editor": 2,
+	"owner":  3,
+}
+
+// tripAccess checks that the logged in user has at least the given role on
+// a trip, admins can do anything. Trips the user isn't a member of are
+// reported as invalid so their existence isn't revealed
+// writes the error response itself if ok is false
+func (r *Repo) tripAccess(c *fiber.Ctx, id pgtype.UUID, need string, invalid *fiber.Map) (ok bool, err error) {
+	if isAdmin(c) {
+		return true, nil
+	}
+
+	user, ok := userID(c)
+	if !ok {
+		return false, c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	role, err := r.Queries.GetTripRole(r.Ctx, db.GetTripRoleParams{
+		TripID: id,
+		UserID: user,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return false, c.Status(fiber.StatusBadRequest).JSON(invalid)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip role in GetTripRole db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if tripRoles[role] < tripRoles[need] {
+		return false, c.Status(fiber.StatusUnauthorized).JSON(fiberUnauthorizedError)
+	}
+
+	return true, nil
+}
+
+// parseMemberRole reads the role form value, viewer by default
+func parseMemberRole(c *fiber.Ctx) (role string, ok bool) {
+	role = c.FormValue("role", "viewer")
+	return role, role == "editor" || role == "viewer"
+}
+
+// listTripMembers retrieves the members of a trip, the owner first, along
+// with the pending invitations if the logged in user is the owner
+func (r *Repo) listTripMembers(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	members, err := r.Queries.ListTripMembers(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting trip members in ListTripMembers db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	data := fiber.Map{"Members": members}
+
+	owner := isAdmin(c)
+	user, _ := userID(c)
+	for _, member := range members {
+		if member.UserID == user && member.Role == "owner" {
+			owner = true
+		}
+	}
+
+	if owner {
+		invitations, err := r.Queries.ListTripInvitations(r.Ctx, id)
+
+		if err != nil {
+			logging.FromCtx(c).Error("error in getting trip invitations in ListTripInvitations db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		data["Invitations"] = invitations
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &data,
+	})
+}
+
+// inviteTripMember invites an email to join a trip as an editor or a
+// viewer, owner only. The token the invitee answers with is only sent to
+// that email, so whoever changes their account to it can't take the
+// invitation over
+func (r *Repo) inviteTripMember(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	email := c.FormValue("email")
+	if email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidEmail)
+	}
+
+	role, ok := parseMemberRole(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMemberRole)
+	}
+
+	if ok, err := r.tripAccess(c, id, "owner", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		logging.FromCtx(c).Error("error in generating invitation token", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// left unset if the token has no user id
+	inviter, _ := userID(c)
+
+	invitation := db.CreateTripInvitationParams{
+		ID: pgtype.UUID{
+			Bytes: uuid.New(),
+			Valid: true,
+		},
+		TripID:    id,
+		Email:     email,
+		Role:      role,
+		Token:     base64.RawURLEncoding.EncodeToString(b),
+		InvitedBy: inviter,
+	}
+
+	trip, err := r.Queries.GetTrip(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+		}
+
+		logging.FromCtx(c).Error("error in getting trip in GetTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	err = r.Queries.CreateTripInvitation(r.Ctx, invitation)
+
+	if err != nil {
+		if err.Error() == tripInvitationUniqueError {
+			return c.Status(fiber.StatusConflict).JSON(fiberInvitationExists)
+		}
+
+		logging.FromCtx(c).Error("error in creating trip invitation in CreateTripInvitation db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	invitationURL := c.BaseURL() + "/invitation/" + invitation.Token
+	body := fmt.Sprintf("You have been invited to join the trip %q as %s.\n\n"+
+		"Log in with this email, then accept the invitation with POST %s/accept "+
+		"or decline it with POST %s/decline.\n", trip.Name, invitation.Role, invitationURL, invitationURL)
+
+	// sent once the invitation is saved so no connection is held meanwhile,
+	// an invitation that couldn't be sent is withdrawn so it can be retried
+	if err := r.Mailer.Send(r.Ctx, email, "Invitation to "+trip.Name, body); err != nil {
+		logging.FromCtx(c).Error("error in sending invitation email", "err", err)
+
+		_, err = r.Queries.DeleteTripInvitation(r.Ctx, db.DeleteTripInvitationParams{
+			ID:     invitation.ID,
+			TripID: id,
+		})
+		if err != nil {
+			logging.FromCtx(c).Error("error in deleting trip invitation in DeleteTripInvitation db function", "err", err)
+		}
+
+		return c.Status(fiber.StatusBadGateway).JSON(fiberInvitationNotSent)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": email + " has been invited",
+		"id":      invitation.ID,
+	})
+}
+
+// deleteTripInvitation withdraws a pending invitation, owner only
+func (r *Repo) deleteTripInvitation(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	invitationID, ok, err := paramUUID(c, "invitation", fiberInvalidInvitationID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "owner", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteTripInvitation(r.Ctx, db.DeleteTripInvitationParams{
+		ID:     invitationID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting trip invitation in DeleteTripInvitation db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidInvitationID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "invitation has been withdrawn"})
+}
+
+// updateTripMember changes the role of a member, the owner's can't be
+// changed, owner only
+func (r *Repo) updateTripMember(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	memberID, ok, err := paramUUID(c, "user", fiberInvalidMemberID)
+	if !ok {
+		return err
+	}
+
+	role, ok := parseMemberRole(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMemberRole)
+	}
+
+	if ok, err := r.tripAccess(c, id, "owner", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.UpdateTripMemberRole(r.Ctx, db.UpdateTripMemberRoleParams{
+		TripID: id,
+		UserID: memberID,
+		Role:   role,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating trip member in UpdateTripMemberRole db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMemberID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "member is now " + role})
+}
+
+// removeTripMember removes a member from a trip, by the owner or by the
+// member leaving, the owner can't leave
+func (r *Repo) removeTripMember(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	memberID, ok, err := paramUUID(c, "user", fiberInvalidMemberID)
+	if !ok {
+		return err
+	}
+
+	need := "owner"
+	if user, ok := userID(c); ok && user == memberID {
+		need = "viewer"
+	}
+
+	if ok, err := r.tripAccess(c, id, need, fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.RemoveTripMember(r.Ctx, db.RemoveTripMemberParams{
+		TripID: id,
+		UserID: memberID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in removing trip member in RemoveTripMember db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidMemberID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "member has been removed"})
+}
+
+// listInvitations retrieves the pending trip invitations sent to the
+// email of the logged in user, without their tokens, which are only in the
+// emails
+func (r *Repo) listInvitations(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	invitations, err := r.Queries.ListUserInvitations(r.Ctx, user)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting invitations in ListUserInvitations db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(invitations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no invitations found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &invitations,
+	})
+}
+
+// acceptInvitation makes the logged in user a member of the trip they
+// were invited to
+func (r *Repo) acceptInvitation(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	invitation, err := qtx.RespondTripInvitation(r.Ctx, db.RespondTripInvitationParams{
+		Status: "accepted",
+		Token:  c.Params("token"),
+		UserID: user,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidInvitation)
+		}
+
+		logging.FromCtx(c).Error("error in accepting invitation in RespondTripInvitation db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	err = qtx.AddTripMember(r.Ctx, db.AddTripMemberParams{
+		TripID: invitation.TripID,
+		UserID: user,
+		Role:   invitation.Role,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in adding trip member in AddTripMember db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "invitation has been accepted",
+		"id":      invitation.TripID,
+	})
+}
+
+// declineInvitation turns down an invitation sent to the logged in user
+func (r *Repo) declineInvitation(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	_, err := r.Queries.RespondTripInvitation(r.Ctx, db.RespondTripInvitationParams{
+		Status: "declined",
+		Token:  c.Params("token"),
+		UserID: user,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidInvitation)
+		}
+
+		logging.FromCtx(c).Error("error in declining invitation in RespondTripInvitation db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "invitation has been declined"})
+}
@@ -0,0 +1,194 @@
+package routes
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var fiberInvalidPlaceID = &fiber.Map{"error": "invalid place id"}
+
+// error returned by postgres when place_id doesn't exist
+const destinationPlaceFkeyError = "ERROR: insert or update on table \"destination\" violates foreign key constraint \"destination_place_id_fkey\" (SQLSTATE 23503)"
+
+// placeCrumb is one step of a breadcrumb path, country first
+type placeCrumb struct {
+	ID          pgtype.UUID
+	Kind        string
+	Name        string
+	CountryCode string
+}
+
+// parsePlaceID reads the optional place_id form value
+// returns ok false if it is set but isn't a valid uuid
+func parsePlaceID(c *fiber.Ctx) (id pgtype.UUID, ok bool) {
+	return placeIDValue(c.FormValue("place_id"))
+}
+
+// placeIDValue validates an optional place id
+func placeIDValue(placeID string) (id pgtype.UUID, ok bool) {
+	if placeID == "" {
+		return id, true
+	}
+
+	uuid, err := uuid.Parse(placeID)
+	if err != nil {
+		return id, false
+	}
+
+	return pgtype.UUID{Bytes: uuid, Valid: true}, true
+}
+
+// placePaths returns the breadcrumb path of each of the given places
+func (r *Repo) placePaths(ids []pgtype.UUID) (map[pgtype.UUID][]placeCrumb, error) {
+	paths := make(map[pgtype.UUID][]placeCrumb, len(ids))
+
+	var valid []pgtype.UUID
+	for _, id := range ids {
+		if id.Valid {
+			valid = append(valid, id)
+		}
+	}
+	if len(valid) == 0 {
+		return paths, nil
+	}
+
+	places, err := r.Queries.ListPlaceAncestors(r.Ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[pgtype.UUID]db.ListPlaceAncestorsRow, len(places))
+	for _, place := range places {
+		byID[place.ID] = place
+	}
+
+	for _, id := range valid {
+		if _, ok := paths[id]; ok {
+			continue
+		}
+
+		// walk up to the country then reverse
+		var path []placeCrumb
+		for place, ok := byID[id]; ok; place, ok = byID[place.ParentID] {
+			path = append(path, placeCrumb{
+				ID:          place.ID,
+				Kind:        place.Kind,
+				Name:        place.Name,
+				CountryCode: place.CountryCode,
+			})
+		}
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+
+		paths[id] = path
+	}
+
+	return paths, nil
+}
+
+// listCountries returns the top level of the place hierarchy
+func (r *Repo) listCountries(c *fiber.Ctx) error {
+	countries, err := r.Queries.ListCountries(r.Ctx)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting countries in ListCountries db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(countries) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no countries found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &countries,
+	})
+}
+
+// parsePlaceParam parses the :id param and returns the place
+// writes the error response itself if ok is false
+func (r *Repo) parsePlaceParam(c *fiber.Ctx) (place db.Place, ok bool, err error) {
+	uuid, err := uuid.Parse(c.Params("id"))
+
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid UUID") {
+			return place, false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
+		}
+
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
+		return place, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	place, err = r.Queries.GetPlace(r.Ctx, pgtype.UUID{Bytes: uuid, Valid: true})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return place, false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
+		}
+
+		logging.FromCtx(c).Error("error in getting place in GetPlace db function", "err", err)
+		return place, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return place, true, nil
+}
+
+// getPlace retrieves a single place along with its breadcrumb path
+func (r *Repo) getPlace(c *fiber.Ctx) error {
+	place, ok, err := r.parsePlaceParam(c)
+	if !ok {
+		return err
+	}
+
+	paths, err := r.placePaths([]pgtype.UUID{place.ID})
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting place path in ListPlaceAncestors db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &struct {
+			db.Place
+			Path []placeCrumb
+		}{place, paths[place.ID]},
+	})
+}
+
+// placeChildren returns the places and destinations directly under a place
+func (r *Repo) placeChildren(c *fiber.Ctx) error {
+	place, ok, err := r.parsePlaceParam(c)
+	if !ok {
+		return err
+	}
+
+	places, err := r.Queries.ListChildPlaces(r.Ctx, place.ID)
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting child places in ListChildPlaces db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	destinations, err := r.Queries.ListPlaceDestinations(r.Ctx, place.ID)
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting destinations in ListPlaceDestinations db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(places) == 0 && len(destinations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no children found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Places":       places,
+			"Destinations": destinations,
+		},
+	})
+}
@@ -0,0 +1,284 @@
+package routes
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var (
+	fiberInvalidReviewID     = &fiber.Map{"error": "invalid review id"}
+	fiberInvalidRating       = &fiber.Map{"error": "rating must be a whole number between 1 and 5"}
+	fiberInvalidVisitDate    = &fiber.Map{"error": "visit_date must be a date in the format 2006-01-02 and not in the future"}
+	fiberInvalidReviewStatus = &fiber.Map{"error": "status must be visible, flagged or hidden"}
+	fiberReviewExists        = &fiber.Map{"error": "you have already reviewed this destination"}
+	fiberLoginAgain          = &fiber.Map{"error": "token is missing the user id, please login again"}
+)
+
+const (
+	// errors returned by postgres
+	reviewUniqueError          = "ERROR: duplicate key value violates unique constraint \"review_destination_id_user_id_key\" (SQLSTATE 23505)"
+	reviewDestinationFkeyError = "ERROR: insert or update on table \"review\" violates foreign key constraint \"review_destination_id_fkey\" (SQLSTATE 23503)"
+)
+
+// rating is the aggregate of a destination's reviews, hidden ones excluded
+type rating struct {
+	AverageRating float64
+	ReviewCount   int64
+}
+
+// parseReview reads and validates the review form values
+// returns the error to respond with if they are invalid
+func parseReview(c *fiber.Ctx) (review db.Review, fiberErr *fiber.Map) {
+	review.Title = c.FormValue("title")
+	review.Body = c.FormValue("body")
+	ratingValue := c.FormValue("rating")
+
+	// if any of the form data is missing return error
+	if review.Title == "" || review.Body == "" || ratingValue == "" {
+		return review, fiberUndefinedParamError
+	}
+
+	stars, err := strconv.Atoi(ratingValue)
+	if err != nil || stars < 1 || stars > 5 {
+		return review, fiberInvalidRating
+	}
+	review.Rating = int32(stars)
+
+	// optional
+	if visitDate := c.FormValue("visit_date"); visitDate != "" {
+		date, err := time.Parse(time.DateOnly, visitDate)
+		if err != nil || date.After(time.Now()) {
+			return review, fiberInvalidVisitDate
+		}
+		review.VisitDate = pgtype.Date{Time: date, Valid: true}
+	}
+
+	return review, nil
+}
+
+// listReviews retrieves the reviews of a destination, newest first
+func (r *Repo) listReviews(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	reviews, err := r.Queries.ListDestinationReviews(r.Ctx, destinationID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting reviews in ListDestinationReviews db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(reviews) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no reviews found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &reviews,
+	})
+}
+
+// createReview adds the logged in user's review of a destination
+func (r *Repo) createReview(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	review, fiberErr := parseReview(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	id := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	err = r.Queries.CreateReview(r.Ctx, db.CreateReviewParams{
+		ID:            id,
+		DestinationID: destinationID,
+		UserID:        user,
+		Rating:        review.Rating,
+		Title:         review.Title,
+		Body:          review.Body,
+		VisitDate:     review.VisitDate,
+	})
+
+	if err != nil {
+		switch err.Error() {
+		case reviewUniqueError:
+			return c.Status(fiber.StatusConflict).JSON(fiberReviewExists)
+		case reviewDestinationFkeyError:
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		logging.FromCtx(c).Error("error in creating review in CreateReview db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "review has been added",
+		"id":      id,
+	})
+}
+
+// updateReview modifies a review, only its author can
+func (r *Repo) updateReview(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "review", fiberInvalidReviewID)
+	if !ok {
+		return err
+	}
+
+	review, fiberErr := parseReview(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.UpdateReview(r.Ctx, db.UpdateReviewParams{
+		ID:            id,
+		DestinationID: destinationID,
+		UserID:        user,
+		Rating:        review.Rating,
+		Title:         review.Title,
+		Body:          review.Body,
+		VisitDate:     review.VisitDate,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in updating review in UpdateReview db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// either doesn't exist or isn't the user's
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidReviewID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "review has been updated"})
+}
+
+// deleteReview removes a review, only its author can
+func (r *Repo) deleteReview(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "review", fiberInvalidReviewID)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteReview(r.Ctx, db.DeleteReviewParams{
+		ID:            id,
+		DestinationID: destinationID,
+		UserID:        user,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting review in DeleteReview db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidReviewID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "review has been deleted"})
+}
+
+// moderateReview sets the status of a review, admin only
+// hidden reviews aren't listed or counted in ratings
+func (r *Repo) moderateReview(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	id, ok, err := paramUUID(c, "review", fiberInvalidReviewID)
+	if !ok {
+		return err
+	}
+
+	status := c.FormValue("status")
+	if status != "visible" && status != "flagged" && status != "hidden" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidReviewStatus)
+	}
+
+	rows, err := r.Queries.SetReviewStatus(r.Ctx, db.SetReviewStatusParams{
+		ID:            id,
+		DestinationID: destinationID,
+		Status:        status,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in moderating review in SetReviewStatus db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidReviewID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "review is now " + status})
+}
+
+// listFlaggedReviews retrieves the reviews waiting for moderation, admin only
+func (r *Repo) listFlaggedReviews(c *fiber.Ctx) error {
+	reviews, err := r.Queries.ListFlaggedReviews(r.Ctx)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting flagged reviews in ListFlaggedReviews db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(reviews) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no flagged reviews found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &reviews,
+	})
+}
@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var fiberInvalidRevision = &fiber.Map{"error": "invalid revision version"}
+
+// destination fields compared between revisions, in the order they are shown
+var revisionFields = []string{
+	"name", "description", "attraction", "latitude", "longitude",
+	"country", "region", "city", "place_id",
+}
+
+// fieldChange is the value of a destination field before and after a revision
+type fieldChange struct {
+	Field string
+	From  any
+	To    any
+}
+
+// revision is a recorded state of a destination along with what changed
+type revision struct {
+	Version    int32
+	Action     string
+	EditorID   pgtype.UUID
+	EditorName pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	Snapshot   map[string]any
+	// compared to the previous version, null for the first recorded
+	// revision of destinations created before revisions were kept
+	Changes []fieldChange
+}
+
+// revise runs change in a transaction and records the resulting state of
+// the destination as a revision made by the logged in user
+// change returns pgx.ErrNoRows if the destination doesn't exist
+func (r *Repo) revise(c *fiber.Ctx, id pgtype.UUID, action string, change func(q *db.Queries) error) error {
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	if err := change(qtx); err != nil {
+		return err
+	}
+
+	// the editor is left unset if the token has no user id
+	editor, _ := userID(c)
+
+	err = qtx.CreateDestinationRevision(r.Ctx, db.CreateDestinationRevisionParams{
+		Action:        action,
+		EditorID:      editor,
+		DestinationID: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(r.Ctx)
+}
+
+// diff returns the fields that differ between two snapshots
+func diff(from, to map[string]any) []fieldChange {
+	changes := []fieldChange{}
+	for _, field := range revisionFields {
+		if !reflect.DeepEqual(from[field], to[field]) {
+			changes = append(changes, fieldChange{field, from[field], to[field]})
+		}
+	}
+	return changes
+}
+
+// listRevisions retrieves the history of a destination, newest first,
+// with the fields each revision changed, admin only
+func (r *Repo) listRevisions(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.ListDestinationRevisions(r.Ctx, destinationID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting revisions in ListDestinationRevisions db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(rows) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no revisions found"})
+	}
+
+	revisions := make([]revision, len(rows))
+	var previous map[string]any
+	for i, row := range rows {
+		var snapshot map[string]any
+		if err := json.Unmarshal(row.Snapshot, &snapshot); err != nil {
+			logging.FromCtx(c).Error("error in decoding revision snapshot", "version", row.Version, "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		var changes []fieldChange
+		if previous != nil {
+			changes = diff(previous, snapshot)
+		} else if row.Action == "create" {
+			changes = diff(map[string]any{}, snapshot)
+		}
+
+		// newest first
+		revisions[len(rows)-1-i] = revision{
+			Version:    row.Version,
+			Action:     row.Action,
+			EditorID:   row.EditorID,
+			EditorName: row.EditorName,
+			CreatedAt:  row.CreatedAt,
+			Snapshot:   snapshot,
+			Changes:    changes,
+		}
+		previous = snapshot
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &revisions,
+	})
+}
+
+// rollbackRevision sets a destination back to the state of one of its
+// revisions, recorded as a new revision, admin only
+func (r *Repo) rollbackRevision(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil || version < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidRevision)
+	}
+
+	// destinations in the trash have to be restored first
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	err = r.revise(c, destinationID, "rollback", func(q *db.Queries) error {
+		rows, err := q.RollbackDestination(r.Ctx, db.RollbackDestinationParams{
+			DestinationID: destinationID,
+			Version:       int32(version),
+		})
+		if err == nil && rows == 0 {
+			return pgx.ErrNoRows
+		}
+		return err
+	})
+
+	if err != nil {
+		switch err.Error() {
+		case "no rows in result set":
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidRevision)
+		case destinationUniqueError:
+			return c.Status(fiber.StatusConflict).JSON(fiberDestinationExists)
+		}
+
+		logging.FromCtx(c).Error("error in rolling back destination in RollbackDestination db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "destination has been rolled back to version " + strconv.Itoa(version)})
+}
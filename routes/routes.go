@@ -2,12 +2,15 @@ package routes
 
 import (
 	"context"
-	"os"
 
 	"github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/Trisamudrisvara/goTrip/config"
 	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/mailer"
+	"github.com/Trisamudrisvara/goTrip/media"
 )
 
 type CheckIfOwner bool
@@ -15,6 +18,15 @@ type CheckIfOwner bool
 type Repo struct {
 	Ctx     context.Context
 	Queries *db.Queries
+	Config  *config.Config
+	// for queries that have to run in a transaction
+	Pool *pgxpool.Pool
+	// shared between instances, nil uses in memory storage
+	LimiterStorage fiber.Storage
+	// destination photos
+	Media media.Store
+	// trip invitations
+	Mailer mailer.Mailer
 }
 
 var (
@@ -31,19 +43,26 @@ var (
 )
 
 func (r *Repo) SetupRoutes(app *fiber.App) {
-	// loads neccessary environment variables
-	loadEnvVars()
+	// JWT signing key and owner's uuid
+	secret = []byte(r.Config.Secret)
+	ownerUuid = r.Config.OwnerUUID
+	// locales destination content is translated to
+	locales = newLocaleMatcher(r.Config.I18n)
 
 	// Prometheus
 	app.Get("/ping", func(c *fiber.Ctx) error {
 		return c.SendString("pong")
 	})
 
-	// Auth
-	login := app.Group("/login")
+	// Auth, strictly rate limited
+	authLimit := r.rateLimit("auth", r.Config.RateLimit.Auth)
+	login := app.Group("/login", authLimit)
 	login.Get("", getCsrfToken)
 	login.Post("", r.login)
-	app.Post("/register", r.register)
+	app.Post("/register", authLimit, r.register)
+
+	// Rate limit everything below, reads more generously than writes
+	app.Use(r.readWriteLimit())
 
 	// For testing csrf
 	app.Post("", hello)
@@ -51,12 +70,41 @@ func (r *Repo) SetupRoutes(app *fiber.App) {
 	// initializing /destination route
 	destination := app.Group("/destination")
 	destination.Get("", r.ListDestinations)
+	destination.Get("/nearby", r.nearbyDestinations)
 	destination.Get("/:id", r.getDestination)
+	destination.Get("/:id/attractions", r.listAttractions)
+	destination.Get("/:id/attractions/:attraction", r.getAttraction)
+	destination.Get("/:id/media", r.listMedia)
+	destination.Get("/:id/reviews", r.listReviews)
+
+	// files of the local media store, signed URLs under MEDIA_BASE_URL
+	app.Get(mediaRoute(r.Config.Media.BaseURL), r.serveMedia)
+
+	// initializing /place route, country -> region -> city
+	place := app.Group("/place")
+	place.Get("", r.listCountries)
+	place.Get("/:id", r.getPlace)
+	place.Get("/:id/children", r.placeChildren)
+
+	// tags used to filter destinations
+	tag := app.Group("/tag")
+	tag.Get("", r.listTags)
 
 	// initializing /trip route
 	trip := app.Group("/trip")
-	trip.Get("", r.ListTrips)
-	trip.Get("/:id", r.getTrip)
+
+	// read only view of a trip shared by its owner, may need a password
+	app.Get(sharedTripPath+":token", r.sharedTrip)
+
+	// subscribable calendar of a user's trips, the token replaces the JWT
+	app.Get(calendarFeedPath+":token.ics", r.calendarFeed)
+
+	// streamed exports, trips need a token
+	export := app.Group("/export")
+	export.Get("/destinations", r.exportDestinations)
+
+	// read only view of a wishlist shared by its owner
+	app.Get(sharedWishlistPath+":token", r.sharedWishlist)
 
 	// JWT Middleware
 	app.Use(jwtware.New(jwtware.Config{
@@ -70,6 +118,8 @@ func (r *Repo) SetupRoutes(app *fiber.App) {
 	// usr.Get("", aboutUser) // GET isn't protected by CSRF
 	usr.Post("", aboutUser)
 	usr.Put("", r.updateUser)
+	usr.Post("/calendar", r.createCalendarFeed)
+	usr.Delete("/calendar", r.deleteCalendarFeed)
 
 	// only owner can promote user to admin
 	// or demote admin to user with additional admin=demote in form
@@ -77,6 +127,112 @@ func (r *Repo) SetupRoutes(app *fiber.App) {
 	checkOwner := CheckIfOwner(true)
 	app.Post("/admin", checkOwner.checkIfAdmin, r.promoteAdmin)
 
+	// reviews, one per user per destination, edited only by their author
+	destination.Post("/:id/reviews", r.createReview)
+	destination.Put("/:id/reviews/:review", r.updateReview)
+	destination.Delete("/:id/reviews/:review", r.deleteReview)
+
+	// trips of the logged in user, every trip for admins with all=true
+	export.Get("/trips", r.exportTrips)
+
+	// trips the logged in user is a member of, every trip for admins
+	trip.Get("", r.ListTrips)
+	// the logged in user becomes the owner of the trips they create
+	trip.Post("", r.createTrip)
+
+	// trips of the logged in user from an .ics file
+	trip.Post("/import", r.importTrips)
+
+	// trips are seen by their members, changed by editors and deleted by
+	// their owner, admins can do anything
+	// .ics before /:id, which would take the suffix as part of the id
+	trip.Get("/:id.ics", r.tripCalendar)
+	trip.Get("/:id", r.getTrip)
+	trip.Put("", r.updateTrip)
+	trip.Delete("/:id", r.deleteTrip)
+	trip.Get("/:id/members", r.listTripMembers)
+	trip.Put("/:id/members/:user", r.updateTripMember)
+	trip.Delete("/:id/members/:user", r.removeTripMember)
+	trip.Post("/:id/invitations", r.inviteTripMember)
+	trip.Delete("/:id/invitations/:invitation", r.deleteTripInvitation)
+	trip.Get("/:id/shares", r.listTripShares)
+	trip.Post("/:id/shares", r.createTripShare)
+	trip.Delete("/:id/shares/:share", r.deleteTripShare)
+	// expenses, in minor units, and the budget in the trip's home currency
+	trip.Get("/:id/expenses", r.listExpenses)
+	trip.Get("/:id/expenses/summary", r.expenseSummary)
+	trip.Post("/:id/expenses", r.createExpense)
+	trip.Put("/:id/expenses/:expense", r.updateExpense)
+	trip.Delete("/:id/expenses/:expense", r.deleteExpense)
+	trip.Put("/:id/budget", r.updateBudget)
+	// shared ledger of named participants, settled with minimal transfers
+	trip.Get("/:id/ledger/participants", r.listLedgerParticipants)
+	trip.Post("/:id/ledger/participants", r.createLedgerParticipant)
+	trip.Delete("/:id/ledger/participants/:participant", r.deleteLedgerParticipant)
+	trip.Get("/:id/ledger/payments", r.listLedgerPayments)
+	trip.Post("/:id/ledger/payments", r.createLedgerPayment)
+	trip.Delete("/:id/ledger/payments/:payment", r.deleteLedgerPayment)
+	trip.Get("/:id/ledger/balances", r.ledgerBalances)
+	trip.Get("/:id/ledger/settle-up", r.settleUp)
+	trip.Post("/:id/ledger/settlements", r.createSettlement)
+	// day by day schedule of timed activities
+	trip.Get("/:id/itinerary", r.getItinerary)
+	trip.Post("/:id/activities", r.createActivity)
+	trip.Put("/:id/activities/:activity", r.updateActivity)
+	trip.Delete("/:id/activities/:activity", r.deleteActivity)
+
+	// flights, hotels and other reservations
+	trip.Get("/:id/bookings", r.listBookings)
+	trip.Post("/:id/bookings", r.createBooking)
+	trip.Post("/:id/bookings/import", r.importBookings)
+	trip.Put("/:id/bookings/:booking", r.updateBooking)
+	trip.Delete("/:id/bookings/:booking", r.deleteBooking)
+	trip.Post("/:id/bookings/:booking/attachments", r.uploadBookingAttachment)
+	trip.Delete("/:id/bookings/:booking/attachments/:attachment", r.deleteBookingAttachment)
+
+	// packing lists and other checklists, from templates or ad hoc
+	trip.Get("/:id/checklist-templates", r.suggestChecklistTemplates)
+	trip.Get("/:id/checklists", r.listChecklists)
+	trip.Post("/:id/checklists", r.createChecklist)
+	trip.Put("/:id/checklists/:checklist", r.renameChecklist)
+	trip.Delete("/:id/checklists/:checklist", r.deleteChecklist)
+	trip.Post("/:id/checklists/:checklist/items", r.createChecklistItem)
+	trip.Put("/:id/checklists/:checklist/items/:item", r.updateChecklistItem)
+	trip.Delete("/:id/checklists/:checklist/items/:item", r.deleteChecklistItem)
+
+	// exchange rates expenses are converted with, set by admins
+	exchangeRate := app.Group("/exchange-rate")
+	exchangeRate.Get("", r.listExchangeRates)
+
+	// checklist templates of the logged in user, those made by admins are
+	// provided to everyone
+	checklistTemplate := app.Group("/checklist-template")
+	checklistTemplate.Get("", r.listChecklistTemplates)
+	checklistTemplate.Post("", r.createChecklistTemplate)
+	checklistTemplate.Get("/:id", r.getChecklistTemplate)
+	checklistTemplate.Put("/:id", r.updateChecklistTemplate)
+	checklistTemplate.Delete("/:id", r.deleteChecklistTemplate)
+
+	// trip invitations sent to the logged in user's email
+	invitation := app.Group("/invitation")
+	invitation.Get("", r.listInvitations)
+	invitation.Post("/:token/accept", r.acceptInvitation)
+	invitation.Post("/:token/decline", r.declineInvitation)
+
+	// wishlists of the logged in user, /wishlist/favorites is the default one
+	wishlist := app.Group("/wishlist")
+	wishlist.Get("", r.listWishlists)
+	wishlist.Post("", r.createWishlist)
+	wishlist.Get("/:id", r.getWishlist)
+	wishlist.Put("/:id", r.renameWishlist)
+	wishlist.Delete("/:id", r.deleteWishlist)
+	wishlist.Post("/:id/items", r.addWishlistItem)
+	wishlist.Put("/:id/items", r.reorderWishlistItems)
+	wishlist.Delete("/:id/items/:destination", r.removeWishlistItem)
+	wishlist.Post("/:id/share", r.shareWishlist)
+	wishlist.Delete("/:id/share", r.unshareWishlist)
+	wishlist.Post("/:id/trip", r.tripFromWishlist)
+
 	// checks for admin instead of owner
 	checkOwner = false
 	// Middleware to check if user is admin
@@ -88,24 +244,44 @@ func (r *Repo) SetupRoutes(app *fiber.App) {
 	destination.Post("", r.createDestination)
 	destination.Put("", r.updateDestination)
 	destination.Delete("/:id", r.deleteDestination)
+	destination.Post("/:id/attractions", r.createAttraction)
+	destination.Put("/:id/attractions/:attraction", r.updateAttraction)
+	destination.Delete("/:id/attractions/:attraction", r.deleteAttraction)
+	destination.Post("/:id/media", r.uploadMedia)
+	destination.Put("/:id/media", r.reorderMedia)
+	destination.Put("/:id/media/:media", r.updateMedia)
+	destination.Delete("/:id/media/:media", r.deleteMedia)
+	destination.Put("/:id/tags", r.setDestinationTags)
+	destination.Get("/:id/translations", r.listTranslations)
+	destination.Put("/:id/translations/:locale", r.putTranslation)
+	destination.Delete("/:id/translations/:locale", r.deleteTranslation)
+	destination.Get("/:id/revisions", r.listRevisions)
+	destination.Post("/:id/revisions/:version/rollback", r.rollbackRevision)
+	// bulk imports, large files are processed in the background
+	destination.Post("/import", r.importDestinations)
+	destination.Get("/import/:id", r.getImportJob)
 
-	// changes trip
-	trip.Post("", r.createTrip)
-	trip.Put("", r.updateTrip)
-	trip.Delete("/:id", r.deleteTrip)
+	// tag taxonomy
+	tag.Post("", r.createTag)
+	tag.Put("/:id", r.updateTag)
+	tag.Delete("/:id", r.deleteTag)
+
+	// review moderation
+	destination.Put("/:id/reviews/:review/status", r.moderateReview)
+	app.Get("/review/flagged", r.listFlaggedReviews)
+
+	// exchange rates
+	exchangeRate.Put("/:base/:quote", r.putExchangeRate)
+	exchangeRate.Delete("/:base/:quote", r.deleteExchangeRate)
+
+	// deleted destinations and trips, purged after TRASH_RETENTION_DAYS
+	trash := app.Group("/trash")
+	trash.Get("/destinations", r.listDeletedDestinations)
+	trash.Post("/destinations/:id/restore", r.restoreDestination)
+	trash.Get("/trips", r.listDeletedTrips)
+	trash.Post("/trips/:id/restore", r.restoreTrip)
 }
 
 func hello(c *fiber.Ctx) error {
 	return c.SendString("Hello World")
 }
-
-// loads neccessary environment variables
-func loadEnvVars() {
-	// Get the secret signing Key for JWT
-	secret = []byte(os.Getenv("SECRET"))
-	// Get uuid of owner
-	ownerUuid = os.Getenv("OWNER_UUID")
-
-	// log.Println(secret)
-	// log.Println(ownerUuid)
-}
@@ -0,0 +1,282 @@
+package routes
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var (
+	fiberInvalidTagID   = &fiber.Map{"error": "invalid tag id"}
+	fiberInvalidTagSlug = &fiber.Map{"error": "slug must be lowercase letters and digits separated by single hyphens, at most 64 characters"}
+	fiberTagExists      = &fiber.Map{"error": "a tag with this slug already exists"}
+	fiberUnknownTag     = &fiber.Map{"error": "unknown tag"}
+)
+
+// error returned by postgres when the slug is taken
+const tagUniqueError = "ERROR: duplicate key value violates unique constraint \"tag_slug_key\" (SQLSTATE 23505)"
+
+// same as the check on tag.slug
+var tagSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// tagRef is a tag as shown on a destination
+type tagRef struct {
+	Slug     string
+	Name     string
+	Category string
+}
+
+// facet is the number of listed destinations that have a tag
+type facet struct {
+	tagRef
+	Count int
+}
+
+// parseTag reads and validates the tag form values
+func parseTag(c *fiber.Ctx) (tag db.Tag, fiberErr *fiber.Map) {
+	tag.Slug = c.FormValue("slug")
+	tag.Name = c.FormValue("name")
+	tag.Category = c.FormValue("category") // optional
+
+	if tag.Slug == "" || tag.Name == "" {
+		return tag, fiberUndefinedParamError
+	}
+
+	if len(tag.Slug) > 64 || !tagSlug.MatchString(tag.Slug) {
+		return tag, fiberInvalidTagSlug
+	}
+
+	return tag, nil
+}
+
+// parseSlugs splits a comma separated list of tag slugs, dropping duplicates
+func parseSlugs(value string) (slugs []string, ok bool) {
+	seen := make(map[string]bool)
+	for _, slug := range strings.Split(value, ",") {
+		slug = strings.TrimSpace(slug)
+		if slug == "" {
+			continue
+		}
+		if !tagSlug.MatchString(slug) {
+			return nil, false
+		}
+		if !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs, true
+}
+
+// destinationTags returns the tags of each of the given destinations
+func (r *Repo) destinationTags(ids []pgtype.UUID) (map[pgtype.UUID][]tagRef, error) {
+	rows, err := r.Queries.ListDestinationTags(r.Ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[pgtype.UUID][]tagRef, len(ids))
+	for _, row := range rows {
+		tags[row.DestinationID] = append(tags[row.DestinationID], tagRef{
+			Slug:     row.Slug,
+			Name:     row.Name,
+			Category: row.Category,
+		})
+	}
+
+	return tags, nil
+}
+
+// facets counts the tags of the listed destinations, by category then name
+func facets(destinations []destinationSummary) []facet {
+	counts := make(map[string]*facet)
+	for _, destination := range destinations {
+		for _, tag := range destination.Tags {
+			if f, ok := counts[tag.Slug]; ok {
+				f.Count++
+			} else {
+				counts[tag.Slug] = &facet{tag, 1}
+			}
+		}
+	}
+
+	out := make([]facet, 0, len(counts))
+	for _, f := range counts {
+		out = append(out, *f)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out
+}
+
+// listTags retrieves the taxonomy along with how many destinations use each tag
+func (r *Repo) listTags(c *fiber.Ctx) error {
+	tags, err := r.Queries.ListTags(r.Ctx)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting tags in ListTags db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(tags) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no tags found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &tags,
+	})
+}
+
+// createTag adds a tag to the taxonomy, admin only
+func (r *Repo) createTag(c *fiber.Ctx) error {
+	tag, fiberErr := parseTag(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+
+	tag.ID = pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	err := r.Queries.CreateTag(r.Ctx, db.CreateTagParams(tag))
+
+	if err != nil {
+		if err.Error() == tagUniqueError {
+			return c.Status(fiber.StatusConflict).JSON(fiberTagExists)
+		}
+
+		logging.FromCtx(c).Error("error in creating tag in CreateTag db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "tag has been added",
+		"id":      tag.ID,
+	})
+}
+
+// updateTag modifies a tag, admin only
+func (r *Repo) updateTag(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTagID)
+	if !ok {
+		return err
+	}
+
+	tag, fiberErr := parseTag(c)
+	if fiberErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberErr)
+	}
+	tag.ID = id
+
+	rows, err := r.Queries.UpdateTag(r.Ctx, db.UpdateTagParams(tag))
+
+	if err != nil {
+		if err.Error() == tagUniqueError {
+			return c.Status(fiber.StatusConflict).JSON(fiberTagExists)
+		}
+
+		logging.FromCtx(c).Error("error in updating tag in UpdateTag db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTagID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "tag has been updated"})
+}
+
+// deleteTag removes a tag from the taxonomy and every destination, admin only
+func (r *Repo) deleteTag(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTagID)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteTag(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting tag in DeleteTag db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTagID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "tag has been deleted"})
+}
+
+// setDestinationTags replaces the tags of a destination with a comma
+// separated list of slugs, an empty list removes them all, admin only
+func (r *Repo) setDestinationTags(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	slugs, ok := parseSlugs(c.FormValue("tags"))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUnknownTag)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	// the old tags are kept if any of the new ones is unknown
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	err = qtx.ClearDestinationTags(r.Ctx, destinationID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in removing destination tags in ClearDestinationTags db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	rows, err := qtx.AddDestinationTags(r.Ctx, db.AddDestinationTagsParams{
+		DestinationID: destinationID,
+		Slugs:         slugs,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in adding destination tags in AddDestinationTags db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows != int64(len(slugs)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUnknownTag)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "destination tags have been updated"})
+}
@@ -0,0 +1,266 @@
+package routes
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/text/language"
+
+	"github.com/Trisamudrisvara/goTrip/config"
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+// error returned by postgres when the destination doesn't exist
+const translationDestinationFkeyError = "ERROR: insert or update on table \"destination_translation\" violates foreign key constraint \"destination_translation_destination_id_fkey\" (SQLSTATE 23503)"
+
+// locales destination content is available in, set by SetupRoutes
+var locales *localeMatcher
+
+// localeMatcher negotiates the locale of destination content
+type localeMatcher struct {
+	// the default locale first, then the translations
+	names   []string
+	matcher language.Matcher
+}
+
+func newLocaleMatcher(cfg config.I18n) *localeMatcher {
+	names := append([]string{cfg.DefaultLocale}, cfg.Locales...)
+
+	tags := make([]language.Tag, len(names))
+	for i, name := range names {
+		// already validated by the config
+		tags[i] = language.MustParse(name)
+	}
+
+	return &localeMatcher{names, language.NewMatcher(tags)}
+}
+
+// chain returns the translations to try for an Accept-Language header,
+// most preferred first, the default locale is the implicit last fallback
+// e.g. "pt-BR, es;q=0.8, en;q=0.5" gives [pt es] if en is the default
+func (m *localeMatcher) chain(acceptLanguage string) []string {
+	prefs, q, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return nil
+	}
+
+	var chain []string
+	for i, pref := range prefs {
+		if q[i] == 0 {
+			continue
+		}
+
+		_, index, confidence := m.matcher.Match(pref)
+		if confidence == language.No {
+			continue
+		}
+
+		// the default locale is always available, later preferences don't matter
+		if index == 0 {
+			break
+		}
+
+		if name := m.names[index]; !slices.Contains(chain, name) {
+			chain = append(chain, name)
+		}
+	}
+
+	return chain
+}
+
+// translation returns the canonical form of a locale destinations can be
+// translated to, ok is false for the default locale and unknown ones
+func (m *localeMatcher) translation(locale string) (name string, ok bool) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", false
+	}
+
+	name = tag.String()
+	return name, slices.Contains(m.names[1:], name)
+}
+
+// localized tells which locale destination content is in
+type localized struct {
+	Locale string
+	// configured locales the destination has no translation for
+	MissingLocales []string
+}
+
+// localize returns the preferred translation of each of the given
+// destinations, if any, and which locale they end up in
+func (r *Repo) localize(ids []pgtype.UUID, chain []string) (map[pgtype.UUID]db.DestinationTranslation, map[pgtype.UUID]localized, error) {
+	translations := make(map[pgtype.UUID]db.DestinationTranslation)
+
+	if len(chain) > 0 {
+		rows, err := r.Queries.ListTranslations(r.Ctx, db.ListTranslationsParams{
+			DestinationIds: ids,
+			Locales:        chain,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// keep the one earliest in the chain
+		for _, row := range rows {
+			current, ok := translations[row.DestinationID]
+			if !ok || slices.Index(chain, row.Locale) < slices.Index(chain, current.Locale) {
+				translations[row.DestinationID] = row
+			}
+		}
+	}
+
+	rows, err := r.Queries.ListTranslatedLocales(r.Ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	translated := make(map[pgtype.UUID][]string, len(ids))
+	for _, row := range rows {
+		translated[row.DestinationID] = append(translated[row.DestinationID], row.Locale)
+	}
+
+	out := make(map[pgtype.UUID]localized, len(ids))
+	for _, id := range ids {
+		l := localized{Locale: locales.names[0], MissingLocales: []string{}}
+		if translation, ok := translations[id]; ok {
+			l.Locale = translation.Locale
+		}
+
+		for _, locale := range locales.names[1:] {
+			if !slices.Contains(translated[id], locale) {
+				l.MissingLocales = append(l.MissingLocales, locale)
+			}
+		}
+
+		out[id] = l
+	}
+
+	return translations, out, nil
+}
+
+// parseLocaleParam reads the :locale param
+// writes the error response itself if ok is false
+func parseLocaleParam(c *fiber.Ctx) (locale string, ok bool, err error) {
+	locale, ok = locales.translation(c.Params("locale"))
+	if !ok {
+		return "", false, c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": "locale must be one of the configured LOCALES: " + strings.Join(locales.names[1:], ", ")})
+	}
+
+	return locale, true, nil
+}
+
+// listTranslations retrieves every translation of a destination along
+// with the locales it's missing, admin only
+func (r *Repo) listTranslations(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	translations, err := r.Queries.ListDestinationTranslations(r.Ctx, destinationID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting translations in ListDestinationTranslations db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	missing := []string{}
+	for _, locale := range locales.names[1:] {
+		if !slices.ContainsFunc(translations, func(t db.DestinationTranslation) bool { return t.Locale == locale }) {
+			missing = append(missing, locale)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Translations":   translations,
+			"MissingLocales": missing,
+		},
+	})
+}
+
+// putTranslation adds or replaces the translation of a destination, admin only
+func (r *Repo) putTranslation(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	locale, ok, err := parseLocaleParam(c)
+	if !ok {
+		return err
+	}
+
+	name := c.FormValue("name")
+	description := c.FormValue("description")
+	attraction := c.FormValue("attraction")
+
+	// if any of the form data is missing return error
+	if name == "" || description == "" || attraction == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if ok, err := r.destinationExists(c, destinationID); !ok {
+		return err
+	}
+
+	err = r.Queries.UpsertDestinationTranslation(r.Ctx, db.UpsertDestinationTranslationParams{
+		DestinationID: destinationID,
+		Locale:        locale,
+		Name:          name,
+		Description:   description,
+		Attraction:    attraction,
+	})
+
+	if err != nil {
+		if err.Error() == translationDestinationFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		logging.FromCtx(c).Error("error in saving translation in UpsertDestinationTranslation db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": locale + " translation has been saved"})
+}
+
+// deleteTranslation removes the translation of a destination, admin only
+func (r *Repo) deleteTranslation(c *fiber.Ctx) error {
+	destinationID, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	locale, ok, err := parseLocaleParam(c)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteDestinationTranslation(r.Ctx, db.DeleteDestinationTranslationParams{
+		DestinationID: destinationID,
+		Locale:        locale,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting translation in DeleteDestinationTranslation db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no " + locale + " translation found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": locale + " translation has been deleted"})
+}
@@ -0,0 +1,185 @@
+package routes
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var fiberInvalidTripID = &fiber.Map{"error": "invalid trip id"}
+
+// listDeletedDestinations retrieves the destinations in the trash, newest first, admin only
+func (r *Repo) listDeletedDestinations(c *fiber.Ctx) error {
+	destinations, err := r.Queries.ListDeletedDestinations(r.Ctx)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting deleted destinations in ListDeletedDestinations db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(destinations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no deleted destinations found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &destinations,
+	})
+}
+
+// restoreDestination takes a destination out of the trash, admin only
+func (r *Repo) restoreDestination(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	err = r.revise(c, id, "restore", func(q *db.Queries) error {
+		rows, err := q.RestoreDestination(r.Ctx, id)
+		if err == nil && rows == 0 {
+			return pgx.ErrNoRows
+		}
+		return err
+	})
+
+	if err != nil {
+		switch err.Error() {
+		// either doesn't exist or isn't in the trash
+		case "no rows in result set":
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		// another one with the same name was added since
+		case destinationUniqueError:
+			return c.Status(fiber.StatusConflict).JSON(fiberDestinationExists)
+		}
+
+		logging.FromCtx(c).Error("error in restoring destination in RestoreDestination db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "destination has been restored"})
+}
+
+// listDeletedTrips retrieves the trips in the trash, newest first, admin only
+func (r *Repo) listDeletedTrips(c *fiber.Ctx) error {
+	trips, err := r.Queries.ListDeletedTrips(r.Ctx)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting deleted trips in ListDeletedTrips db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(trips) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no deleted trips found"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &trips,
+	})
+}
+
+// restoreTrip takes a trip out of the trash, admin only
+func (r *Repo) restoreTrip(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.RestoreTrip(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in restoring trip in RestoreTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "trip has been restored"})
+}
+
+// PurgeTrash permanently deletes the destinations and trips that have been
+// in the trash for longer than TRASH_RETENTION_DAYS, every TRASH_PURGE_INTERVAL
+// it blocks, so it should be run in its own goroutine
+func (r *Repo) PurgeTrash() {
+	cfg := r.Config.Trash
+	if cfg.RetentionDays == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		before := pgtype.Timestamptz{
+			Time:  time.Now().AddDate(0, 0, -cfg.RetentionDays),
+			Valid: true,
+		}
+
+		if err := r.purge(before); err != nil {
+			slog.Error("error in purging trash", "err", err)
+		}
+
+		select {
+		case <-r.Ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge deletes the items moved to the trash before the given time
+func (r *Repo) purge(before pgtype.Timestamptz) error {
+	// attachments of the trips' bookings would cascade with them, so
+	// their rows go first to get the files to remove
+	attachments, err := r.Queries.DeletePurgedBookingAttachments(r.Ctx, before)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range attachments {
+		if err := r.Media.Delete(r.Ctx, key); err != nil {
+			slog.Warn("error in deleting stored attachment", "key", key, "err", err)
+		}
+	}
+
+	trips, err := r.Queries.PurgeTrips(r.Ctx, before)
+	if err != nil {
+		return err
+	}
+
+	// media rows go first so their files can be removed from the store,
+	// the rest of a destination's rows cascade
+	files, err := r.Queries.DeletePurgedMedia(r.Ctx, before)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		for _, key := range []string{file.Key, file.ThumbnailKey} {
+			if err := r.Media.Delete(r.Ctx, key); err != nil {
+				slog.Warn("error in deleting stored media", "key", key, "err", err)
+			}
+		}
+	}
+
+	destinations, err := r.Queries.PurgeDestinations(r.Ctx, before)
+	if err != nil {
+		return err
+	}
+
+	if trips > 0 || destinations > 0 || len(attachments) > 0 {
+		slog.Info("purged trash", "trips", trips, "destinations", destinations, "media", len(files), "attachments", len(attachments))
+	}
+
+	return nil
+}
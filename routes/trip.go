@@ -1,7 +1,6 @@
 package routes
 
 import (
-	"log"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -9,15 +8,38 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
 )
 
-// getTrips retrieves all trips from the database
+// ListTrips retrieves the trips the logged in user is a member of, every
+// trip for admins
 func (r *Repo) ListTrips(c *fiber.Ctx) error {
-	trips, err := r.Queries.ListTrips(r.Ctx)
+	var trips []db.ListTripsRow
+	var err error
 
-	if err != nil {
-		log.Println("Error in getting trips in GetTrips db function:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	if isAdmin(c) {
+		trips, err = r.Queries.ListTrips(r.Ctx)
+
+		if err != nil {
+			logging.FromCtx(c).Error("error in getting trips in ListTrips db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+	} else {
+		user, ok := userID(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+		}
+
+		rows, err := r.Queries.ListMemberTrips(r.Ctx, user)
+
+		if err != nil {
+			logging.FromCtx(c).Error("error in getting trips in ListMemberTrips db function", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		for _, row := range rows {
+			trips = append(trips, db.ListTripsRow(row))
+		}
 	}
 
 	if len(trips) == 0 {
@@ -30,7 +52,8 @@ func (r *Repo) ListTrips(c *fiber.Ctx) error {
 	})
 }
 
-// getTrip retrieves a single trip by ID
+// getTrip retrieves a single trip by ID, members and admins only
+// DestinationDeleted is set once its destination is in the trash or purged
 func (r *Repo) getTrip(c *fiber.Ctx) error {
 	uuid, err := uuid.Parse(c.Params("id"))
 
@@ -39,7 +62,7 @@ func (r *Repo) getTrip(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -48,6 +71,11 @@ func (r *Repo) getTrip(c *fiber.Ctx) error {
 		Valid: true,
 	}
 
+	// members only
+	if ok, err := r.tripAccess(c, id, "viewer", fiberInvalidID); !ok {
+		return err
+	}
+
 	trip, err := r.Queries.GetTrip(r.Ctx, id)
 
 	if err != nil {
@@ -55,17 +83,33 @@ func (r *Repo) getTrip(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
 		}
 
-		log.Println("Error in getting trip in GetTrip db function:", err)
+		logging.FromCtx(c).Error("error in getting trip in GetTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// every stop of trips made from a wishlist, in order, deleted ones are flagged
+	destinations, err := r.Queries.ListTripDestinations(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting trip destinations in ListTripDestinations db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
-		"data": &trip,
+		"data": &struct {
+			db.GetTripRow
+			Destinations []db.ListTripDestinationsRow
+		}{trip, destinations},
 	})
 }
 
-// createTrip adds a new trip to the database
+// createTrip adds a new trip to the database, owned by the logged in user
 func (r *Repo) createTrip(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
 	// Extract trip details from form data
 	name := c.FormValue("name")
 	startDate := c.FormValue("start_date")
@@ -77,6 +121,11 @@ func (r *Repo) createTrip(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
 	}
 
+	// dates have to be readable by calendar apps
+	if _, _, ok := parseTripDates(c); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripDates)
+	}
+
 	// Parse destination UUID
 	Uuid, err := uuid.Parse(destinationId)
 
@@ -85,7 +134,7 @@ func (r *Repo) createTrip(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -102,6 +151,12 @@ func (r *Repo) createTrip(c *fiber.Ctx) error {
 			Bytes: Uuid,
 			Valid: true,
 		},
+		UserID: user,
+	}
+
+	// destinations in the trash can't be added to trips
+	if ok, err := r.destinationExists(c, trip.DestinationID); !ok {
+		return err
 	}
 
 	// Create trip in database
@@ -112,15 +167,18 @@ func (r *Repo) createTrip(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
 		}
 
-		log.Println("Error in creating trip in CreateTrip db function:", err)
+		logging.FromCtx(c).Error("error in creating trip in CreateTrip db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
-		"message": "trip has been added"})
+		"message": "trip has been added",
+		"id":      trip.ID,
+	})
 }
 
-// updateTrip modifies an existing trip in the database
+// updateTrip modifies an existing trip in the database, editors,
+// the owner and admins only
 func (r *Repo) updateTrip(c *fiber.Ctx) error {
 	// Extract trip details from form data
 	id := c.FormValue("id")
@@ -134,6 +192,11 @@ func (r *Repo) updateTrip(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
 	}
 
+	// dates have to be readable by calendar apps
+	if _, _, ok := parseTripDates(c); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripDates)
+	}
+
 	// Parse destination UUID
 	destinationUuid, err := uuid.Parse(destinationId)
 
@@ -142,7 +205,7 @@ func (r *Repo) updateTrip(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -155,7 +218,7 @@ func (r *Repo) updateTrip(c *fiber.Ctx) error {
 				&fiber.Map{"error": "invalid trip id"})
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -174,23 +237,38 @@ func (r *Repo) updateTrip(c *fiber.Ctx) error {
 		},
 	}
 
+	// editors and the owner
+	if ok, err := r.tripAccess(c, trip.ID, "editor", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	// destinations in the trash can't be added to trips
+	if ok, err := r.destinationExists(c, trip.DestinationID); !ok {
+		return err
+	}
+
 	// Update trip in database
-	err = r.Queries.UpdateTrip(r.Ctx, trip)
+	rows, err := r.Queries.UpdateTrip(r.Ctx, trip)
 
 	if err != nil {
 		if err.Error() == "ERROR: insert or update on table \"trip\" violates foreign key constraint \"trip_destination_id_fkey\" (SQLSTATE 23503)" {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
 		}
 
-		log.Println("Error in updating trip in UpdateTrip db function:", err)
+		logging.FromCtx(c).Error("error in updating trip in UpdateTrip db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
+	// either doesn't exist or is in the trash
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripID)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
 		"message": "trip has been updated"})
 }
 
-// deleteTrip removes a trip from the database by ID
+// deleteTrip moves a trip to the trash by ID, the owner and admins only
 func (r *Repo) deleteTrip(c *fiber.Ctx) error {
 	uuid, err := uuid.Parse(c.Params("id"))
 
@@ -199,7 +277,7 @@ func (r *Repo) deleteTrip(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
 		}
 
-		log.Println("Error in parsing uuid:", err)
+		logging.FromCtx(c).Error("error in parsing uuid", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
@@ -208,17 +286,39 @@ func (r *Repo) deleteTrip(c *fiber.Ctx) error {
 		Valid: true,
 	}
 
-	err = r.Queries.DeleteTrip(r.Ctx, id)
+	// owner only
+	if ok, err := r.tripAccess(c, id, "owner", fiberInvalidID); !ok {
+		return err
+	}
 
-	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
-		}
+	rows, err := r.Queries.DeleteTrip(r.Ctx, id)
 
-		log.Println("Error in deleting trip in DeleteTrip db function:", err)
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting trip in DeleteTrip db function", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidID)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
 		"message": "trip has been deleted"})
 }
+
+// destinationExists checks that a destination exists and isn't in the trash
+// writes the error response itself if ok is false
+func (r *Repo) destinationExists(c *fiber.Ctx, id pgtype.UUID) (ok bool, err error) {
+	_, err = r.Queries.GetDestination(r.Ctx, id)
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		logging.FromCtx(c).Error("error in getting destination in GetDestination db function", "err", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,241 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+const (
+	// path of shared trips, followed by the share token
+	sharedTripPath = "/shared/trip/"
+	// header carrying the password of protected links
+	sharePasswordHeader = "X-Share-Password"
+)
+
+var (
+	fiberInvalidShareID     = &fiber.Map{"error": "invalid share id"}
+	fiberInvalidShareExpiry = &fiber.Map{"error": "expires_at must be a future time in the RFC 3339 format, e.g. 2006-01-02T15:04:05Z"}
+	fiberSharedTripNotFound = &fiber.Map{"error": "trip not found, no longer shared or the link has expired"}
+	fiberSharePassword      = &fiber.Map{"error": "missing or wrong password, send it in the " + sharePasswordHeader + " header"}
+)
+
+// tripShare is a share link of a trip as shown to its owner
+type tripShare struct {
+	db.ListTripSharesRow
+	URL string
+}
+
+// sharedStop is a destination of a shared trip, without any ids
+type sharedStop struct {
+	Name        string
+	Description string
+	Attraction  string
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Country     pgtype.Text
+	Region      pgtype.Text
+	City        pgtype.Text
+}
+
+// listTripShares retrieves the share links of a trip along with how many
+// times each was viewed, owner only
+func (r *Repo) listTripShares(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "owner", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	shares, err := r.Queries.ListTripShares(r.Ctx, id)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting trip shares in ListTripShares db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(shares) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			&fiber.Map{"error": "no share links found"})
+	}
+
+	data := make([]tripShare, len(shares))
+	for i, share := range shares {
+		data[i] = tripShare{share, c.BaseURL() + sharedTripPath + share.Token}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &data,
+	})
+}
+
+// createTripShare creates a public read only link to a trip, optionally
+// protected by a password and expiring at expires_at, owner only
+func (r *Repo) createTripShare(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if value := c.FormValue("expires_at"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil || !t.After(time.Now()) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidShareExpiry)
+		}
+		expiresAt = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+
+	if ok, err := r.tripAccess(c, id, "owner", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	var passwordHash pgtype.Text
+	if password := c.FormValue("password"); password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+
+		if err != nil {
+			logging.FromCtx(c).Error("error hashing password", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		passwordHash = pgtype.Text{String: string(hash), Valid: true}
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		logging.FromCtx(c).Error("error in generating share token", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// left unset if the token has no user id
+	creator, _ := userID(c)
+
+	share := db.CreateTripShareParams{
+		ID: pgtype.UUID{
+			Bytes: uuid.New(),
+			Valid: true,
+		},
+		TripID:       id,
+		Token:        base64.RawURLEncoding.EncodeToString(b),
+		PasswordHash: passwordHash,
+		ExpiresAt:    expiresAt,
+		CreatedBy:    creator,
+	}
+
+	err = r.Queries.CreateTripShare(r.Ctx, share)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating trip share in CreateTripShare db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message":  "trip has been shared",
+		"id":       share.ID,
+		"ShareURL": c.BaseURL() + sharedTripPath + share.Token,
+	})
+}
+
+// deleteTripShare revokes a share link, owner only
+func (r *Repo) deleteTripShare(c *fiber.Ctx) error {
+	id, ok, err := paramUUID(c, "id", fiberInvalidTripID)
+	if !ok {
+		return err
+	}
+
+	shareID, ok, err := paramUUID(c, "share", fiberInvalidShareID)
+	if !ok {
+		return err
+	}
+
+	if ok, err := r.tripAccess(c, id, "owner", fiberInvalidTripID); !ok {
+		return err
+	}
+
+	rows, err := r.Queries.DeleteTripShare(r.Ctx, db.DeleteTripShareParams{
+		ID:     shareID,
+		TripID: id,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting trip share in DeleteTripShare db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidShareID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "share link has been revoked"})
+}
+
+// sharedTrip retrieves a read only view of a shared trip, no JWT needed
+// the password of protected links is sent in the X-Share-Password header
+func (r *Repo) sharedTrip(c *fiber.Ctx) error {
+	trip, err := r.Queries.GetSharedTrip(r.Ctx, c.Params("token"))
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusNotFound).JSON(fiberSharedTripNotFound)
+		}
+
+		logging.FromCtx(c).Error("error in getting shared trip in GetSharedTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if trip.PasswordHash.Valid {
+		err := bcrypt.CompareHashAndPassword([]byte(trip.PasswordHash.String), []byte(c.Get(sharePasswordHeader)))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiberSharePassword)
+		}
+	}
+
+	rows, err := r.Queries.ListSharedTripStops(r.Ctx, trip.TripID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting shared trip stops in ListSharedTripStops db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	stops := make([]sharedStop, len(rows))
+	for i, row := range rows {
+		stops[i] = sharedStop{row.Name, row.Description, row.Attraction,
+			row.Latitude, row.Longitude, row.Country, row.Region, row.City}
+	}
+
+	// trips whose destination is in the trash or purged have none
+	var destination *sharedStop
+	if trip.DestinationName.Valid {
+		destination = &sharedStop{trip.DestinationName.String, trip.DestinationDescription.String,
+			trip.DestinationAttraction.String, trip.Latitude, trip.Longitude, trip.Country, trip.Region, trip.City}
+	}
+
+	// a failed count shouldn't hide the trip
+	if err := r.Queries.RecordTripShareView(r.Ctx, trip.ShareID); err != nil {
+		logging.FromCtx(c).Warn("error in recording view in RecordTripShareView db function", "err", err)
+	}
+
+	// nothing about the owner, the members or the ids
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Name":         trip.Name,
+			"StartDate":    trip.StartDate,
+			"EndDate":      trip.EndDate,
+			"Destination":  destination,
+			"Destinations": stops,
+		},
+	})
+}
@@ -1,12 +1,13 @@
 package routes
 
 import (
-	"log"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
 )
 
 // aboutUser retrieves and returns user information from the JWT token
@@ -51,13 +52,14 @@ func (r *Repo) updateUser(c *fiber.Ctx) error {
 	err := r.Queries.UpdateUser(r.Ctx, usr)
 
 	if err != nil {
-		log.Println("Error in updating user in UpdateUser db function:", err)
+		logging.FromCtx(c).Error("error in updating user in UpdateUser db function", "err", err)
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUndefinedParamError)
 	}
 
 	// Create new JWT claims with updated information
 	claims = jwt.MapClaims{
+		"id":    claims["id"],
 		"email": newEmail,
 		"name":  name,
 		"admin": claims["admin"],
@@ -72,10 +74,48 @@ func (r *Repo) updateUser(c *fiber.Ctx) error {
 	jwtToken, err := token.SignedString(secret)
 
 	if err != nil {
-		log.Println("error in signing JWT key:", err)
+		logging.FromCtx(c).Error("error in signing JWT key", "err", err)
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
 	}
 
 	return c.JSON(fiber.Map{"jwt": jwtToken})
 }
+
+// userID returns the id of the logged in user from the JWT claims
+// tokens issued before the id claim existed don't have it
+func userID(c *fiber.Ctx) (pgtype.UUID, bool) {
+	user, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return pgtype.UUID{}, false
+	}
+
+	claims, ok := user.Claims.(jwt.MapClaims)
+	if !ok {
+		return pgtype.UUID{}, false
+	}
+
+	id, _ := claims["id"].(string)
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return pgtype.UUID{}, false
+	}
+
+	return pgtype.UUID{Bytes: uuid, Valid: true}, true
+}
+
+// isAdmin tells whether the logged in user is an admin
+func isAdmin(c *fiber.Ctx) bool {
+	user, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return false
+	}
+
+	claims, ok := user.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+
+	admin, _ := claims["admin"].(bool)
+	return admin
+}
@@ -0,0 +1,564 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Trisamudrisvara/goTrip/db"
+	"github.com/Trisamudrisvara/goTrip/logging"
+)
+
+var (
+	fiberInvalidWishlistID  = &fiber.Map{"error": "invalid wishlist id"}
+	fiberDefaultWishlist    = &fiber.Map{"error": "the default wishlist can't be renamed or deleted"}
+	fiberWishlistExists     = &fiber.Map{"error": "a wishlist with this name already exists"}
+	fiberReservedWishlist   = &fiber.Map{"error": "the name Favorites is reserved for the default wishlist"}
+	fiberInvalidWishlistIDs = &fiber.Map{"error": "ids must list every destination of the wishlist exactly once"}
+	fiberEmptyWishlist      = &fiber.Map{"error": "wishlist has no destinations"}
+	fiberInvalidTripDates   = &fiber.Map{"error": "start_date and end_date must be dates in the format 2006-01-02, end_date not before start_date"}
+)
+
+const (
+	// errors returned by postgres
+	wishlistUniqueError          = "ERROR: duplicate key value violates unique constraint \"wishlist_user_id_name_key\" (SQLSTATE 23505)"
+	wishlistDestinationFkeyError = "ERROR: insert or update on table \"wishlist_item\" violates foreign key constraint \"wishlist_item_destination_id_fkey\" (SQLSTATE 23503)"
+
+	// :id alias of the user's default wishlist
+	favorites = "favorites"
+	// name of the default wishlist, reserved so creating it never conflicts
+	defaultWishlistName = "Favorites"
+	// path of shared wishlists, followed by the share token
+	sharedWishlistPath = "/shared/wishlist/"
+)
+
+// wishlistParam returns the :id wishlist of the logged in user
+// "favorites" is their default list, created on first use
+// writes the error response itself if ok is false
+func (r *Repo) wishlistParam(c *fiber.Ctx) (wishlist db.Wishlist, ok bool, err error) {
+	user, ok := userID(c)
+	if !ok {
+		return wishlist, false, c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	if c.Params("id") == favorites {
+		if err := r.ensureDefaultWishlist(user); err != nil {
+			logging.FromCtx(c).Error("error in creating default wishlist in EnsureDefaultWishlist db function", "err", err)
+			return wishlist, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+		}
+
+		wishlist, err = r.Queries.GetDefaultWishlist(r.Ctx, user)
+	} else {
+		id, ok, err := paramUUID(c, "id", fiberInvalidWishlistID)
+		if !ok {
+			return wishlist, false, err
+		}
+
+		wishlist, err = r.Queries.GetWishlist(r.Ctx, db.GetWishlistParams{
+			ID:     id,
+			UserID: user,
+		})
+	}
+
+	if err != nil {
+		// other users' wishlists don't exist for this user
+		if err.Error() == "no rows in result set" {
+			return wishlist, false, c.Status(fiber.StatusBadRequest).JSON(fiberInvalidWishlistID)
+		}
+
+		logging.FromCtx(c).Error("error in getting wishlist in GetWishlist db function", "err", err)
+		return wishlist, false, c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return wishlist, true, nil
+}
+
+// ensureDefaultWishlist creates the user's Favorites list if it doesn't exist
+func (r *Repo) ensureDefaultWishlist(user pgtype.UUID) error {
+	return r.Queries.EnsureDefaultWishlist(r.Ctx, db.EnsureDefaultWishlistParams{
+		ID:     pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		UserID: user,
+	})
+}
+
+// shareURL returns the read only link of a shared wishlist
+func shareURL(c *fiber.Ctx, token pgtype.Text) string {
+	if !token.Valid {
+		return ""
+	}
+	return c.BaseURL() + sharedWishlistPath + token.String
+}
+
+// listWishlists retrieves the logged in user's wishlists, Favorites first
+func (r *Repo) listWishlists(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	if err := r.ensureDefaultWishlist(user); err != nil {
+		logging.FromCtx(c).Error("error in creating default wishlist in EnsureDefaultWishlist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	wishlists, err := r.Queries.ListWishlists(r.Ctx, user)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting wishlists in ListWishlists db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	type wishlistSummary struct {
+		db.ListWishlistsRow
+		ShareURL string
+	}
+
+	data := make([]wishlistSummary, len(wishlists))
+	for i, wishlist := range wishlists {
+		data[i] = wishlistSummary{wishlist, shareURL(c, wishlist.ShareToken)}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &data,
+	})
+}
+
+// createWishlist adds a custom wishlist for the logged in user
+func (r *Repo) createWishlist(c *fiber.Ctx) error {
+	user, ok := userID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiberLoginAgain)
+	}
+
+	name := c.FormValue("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if name == defaultWishlistName {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberReservedWishlist)
+	}
+
+	id := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	err := r.Queries.CreateWishlist(r.Ctx, db.CreateWishlistParams{
+		ID:     id,
+		UserID: user,
+		Name:   name,
+	})
+
+	if err != nil {
+		if err.Error() == wishlistUniqueError {
+			return c.Status(fiber.StatusConflict).JSON(fiberWishlistExists)
+		}
+
+		logging.FromCtx(c).Error("error in creating wishlist in CreateWishlist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "wishlist has been created",
+		"id":      id,
+	})
+}
+
+// getWishlist retrieves a wishlist of the logged in user with its destinations
+func (r *Repo) getWishlist(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	items, err := r.Queries.ListWishlistItems(r.Ctx, wishlist.ID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting wishlist items in ListWishlistItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &struct {
+			db.Wishlist
+			ShareURL string
+			Items    []db.ListWishlistItemsRow
+		}{wishlist, shareURL(c, wishlist.ShareToken), items},
+	})
+}
+
+// renameWishlist changes the name of a custom wishlist
+func (r *Repo) renameWishlist(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	name := c.FormValue("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	if name == defaultWishlistName {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberReservedWishlist)
+	}
+
+	if wishlist.IsDefault {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberDefaultWishlist)
+	}
+
+	_, err = r.Queries.RenameWishlist(r.Ctx, db.RenameWishlistParams{
+		ID:     wishlist.ID,
+		UserID: wishlist.UserID,
+		Name:   name,
+	})
+
+	if err != nil {
+		if err.Error() == wishlistUniqueError {
+			return c.Status(fiber.StatusConflict).JSON(fiberWishlistExists)
+		}
+
+		logging.FromCtx(c).Error("error in renaming wishlist in RenameWishlist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "wishlist has been renamed"})
+}
+
+// deleteWishlist removes a custom wishlist
+func (r *Repo) deleteWishlist(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	if wishlist.IsDefault {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberDefaultWishlist)
+	}
+
+	_, err = r.Queries.DeleteWishlist(r.Ctx, db.DeleteWishlistParams{
+		ID:     wishlist.ID,
+		UserID: wishlist.UserID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in deleting wishlist in DeleteWishlist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "wishlist has been deleted"})
+}
+
+// addWishlistItem appends a destination to a wishlist
+// adding it again only updates its note
+func (r *Repo) addWishlistItem(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	destinationID := c.FormValue("destination_id")
+	if destinationID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	destination, err := uuid.Parse(destinationID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+	}
+
+	id := pgtype.UUID{Bytes: destination, Valid: true}
+
+	// destinations in the trash can't be added
+	if ok, err := r.destinationExists(c, id); !ok {
+		return err
+	}
+
+	err = r.Queries.AddWishlistItem(r.Ctx, db.AddWishlistItemParams{
+		WishlistID:    wishlist.ID,
+		DestinationID: id,
+		Note:          c.FormValue("note"),
+	})
+
+	if err != nil {
+		if err.Error() == wishlistDestinationFkeyError {
+			return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+		}
+
+		logging.FromCtx(c).Error("error in adding wishlist item in AddWishlistItem db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "destination has been added to " + wishlist.Name})
+}
+
+// removeWishlistItem removes a destination from a wishlist
+func (r *Repo) removeWishlistItem(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	destinationID, ok, err := paramUUID(c, "destination", fiberInvalidDestinationID)
+	if !ok {
+		return err
+	}
+
+	rows, err := r.Queries.RemoveWishlistItem(r.Ctx, db.RemoveWishlistItemParams{
+		WishlistID:    wishlist.ID,
+		DestinationID: destinationID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in removing wishlist item in RemoveWishlistItem db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if rows == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidDestinationID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "destination has been removed from " + wishlist.Name})
+}
+
+// reorderWishlistItems sets the order of a wishlist from a comma separated
+// list of every destination id in it
+func (r *Repo) reorderWishlistItems(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	idsValue := c.FormValue("ids")
+	if idsValue == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	items, err := r.Queries.ListWishlistItems(r.Ctx, wishlist.ID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting wishlist items in ListWishlistItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	existing := make([]pgtype.UUID, len(items))
+	for i, item := range items {
+		existing[i] = item.DestinationID
+	}
+
+	ids, ok := orderedIDs(idsValue, existing)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidWishlistIDs)
+	}
+
+	_, err = r.Queries.ReorderWishlistItems(r.Ctx, db.ReorderWishlistItemsParams{
+		DestinationIds: ids,
+		WishlistID:     wishlist.ID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in reordering wishlist in ReorderWishlistItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "wishlist has been reordered"})
+}
+
+// shareWishlist creates a read only public link to a wishlist
+// sharing again replaces the link, the old one stops working
+func (r *Repo) shareWishlist(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		logging.FromCtx(c).Error("error in generating share token", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	token := pgtype.Text{String: base64.RawURLEncoding.EncodeToString(b), Valid: true}
+
+	_, err = r.Queries.SetWishlistShareToken(r.Ctx, db.SetWishlistShareTokenParams{
+		ID:         wishlist.ID,
+		UserID:     wishlist.UserID,
+		ShareToken: token,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in sharing wishlist in SetWishlistShareToken db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message":  "wishlist has been shared",
+		"ShareURL": shareURL(c, token),
+	})
+}
+
+// unshareWishlist removes the public link of a wishlist
+func (r *Repo) unshareWishlist(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	_, err = r.Queries.SetWishlistShareToken(r.Ctx, db.SetWishlistShareTokenParams{
+		ID:     wishlist.ID,
+		UserID: wishlist.UserID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in unsharing wishlist in SetWishlistShareToken db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "wishlist is no longer shared"})
+}
+
+// sharedWishlist is the public read only view of a shared wishlist
+func (r *Repo) sharedWishlist(c *fiber.Ctx) error {
+	wishlist, err := r.Queries.GetSharedWishlist(r.Ctx, pgtype.Text{
+		String: c.Params("token"),
+		Valid:  true,
+	})
+
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			return c.Status(fiber.StatusNotFound).JSON(
+				&fiber.Map{"error": "wishlist not found or no longer shared"})
+		}
+
+		logging.FromCtx(c).Error("error in getting shared wishlist in GetSharedWishlist db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	items, err := r.Queries.ListWishlistItems(r.Ctx, wishlist.ID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting wishlist items in ListWishlistItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	// only the name and destinations, nothing about the owner
+	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"data": &fiber.Map{
+			"Name":  wishlist.Name,
+			"Items": items,
+		},
+	})
+}
+
+// parseTripDates reads and validates the start_date and end_date form values
+func parseTripDates(c *fiber.Ctx) (start, end string, ok bool) {
+	start = c.FormValue("start_date")
+	end = c.FormValue("end_date")
+
+	startDate, err := time.Parse(time.DateOnly, start)
+	if err != nil {
+		return start, end, false
+	}
+
+	endDate, err := time.Parse(time.DateOnly, end)
+	if err != nil || endDate.Before(startDate) {
+		return start, end, false
+	}
+
+	return start, end, true
+}
+
+// tripFromWishlist creates a trip for the logged in user visiting every
+// destination of a wishlist in order, named after the wishlist by default
+func (r *Repo) tripFromWishlist(c *fiber.Ctx) error {
+	wishlist, ok, err := r.wishlistParam(c)
+	if !ok {
+		return err
+	}
+
+	if c.FormValue("start_date") == "" || c.FormValue("end_date") == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberUndefinedParamError)
+	}
+
+	startDate, endDate, ok := parseTripDates(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberInvalidTripDates)
+	}
+
+	name := c.FormValue("name")
+	if name == "" {
+		name = wishlist.Name
+	}
+
+	items, err := r.Queries.ListWishlistItems(r.Ctx, wishlist.ID)
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in getting wishlist items in ListWishlistItems db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if len(items) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiberEmptyWishlist)
+	}
+
+	id := pgtype.UUID{
+		Bytes: uuid.New(),
+		Valid: true,
+	}
+
+	// the trip and its destinations are created together or not at all
+	tx, err := r.Pool.Begin(r.Ctx)
+	if err != nil {
+		logging.FromCtx(c).Error("error in starting transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+	defer tx.Rollback(r.Ctx)
+
+	qtx := r.Queries.WithTx(tx)
+
+	err = qtx.CreateTrip(r.Ctx, db.CreateTripParams{
+		ID:        id,
+		Name:      name,
+		StartDate: startDate,
+		EndDate:   endDate,
+		// first stop, for clients that only know about one destination
+		DestinationID: items[0].DestinationID,
+		UserID:        wishlist.UserID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in creating trip in CreateTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	err = qtx.CopyWishlistToTrip(r.Ctx, db.CopyWishlistToTripParams{
+		TripID:     id,
+		WishlistID: wishlist.ID,
+	})
+
+	if err != nil {
+		logging.FromCtx(c).Error("error in adding trip destinations in CopyWishlistToTrip db function", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	if err := tx.Commit(r.Ctx); err != nil {
+		logging.FromCtx(c).Error("error in committing transaction", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiberUnknownError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+		"message": "trip has been created",
+		"id":      id,
+	})
+}
@@ -7,17 +7,471 @@ CREATE TABLE users (
 );
 
 
+-- country -> region -> city hierarchy, imported from GeoNames style data
+CREATE TABLE place (
+    id           UUID             PRIMARY KEY,
+    parent_id    UUID             REFERENCES place(id) ON DELETE CASCADE,
+    kind         text             NOT NULL CHECK (kind IN ('country', 'region', 'city')),
+    name         text             NOT NULL,
+    -- ISO 3166-1 alpha-2
+    country_code CHAR(2)          NOT NULL,
+    geoname_id   BIGINT           UNIQUE,
+    latitude     double precision,
+    longitude    double precision,
+    -- GeoNames first level division, cities are attached to the region
+    -- of their country with the same code
+    admin1_code  text,
+    -- only countries are roots
+    CHECK ((kind = 'country') = (parent_id IS NULL))
+);
+
+CREATE INDEX place_parent_id_idx ON place (parent_id);
+
 CREATE TABLE destination (
-    id          UUID         PRIMARY KEY,
-    name        VARCHAR(128) NOT NULL,
-    description text         NOT NULL,
-    attraction  text         NOT NULL
+    id          UUID             PRIMARY KEY,
+    name        VARCHAR(128)     NOT NULL,
+    description text             NOT NULL,
+    attraction  text             NOT NULL,
+    latitude    double precision CHECK (latitude BETWEEN -90 AND 90),
+    longitude   double precision CHECK (longitude BETWEEN -180 AND 180),
+    country     text,
+    region      text,
+    city        text,
+    place_id    UUID             REFERENCES place(id) ON DELETE SET NULL,
+    -- in the trash when set, purged after TRASH_RETENTION_DAYS
+    deleted_at  timestamptz,
+    -- coordinates are set together or not at all
+    CHECK ((latitude IS NULL) = (longitude IS NULL))
+);
+
+-- bounding box prefilter of nearby searches
+CREATE INDEX destination_coordinates_idx ON destination (latitude, longitude);
+CREATE INDEX destination_place_id_idx ON destination (place_id);
+CREATE INDEX destination_deleted_at_idx ON destination (deleted_at) WHERE deleted_at IS NOT NULL;
+-- natural key imports upsert by
+CREATE UNIQUE INDEX destination_natural_key_idx
+    ON destination (lower(name), coalesce(country, ''), coalesce(city, ''))
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE attraction (
+    id                     UUID             PRIMARY KEY,
+    destination_id         UUID             NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    name                   VARCHAR(128)     NOT NULL,
+    category               text             NOT NULL CHECK (category IN (
+        'landmark', 'museum', 'park', 'religious', 'viewpoint',
+        'entertainment', 'shopping', 'food', 'other'
+    )),
+    description            text             NOT NULL,
+    -- free form, e.g. "Mo-Fr 09:00-18:00; Sa 10:00-16:00"
+    opening_hours          text,
+    visit_duration_minutes integer          CHECK (visit_duration_minutes > 0),
+    -- 0 is free, 1 to 4 is cheap to expensive
+    price_level            integer          CHECK (price_level BETWEEN 0 AND 4),
+    latitude               double precision CHECK (latitude BETWEEN -90 AND 90),
+    longitude              double precision CHECK (longitude BETWEEN -180 AND 180),
+    CHECK ((latitude IS NULL) = (longitude IS NULL))
+);
+
+CREATE INDEX attraction_destination_id_idx ON attraction (destination_id);
+
+CREATE TABLE media_file (
+    id             UUID        PRIMARY KEY,
+    destination_id UUID        NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    -- keys in the media store
+    key            text        NOT NULL UNIQUE,
+    thumbnail_key  text        NOT NULL UNIQUE,
+    content_type   text        NOT NULL,
+    size           BIGINT      NOT NULL,
+    width          integer     NOT NULL,
+    height         integer     NOT NULL,
+    caption        text        NOT NULL DEFAULT '',
+    -- gallery order, lowest first
+    position       integer     NOT NULL,
+    -- at most one per destination, kept by SetMediaCover
+    is_cover       boolean     NOT NULL DEFAULT false,
+    created_at     timestamptz NOT NULL DEFAULT now()
 );
 
+CREATE INDEX media_file_destination_id_idx ON media_file (destination_id, position);
+
+CREATE TABLE review (
+    id             UUID         PRIMARY KEY,
+    destination_id UUID         NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    user_id        UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating         integer      NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title          VARCHAR(128) NOT NULL,
+    body           text         NOT NULL,
+    visit_date     date,
+    -- moderation, flagged reviews wait for an admin, hidden ones
+    -- aren't shown or counted in ratings
+    status         text         NOT NULL DEFAULT 'visible'
+        CHECK (status IN ('visible', 'flagged', 'hidden')),
+    created_at     timestamptz  NOT NULL DEFAULT now(),
+    updated_at     timestamptz  NOT NULL DEFAULT now(),
+    -- one review per user per destination
+    UNIQUE (destination_id, user_id)
+);
+
+CREATE INDEX review_status_idx ON review (status) WHERE status <> 'visible';
+
 CREATE TABLE trip (
     id             UUID  PRIMARY KEY,
     name           text  NOT NULL,
     start_date     text  NOT NULL,
     end_date       text  NOT NULL,
-    destination_id UUID  REFERENCES destination(id)
+    -- NULL once the destination is purged from the trash
+    destination_id UUID  REFERENCES destination(id) ON DELETE SET NULL,
+    -- NULL for trips made by admins
+    user_id        UUID  REFERENCES users(id) ON DELETE CASCADE,
+    -- in the trash when set, purged after TRASH_RETENTION_DAYS
+    deleted_at     timestamptz,
+    -- bumped by every update so calendar apps pick up the changes
+    updated_at     timestamptz NOT NULL DEFAULT now(),
+    sequence       integer     NOT NULL DEFAULT 0,
+    -- in minor units of currency, NULL if the trip has no budget
+    budget         bigint      CHECK (budget >= 0),
+    -- home currency, expenses are totalled in it
+    currency       text        NOT NULL DEFAULT 'USD'
+);
+
+CREATE INDEX trip_deleted_at_idx ON trip (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- every destination of a trip, in order
+CREATE TABLE trip_destination (
+    trip_id        UUID    NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    destination_id UUID    NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    position       integer NOT NULL,
+    PRIMARY KEY (trip_id, destination_id)
+);
+
+CREATE TABLE wishlist (
+    id          UUID         PRIMARY KEY,
+    user_id     UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        VARCHAR(128) NOT NULL,
+    -- the Favorites list, created on first use, can't be renamed or deleted
+    is_default  boolean      NOT NULL DEFAULT false,
+    -- token of the read only public link, NULL when not shared
+    share_token text         UNIQUE,
+    created_at  timestamptz  NOT NULL DEFAULT now(),
+    UNIQUE (user_id, name)
+);
+
+CREATE UNIQUE INDEX wishlist_default_idx ON wishlist (user_id) WHERE is_default;
+
+CREATE TABLE wishlist_item (
+    wishlist_id    UUID        NOT NULL REFERENCES wishlist(id) ON DELETE CASCADE,
+    destination_id UUID        NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    note           text        NOT NULL DEFAULT '',
+    position       integer     NOT NULL,
+    added_at       timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (wishlist_id, destination_id)
 );
+
+-- admin managed taxonomy, e.g. beach, mountains, family-friendly, unesco
+CREATE TABLE tag (
+    id       UUID        PRIMARY KEY,
+    -- used in ?tag= filters
+    slug     VARCHAR(64) NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+    name     VARCHAR(64) NOT NULL,
+    -- groups tags into facets, e.g. landscape or audience
+    category VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE destination_tag (
+    destination_id UUID NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    tag_id         UUID NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+    PRIMARY KEY (destination_id, tag_id)
+);
+
+CREATE INDEX destination_tag_tag_id_idx ON destination_tag (tag_id);
+
+-- destination content in other locales than DEFAULT_LOCALE
+CREATE TABLE destination_translation (
+    destination_id UUID         NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    -- BCP 47, e.g. fr or pt-BR
+    locale         VARCHAR(35)  NOT NULL,
+    name           VARCHAR(128) NOT NULL,
+    description    text         NOT NULL,
+    attraction     text         NOT NULL,
+    updated_at     timestamptz  NOT NULL DEFAULT now(),
+    PRIMARY KEY (destination_id, locale)
+);
+
+-- every create, update, delete, restore and rollback of a destination
+CREATE TABLE destination_revision (
+    destination_id UUID        NOT NULL REFERENCES destination(id) ON DELETE CASCADE,
+    -- 1 for the first recorded revision of a destination
+    version        integer     NOT NULL,
+    action         text        NOT NULL CHECK (action IN (
+        'create', 'update', 'delete', 'restore', 'rollback'
+    )),
+    -- destination columns after the change, except id and deleted_at
+    snapshot       jsonb       NOT NULL,
+    -- NULL once the editor's account is deleted
+    editor_id      UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at     timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (destination_id, version)
+);
+
+-- bulk imports of destinations, large files are processed in the background
+CREATE TABLE import_job (
+    id             UUID        PRIMARY KEY,
+    status         text        NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    format         text        NOT NULL CHECK (format IN ('csv', 'json', 'jsonl')),
+    -- atomic imports nothing if any row fails, best_effort skips failed rows
+    mode           text        NOT NULL CHECK (mode IN ('atomic', 'best_effort')),
+    -- validated and run in a transaction that is rolled back
+    dry_run        boolean     NOT NULL,
+    total_rows     integer     NOT NULL,
+    processed_rows integer     NOT NULL DEFAULT 0,
+    created_rows   integer     NOT NULL DEFAULT 0,
+    updated_rows   integer     NOT NULL DEFAULT 0,
+    failed_rows    integer     NOT NULL DEFAULT 0,
+    -- [{"Row": line number, "Error": message}]
+    errors         jsonb       NOT NULL DEFAULT '[]',
+    editor_id      UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at     timestamptz NOT NULL DEFAULT now(),
+    finished_at    timestamptz
+);
+
+-- secret token of each user's subscribable calendar of trips
+CREATE TABLE calendar_feed (
+    user_id    UUID        PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token      text        NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- people planning a trip together, the creator is its owner
+CREATE TABLE trip_member (
+    trip_id    UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    user_id    UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- viewers can see the trip, editors change it, the owner also deletes it and manages members
+    role       text        NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (trip_id, user_id)
+);
+
+CREATE INDEX trip_member_user_id_idx ON trip_member (user_id);
+
+-- invitations to join a trip, accepted or declined by the user with the email
+CREATE TABLE trip_invitation (
+    id           UUID        PRIMARY KEY,
+    trip_id      UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    email        text        NOT NULL,
+    role         text        NOT NULL CHECK (role IN ('editor', 'viewer')),
+    token        text        NOT NULL UNIQUE,
+    invited_by   UUID        REFERENCES users(id) ON DELETE SET NULL,
+    status       text        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    responded_at timestamptz
+);
+
+-- one pending invitation per email and trip
+CREATE UNIQUE INDEX trip_invitation_pending_idx
+    ON trip_invitation (trip_id, lower(email))
+    WHERE status = 'pending';
+
+-- public read only links to a trip, a trip can have several
+CREATE TABLE trip_share (
+    id             UUID        PRIMARY KEY,
+    trip_id        UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    token          text        NOT NULL UNIQUE,
+    -- bcrypt hash, NULL if the link needs no password
+    password_hash  text,
+    -- NULL if the link never expires
+    expires_at     timestamptz,
+    view_count     integer     NOT NULL DEFAULT 0,
+    last_viewed_at timestamptz,
+    created_by     UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX trip_share_trip_id_idx ON trip_share (trip_id);
+
+-- money spent on a trip, amounts are in minor units of their currency
+CREATE TABLE expense (
+    id         UUID        PRIMARY KEY,
+    trip_id    UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    amount     bigint      NOT NULL CHECK (amount > 0),
+    currency   text        NOT NULL,
+    category   text        NOT NULL CHECK (category IN ('lodging', 'transport', 'food', 'activities', 'shopping', 'fees', 'other')),
+    -- the member who paid, NULL once their account is deleted
+    paid_by    UUID        REFERENCES users(id) ON DELETE SET NULL,
+    spent_on   date        NOT NULL,
+    note       text        NOT NULL DEFAULT '',
+    created_by UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX expense_trip_id_idx ON expense (trip_id, spent_on);
+
+-- exchange rates managed by admins, one unit of base is worth rate units
+-- of quote, the opposite pair is derived from it when missing
+CREATE TABLE exchange_rate (
+    base       text          NOT NULL,
+    quote      text          NOT NULL,
+    rate       numeric(24, 12) NOT NULL CHECK (rate > 0),
+    updated_at timestamptz   NOT NULL DEFAULT now(),
+    PRIMARY KEY (base, quote),
+    CHECK (base <> quote)
+);
+
+-- people sharing the costs of a trip, named so they don't need an account
+CREATE TABLE ledger_participant (
+    id         UUID        PRIMARY KEY,
+    trip_id    UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    name       text        NOT NULL,
+    -- the trip member the participant is, if any, the trip expenses they
+    -- paid count as their payments split equally between linked participants
+    user_id    UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX ledger_participant_name_idx ON ledger_participant (trip_id, lower(name));
+CREATE UNIQUE INDEX ledger_participant_user_id_idx ON ledger_participant (trip_id, user_id);
+
+-- a payment made by a participant for others, amounts are in minor units
+-- of its currency. Settlements are paid whole to one participant
+CREATE TABLE ledger_payment (
+    id          UUID        PRIMARY KEY,
+    trip_id     UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    paid_by     UUID        NOT NULL REFERENCES ledger_participant(id),
+    amount      bigint      NOT NULL CHECK (amount > 0),
+    currency    text        NOT NULL,
+    description text        NOT NULL DEFAULT '',
+    split       text        NOT NULL CHECK (split IN ('equal', 'shares', 'exact', 'settlement')),
+    created_by  UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ledger_payment_trip_id_idx ON ledger_payment (trip_id, created_at);
+
+-- what each participant owes of a payment, the parts add up to its amount
+CREATE TABLE ledger_split (
+    payment_id     UUID    NOT NULL REFERENCES ledger_payment(id) ON DELETE CASCADE,
+    participant_id UUID    NOT NULL REFERENCES ledger_participant(id),
+    -- weight of the participant when split by shares, 1 otherwise
+    shares         integer NOT NULL DEFAULT 1 CHECK (shares > 0),
+    amount         bigint  NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (payment_id, participant_id)
+);
+
+CREATE INDEX ledger_split_participant_id_idx ON ledger_split (participant_id);
+
+-- timed activities of a trip's days, an activity can't span midnight
+CREATE TABLE activity (
+    id             UUID        PRIMARY KEY,
+    trip_id        UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    title          text        NOT NULL,
+    category       text        NOT NULL CHECK (category IN ('transport', 'meal', 'sight', 'lodging', 'activity', 'other')),
+    day            date        NOT NULL,
+    start_time     time        NOT NULL,
+    end_time       time        NOT NULL CHECK (end_time > start_time),
+    -- free text, for places that aren't destinations
+    location       text        NOT NULL DEFAULT '',
+    destination_id UUID        REFERENCES destination(id) ON DELETE SET NULL,
+    attraction_id  UUID        REFERENCES attraction(id) ON DELETE SET NULL,
+    notes          text        NOT NULL DEFAULT '',
+    created_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX activity_trip_id_idx ON activity (trip_id, day, start_time);
+
+-- flights, hotels, cars and other reservations of a trip
+CREATE TABLE booking (
+    id                UUID        PRIMARY KEY,
+    trip_id           UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    kind              text        NOT NULL CHECK (kind IN ('flight', 'lodging', 'car', 'train', 'bus', 'restaurant', 'event', 'other')),
+    title             text        NOT NULL,
+    provider          text        NOT NULL DEFAULT '',
+    confirmation_code text        NOT NULL DEFAULT '',
+    -- zones are where the booking starts and ends, an IANA name such as
+    -- Europe/Paris or an offset such as -08:00
+    starts_at         timestamptz NOT NULL,
+    start_zone        text        NOT NULL,
+    ends_at           timestamptz,
+    end_zone          text,
+    address           text        NOT NULL DEFAULT '',
+    -- in minor units of currency, NULL if unknown
+    cost              bigint      CHECK (cost >= 0),
+    currency          text,
+    notes             text        NOT NULL DEFAULT '',
+    created_by        UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at        timestamptz NOT NULL DEFAULT now(),
+    -- bumped by every update so calendar apps pick up the changes
+    updated_at        timestamptz NOT NULL DEFAULT now(),
+    sequence          integer     NOT NULL DEFAULT 0,
+    CHECK (ends_at IS NULL OR ends_at >= starts_at),
+    CHECK ((ends_at IS NULL) = (end_zone IS NULL)),
+    CHECK ((cost IS NULL) = (currency IS NULL))
+);
+
+CREATE INDEX booking_trip_id_idx ON booking (trip_id, starts_at);
+
+-- confirmations and tickets of a booking, kept in the media store
+CREATE TABLE booking_attachment (
+    id           UUID        PRIMARY KEY,
+    booking_id   UUID        NOT NULL REFERENCES booking(id) ON DELETE CASCADE,
+    key          text        NOT NULL,
+    filename     text        NOT NULL,
+    content_type text        NOT NULL,
+    size         bigint      NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX booking_attachment_booking_id_idx ON booking_attachment (booking_id);
+
+-- reusable checklists such as packing lists
+CREATE TABLE checklist_template (
+    id          UUID        PRIMARY KEY,
+    -- NULL for templates provided by admins, seen by everyone
+    user_id     UUID        REFERENCES users(id) ON DELETE CASCADE,
+    name        text        NOT NULL,
+    description text        NOT NULL DEFAULT '',
+    -- suggested for trips lasting at least and at most these many days,
+    -- NULL for no bound
+    min_days    integer     CHECK (min_days > 0),
+    max_days    integer     CHECK (max_days > 0),
+    -- suggested for trips to destinations with this tag, NULL for any
+    tag_id      UUID        REFERENCES tag(id) ON DELETE SET NULL,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    CHECK (max_days >= min_days)
+);
+
+CREATE INDEX checklist_template_user_id_idx ON checklist_template (user_id);
+
+CREATE TABLE checklist_template_item (
+    id          UUID    PRIMARY KEY,
+    template_id UUID    NOT NULL REFERENCES checklist_template(id) ON DELETE CASCADE,
+    title       text    NOT NULL,
+    position    integer NOT NULL
+);
+
+CREATE INDEX checklist_template_item_template_id_idx ON checklist_template_item (template_id, position);
+
+-- checklists of a trip, made from a template or ad hoc
+CREATE TABLE checklist (
+    id          UUID        PRIMARY KEY,
+    trip_id     UUID        NOT NULL REFERENCES trip(id) ON DELETE CASCADE,
+    name        text        NOT NULL,
+    -- NULL for ad hoc checklists or once the template is deleted
+    template_id UUID        REFERENCES checklist_template(id) ON DELETE SET NULL,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX checklist_trip_id_idx ON checklist (trip_id);
+
+CREATE TABLE checklist_item (
+    id           UUID        PRIMARY KEY,
+    checklist_id UUID        NOT NULL REFERENCES checklist(id) ON DELETE CASCADE,
+    title        text        NOT NULL,
+    -- member of the trip taking care of it, NULL for anyone
+    assignee_id  UUID        REFERENCES users(id) ON DELETE SET NULL,
+    done         boolean     NOT NULL DEFAULT false,
+    -- when it was last ticked off, NULL while not done
+    done_at      timestamptz,
+    position     integer     NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX checklist_item_checklist_id_idx ON checklist_item (checklist_id, position);